@@ -0,0 +1,481 @@
+package bittrex
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+)
+
+//ArbitrageLeg one trade of a cycle, turning From into To on Market: a buy when From is the base currency, a sell
+//otherwise. Price is the best price on the side of the book the leg takes from. Depth-adjusted, Quantity is the
+//market currency traded at the cycle's Size and Limit the worst price it reaches.
+type ArbitrageLeg struct {
+	Market   Market
+	Side     OrderSide
+	From     string
+	To       string
+	Price    *big.Float
+	Quantity *big.Float
+	Limit    *big.Float
+}
+
+//ArbitrageOpportunity a triangular cycle that ends with more of its start currency than it began with, after
+//commission on every leg. Currencies lists the ones visited, start first and last (ex: USDT, BTC, LTC, USDT).
+//Return is the gain per unit of the start currency as a fraction (0.004 for 0.4%): at the top of the book for the
+//first unit, depth-adjusted on average over Size. Size is how much of the start currency the books take before the
+//cycle stops paying, and Profit what that earns; both are nil at the top of the book.
+type ArbitrageOpportunity struct {
+	Currencies []string
+	Legs       []ArbitrageLeg
+	Return     *big.Float
+	Size       *big.Float
+	Profit     *big.Float
+}
+
+type arbitrageQuote struct {
+	bid *big.Rat
+	ask *big.Rat
+}
+
+//ArbitrageScanner finds triangular arbitrage between the markets it was built from. Best bids and asks come from
+//Poll (/public/getmarketsummaries) or from summaries passed to OnSummary, ex: live summary deltas from
+//WsSubSummaryDeltas through Follow; Opportunities ranks the cycles they make profitable, and Depth sizes one against
+//the order books. Set Commission and MinReturn before use. Safe for concurrent use.
+type ArbitrageScanner struct {
+	//Commission charged on the base currency total of every leg. Defaults to BittrexCommission.
+	Commission *big.Float
+
+	//MinReturn smallest Return reported. Nil reports every cycle with a positive one.
+	MinReturn *big.Float
+
+	//PublicGetMarketSummaries and PublicGetOrderBook outside tests.
+	summaries func() ([]MarketSummary, error)
+	book      func(market string) (OrderBook, error)
+
+	cycles [][]ArbitrageLeg
+
+	mutex  sync.Mutex
+	quotes map[string]arbitrageQuote
+}
+
+//NewArbitrageScanner scanner over every active market, starting cycles from the given currencies (ex: "USDT").
+//Without any, cycles start from the base currencies, BTC before ETH before USDT. A cycle is reported once, from the
+//first start currency it visits.
+func (c *Client) NewArbitrageScanner(start ...string) (*ArbitrageScanner, error) {
+	markets, err := c.PublicGetMarkets()
+	if err != nil {
+		return nil, err
+	}
+
+	base := c.session()
+
+	summaries := func() ([]MarketSummary, error) {
+		return base.session().PublicGetMarketSummaries()
+	}
+
+	book := func(market string) (OrderBook, error) {
+		return base.session().PublicGetOrderBook(market, OrderBookBoth)
+	}
+
+	return newArbitrageScanner(markets, start, summaries, book), nil
+}
+
+func newArbitrageScanner(markets []MarketDescription, start []string, summaries func() ([]MarketSummary, error), book func(string) (OrderBook, error)) *ArbitrageScanner {
+	s := &ArbitrageScanner{
+		Commission: new(big.Float).Set(BittrexCommission),
+		summaries:  summaries,
+		book:       book,
+		quotes:     make(map[string]arbitrageQuote),
+	}
+
+	//graph[a][b] the market trading a for b.
+	graph := make(map[string]map[string]Market)
+	bases := make(map[string]bool)
+
+	link := func(a, b string, market Market) {
+		if graph[a] == nil {
+			graph[a] = make(map[string]Market)
+		}
+		graph[a][b] = market
+	}
+
+	for _, description := range markets {
+		market, err := ParseMarket(description.MarketName)
+		if !description.IsActive || err != nil {
+			continue
+		}
+
+		link(market.Base(), market.Quote(), market)
+		link(market.Quote(), market.Base(), market)
+		bases[market.Base()] = true
+	}
+
+	if len(start) == 0 {
+		for currency := range bases {
+			start = append(start, currency)
+		}
+		sort.Strings(start)
+	}
+
+	priority := make(map[string]int, len(start))
+	for i, currency := range start {
+		if _, ok := priority[currency]; !ok {
+			priority[currency] = i
+		}
+	}
+
+	//a cycle through an earlier start currency was already found from it.
+	earlier := func(currency string, than int) bool {
+		i, ok := priority[currency]
+		return ok && i < than
+	}
+
+	for i, first := range start {
+		if priority[first] != i {
+			continue
+		}
+
+		for _, second := range sortedNeighbours(graph[first]) {
+			if earlier(second, i) {
+				continue
+			}
+
+			for _, third := range sortedNeighbours(graph[second]) {
+				closing, ok := graph[third][first]
+				if third == first || earlier(third, i) || !ok {
+					continue
+				}
+
+				s.cycles = append(s.cycles, []ArbitrageLeg{
+					arbitrageLeg(graph[first][second], first, second),
+					arbitrageLeg(graph[second][third], second, third),
+					arbitrageLeg(closing, third, first),
+				})
+			}
+		}
+	}
+
+	return s
+}
+
+func sortedNeighbours(neighbours map[string]Market) []string {
+	currencies := make([]string, 0, len(neighbours))
+	for currency := range neighbours {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	return currencies
+}
+
+func arbitrageLeg(market Market, from, to string) ArbitrageLeg {
+	side := OrderSideSell
+	if market.Base() == from {
+		side = OrderSideBuy
+	}
+
+	return ArbitrageLeg{Market: market, Side: side, From: from, To: to}
+}
+
+//Cycles how many cycles the scanner evaluates.
+func (s *ArbitrageScanner) Cycles() int {
+	return len(s.cycles)
+}
+
+//OnSummary take the best bid and ask of summary's market.
+func (s *ArbitrageScanner) OnSummary(summary MarketSummary) {
+	if summary.Bid == nil || summary.Ask == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.quotes[summary.MarketName] = arbitrageQuote{decimal(summary.Bid), decimal(summary.Ask)}
+}
+
+//OnSummaryState OnSummary for every delta of state.
+func (s *ArbitrageScanner) OnSummaryState(state SummaryState) {
+	for _, summary := range state.Deltas {
+		s.OnSummary(summary)
+	}
+}
+
+//Follow feed every state from states (ex: a SummarySubscription's Data) to the scanner in the background, until
+//states is closed.
+func (s *ArbitrageScanner) Follow(states <-chan SummaryState) {
+	go func() {
+		for state := range states {
+			s.OnSummaryState(state)
+		}
+	}()
+}
+
+//Poll fetch every market summary.
+func (s *ArbitrageScanner) Poll() error {
+	summaries, err := s.summaries()
+	if err != nil {
+		return err
+	}
+
+	for _, summary := range summaries {
+		s.OnSummary(summary)
+	}
+
+	return nil
+}
+
+//legRate how much To a unit of From buys at price, after commission: buys pay it on top of the total,
+//sells have it taken off.
+func legRate(side OrderSide, price, commission *big.Rat) *big.Rat {
+	one := big.NewRat(1, 1)
+
+	if side == OrderSideBuy {
+		cost := new(big.Rat).Mul(price, new(big.Rat).Add(one, commission))
+		return cost.Inv(cost)
+	}
+
+	return new(big.Rat).Mul(price, new(big.Rat).Sub(one, commission))
+}
+
+//Opportunities every cycle whose top of book Return is above MinReturn, best first.
+func (s *ArbitrageScanner) Opportunities() []ArbitrageOpportunity {
+	commission := decimal(s.Commission)
+
+	minimum := new(big.Rat)
+	if s.MinReturn != nil {
+		minimum = decimal(s.MinReturn)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var opportunities []ArbitrageOpportunity
+
+	for _, cycle := range s.cycles {
+		legs := append([]ArbitrageLeg(nil), cycle...)
+		growth := big.NewRat(1, 1)
+
+		for i := range legs {
+			quote, ok := s.quotes[legs[i].Market.String()]
+			price := quote.ask
+			if legs[i].Side == OrderSideSell {
+				price = quote.bid
+			}
+
+			if !ok || price.Sign() <= 0 {
+				growth = nil
+				break
+			}
+
+			legs[i].Price = ratAmount(price)
+			growth.Mul(growth, legRate(legs[i].Side, price, commission))
+		}
+
+		if growth == nil {
+			continue
+		}
+
+		if ret := growth.Sub(growth, big.NewRat(1, 1)); ret.Sign() > 0 && ret.Cmp(minimum) > 0 {
+			opportunities = append(opportunities, ArbitrageOpportunity{
+				Currencies: cycleCurrencies(legs),
+				Legs:       legs,
+				Return:     ratAmount(ret),
+			})
+		}
+	}
+
+	rankArbitrage(opportunities)
+	return opportunities
+}
+
+func cycleCurrencies(legs []ArbitrageLeg) []string {
+	currencies := []string{legs[0].From}
+	for _, leg := range legs {
+		currencies = append(currencies, leg.To)
+	}
+	return currencies
+}
+
+func rankArbitrage(opportunities []ArbitrageOpportunity) {
+	sort.SliceStable(opportunities, func(i, j int) bool {
+		return opportunities[i].Return.Cmp(opportunities[j].Return) > 0
+	})
+}
+
+//Depth size opportunity against the current order books of its legs.
+func (s *ArbitrageScanner) Depth(opportunity ArbitrageOpportunity) (ArbitrageOpportunity, error) {
+	books := make([]OrderBook, len(opportunity.Legs))
+
+	for i, leg := range opportunity.Legs {
+		book, err := s.book(leg.Market.String())
+		if err != nil {
+			return ArbitrageOpportunity{}, err
+		}
+		books[i] = book
+	}
+
+	return arbitrageDepth(opportunity, books, decimal(s.Commission))
+}
+
+//Scan Poll, then rank the opportunities. With depth, each is sized by Depth and re-ranked by its depth-adjusted
+//Return; those the books can't execute at a profit are left out.
+func (s *ArbitrageScanner) Scan(depth bool) ([]ArbitrageOpportunity, error) {
+	if err := s.Poll(); err != nil {
+		return nil, err
+	}
+
+	opportunities := s.Opportunities()
+	if !depth {
+		return opportunities, nil
+	}
+
+	minimum := new(big.Rat)
+	if s.MinReturn != nil {
+		minimum = decimal(s.MinReturn)
+	}
+
+	var sized []ArbitrageOpportunity
+	for _, opportunity := range opportunities {
+		opportunity, err := s.Depth(opportunity)
+		if err == ErrOrderBookEmpty {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if opportunity.Size.Sign() > 0 && decimal(opportunity.Return).Cmp(minimum) > 0 {
+			sized = append(sized, opportunity)
+		}
+	}
+
+	rankArbitrage(sized)
+	return sized, nil
+}
+
+//arbitrageLevel one order of a leg's book, as a rate of To per From after commission and how much From it takes.
+type arbitrageLevel struct {
+	price    *big.Rat
+	rate     *big.Rat
+	capacity *big.Rat
+}
+
+func arbitrageLevels(leg ArbitrageLeg, book OrderBook, commission *big.Rat) []arbitrageLevel {
+	orders := book.asks()
+	if leg.Side == OrderSideSell {
+		orders = book.bids()
+	}
+
+	levels := make([]arbitrageLevel, 0, len(orders))
+	for _, order := range orders {
+		price, quantity := decimal(order.Rate), decimal(order.Quantity)
+		if price.Sign() <= 0 || quantity.Sign() <= 0 {
+			continue
+		}
+
+		rate := legRate(leg.Side, price, commission)
+
+		//buys spend base currency, the order's quantity at the rate this leg gets for it; sells spend the quantity.
+		capacity := quantity
+		if leg.Side == OrderSideBuy {
+			capacity = new(big.Rat).Quo(quantity, rate)
+		}
+
+		levels = append(levels, arbitrageLevel{price, rate, capacity})
+	}
+
+	return levels
+}
+
+//arbitrageDepth push the start currency through the books (one per leg) for as long as the next unit still comes
+//back larger, consuming the levels of every leg together.
+func arbitrageDepth(opportunity ArbitrageOpportunity, books []OrderBook, commission *big.Rat) (ArbitrageOpportunity, error) {
+	one := big.NewRat(1, 1)
+	legs := append([]ArbitrageLeg(nil), opportunity.Legs...)
+
+	if len(books) != len(legs) {
+		return ArbitrageOpportunity{}, ErrOrderBookEmpty
+	}
+
+	ladders := make([][]arbitrageLevel, len(books))
+	for i, leg := range legs {
+		if ladders[i] = arbitrageLevels(leg, books[i], commission); len(ladders[i]) == 0 {
+			return ArbitrageOpportunity{}, ErrOrderBookEmpty
+		}
+	}
+
+	at := make([]int, len(ladders))
+	used := make([]*big.Rat, len(ladders))
+	quantity := make([]*big.Rat, len(ladders))
+	for i := range ladders {
+		used[i], quantity[i] = new(big.Rat), new(big.Rat)
+	}
+
+	size, returned := new(big.Rat), new(big.Rat)
+
+	for exhausted := false; !exhausted; {
+		growth := big.NewRat(1, 1)
+		for i, ladder := range ladders {
+			growth.Mul(growth, ladder[at[i]].rate)
+		}
+
+		if growth.Cmp(one) <= 0 {
+			break
+		}
+
+		//the most start currency every leg's current level can take, in start currency.
+		var take *big.Rat
+		scale := big.NewRat(1, 1)
+
+		for i, ladder := range ladders {
+			left := new(big.Rat).Sub(ladder[at[i]].capacity, used[i])
+			if left.Quo(left, scale); take == nil || left.Cmp(take) < 0 {
+				take = left
+			}
+			scale.Mul(scale, ladder[at[i]].rate)
+		}
+
+		size.Add(size, take)
+
+		flow := new(big.Rat).Set(take)
+		for i, ladder := range ladders {
+			level := ladder[at[i]]
+
+			if legs[i].Side == OrderSideBuy {
+				quantity[i].Add(quantity[i], new(big.Rat).Mul(flow, level.rate))
+			} else {
+				quantity[i].Add(quantity[i], flow)
+			}
+			legs[i].Limit = ratAmount(level.price)
+
+			if used[i].Add(used[i], flow); used[i].Cmp(level.capacity) >= 0 {
+				used[i] = new(big.Rat)
+				if at[i]++; at[i] == len(ladder) {
+					exhausted = true
+				}
+			}
+
+			flow.Mul(flow, level.rate)
+		}
+
+		returned.Add(returned, flow)
+	}
+
+	for i := range legs {
+		legs[i].Price = ratAmount(ladders[i][0].price)
+		legs[i].Quantity = ratAmount(quantity[i])
+	}
+	opportunity.Legs = legs
+
+	profit := new(big.Rat).Sub(returned, size)
+	opportunity.Size = ratAmount(size)
+	opportunity.Profit = ratAmount(profit)
+
+	if size.Sign() > 0 {
+		opportunity.Return = ratAmount(profit.Quo(profit, size))
+	} else {
+		opportunity.Return = newAmount()
+	}
+
+	return opportunity, nil
+}
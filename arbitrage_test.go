@@ -0,0 +1,141 @@
+package bittrex
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func arbitrageMarkets() []MarketDescription {
+	return []MarketDescription{
+		{MarketName: "USDT-BTC", IsActive: true},
+		{MarketName: "BTC-LTC", IsActive: true},
+		{MarketName: "USDT-LTC", IsActive: true},
+		{MarketName: "ETH-LTC", IsActive: false},
+	}
+}
+
+func arbitrageSummaries() []MarketSummary {
+	return []MarketSummary{
+		{MarketName: "USDT-BTC", Bid: dec("9990"), Ask: dec("10000")},
+		{MarketName: "BTC-LTC", Bid: dec("0.0099"), Ask: dec("0.01")},
+		{MarketName: "USDT-LTC", Bid: dec("102"), Ask: dec("103")},
+	}
+}
+
+func TestArbitrageCycles(t *testing.T) {
+	//every triangle once per direction, from the first start currency in it; inactive markets are left out.
+	if cycles := newArbitrageScanner(arbitrageMarkets(), nil, nil, nil).Cycles(); cycles != 2 {
+		t.Errorf("expected 2 cycles from the base currencies, got %d", cycles)
+	}
+
+	s := newArbitrageScanner(arbitrageMarkets(), []string{"USDT", "BTC"}, nil, nil)
+	if s.Cycles() != 2 {
+		t.Fatalf("expected 2 cycles from USDT, got %d", s.Cycles())
+	}
+
+	for _, summary := range arbitrageSummaries() {
+		s.OnSummary(summary)
+	}
+
+	opportunities := s.Opportunities()
+	if len(opportunities) != 1 {
+		t.Fatalf("expected only the USDT, BTC, LTC cycle to pay, got %+v", opportunities)
+	}
+
+	opportunity := opportunities[0]
+	if !reflect.DeepEqual(opportunity.Currencies, []string{"USDT", "BTC", "LTC", "USDT"}) {
+		t.Errorf("unexpected cycle %v", opportunity.Currencies)
+	}
+
+	sides := []OrderSide{opportunity.Legs[0].Side, opportunity.Legs[1].Side, opportunity.Legs[2].Side}
+	if !reflect.DeepEqual(sides, []OrderSide{OrderSideBuy, OrderSideBuy, OrderSideSell}) {
+		t.Errorf("unexpected sides %v", sides)
+	}
+
+	//102 * 0.9975 / (10000 * 1.0025 * 0.01 * 1.0025) - 1
+	if got := opportunity.Return.Text('f', 10); got != "0.0123817638" {
+		t.Errorf("expected a return of 0.0123817638, got %s", got)
+	}
+
+	s.MinReturn = dec("0.02")
+	if opportunities := s.Opportunities(); len(opportunities) != 0 {
+		t.Errorf("expected MinReturn to filter the cycle out, got %+v", opportunities)
+	}
+}
+
+func TestArbitrageDepth(t *testing.T) {
+	books := map[string]OrderBook{
+		"USDT-BTC": {Sell: []OrderElement{{Rate: dec("10100"), Quantity: dec("1")}, {Rate: dec("10000"), Quantity: dec("0.05")}}},
+		"BTC-LTC":  {Sell: []OrderElement{{Rate: dec("0.01"), Quantity: dec("30")}, {Rate: dec("0.0105"), Quantity: dec("100")}}},
+		"USDT-LTC": {Buy: []OrderElement{{Rate: dec("102"), Quantity: dec("40")}, {Rate: dec("95"), Quantity: dec("100")}}},
+	}
+
+	s := newArbitrageScanner(
+		arbitrageMarkets(),
+		[]string{"USDT"},
+		func() ([]MarketSummary, error) { return arbitrageSummaries(), nil },
+		func(market string) (OrderBook, error) { return books[market], nil },
+	)
+
+	opportunities, err := s.Scan(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(opportunities) != 1 {
+		t.Fatalf("expected one sized opportunity, got %+v", opportunities)
+	}
+
+	//all 0.05 BTC at 10000 and 0.25075 at 10100 buy the 30 LTC at 0.01, sold at 102; 0.0105 no longer pays.
+	opportunity := opportunities[0]
+	expectDecimal(t, "size", opportunity.Size, "3040.1564375")
+	expectDecimal(t, "profit", opportunity.Profit, "12.1935625")
+
+	for i, want := range []struct{ quantity, price, limit string }{
+		{"0.30075", "10000", "10100"},
+		{"30", "0.01", "0.01"},
+		{"30", "102", "102"},
+	} {
+		leg := opportunity.Legs[i]
+		expectDecimal(t, leg.Market.String()+" quantity", leg.Quantity, want.quantity)
+		expectDecimal(t, leg.Market.String()+" price", leg.Price, want.price)
+		expectDecimal(t, leg.Market.String()+" limit", leg.Limit, want.limit)
+	}
+
+	//a cycle the books can't fill at a profit is not reported
+	books["USDT-LTC"] = OrderBook{Buy: []OrderElement{{Rate: dec("99"), Quantity: dec("40")}}}
+	if opportunities, err := s.Scan(true); err != nil || len(opportunities) != 0 {
+		t.Errorf("expected nothing executable, got %+v, %v", opportunities, err)
+	}
+
+	s.book = func(string) (OrderBook, error) { return OrderBook{}, errors.New("down") }
+	if _, err := s.Scan(true); err == nil {
+		t.Error("expected the order book error")
+	}
+}
+
+func TestArbitrageDepthLimitedByBook(t *testing.T) {
+	legs := newArbitrageScanner(arbitrageMarkets(), []string{"USDT"}, nil, nil).cycles[0]
+
+	//books running out before the cycle stops paying: the size is what they hold.
+	books := []OrderBook{
+		{Sell: []OrderElement{{Rate: dec("10000"), Quantity: dec("0.01")}}},
+		{Sell: []OrderElement{{Rate: dec("0.01"), Quantity: dec("100")}}},
+		{Buy: []OrderElement{{Rate: dec("102"), Quantity: dec("100")}}},
+	}
+
+	opportunity, err := arbitrageDepth(ArbitrageOpportunity{Legs: legs}, books, new(big.Rat))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectDecimal(t, "size", opportunity.Size, "100")
+	expectDecimal(t, "profit", opportunity.Profit, "2")
+	expectDecimal(t, "return", opportunity.Return, "0.02")
+
+	if _, err := arbitrageDepth(ArbitrageOpportunity{Legs: legs}, books[:2], new(big.Rat)); err == nil {
+		t.Error("expected an error for a missing book")
+	}
+}
@@ -0,0 +1,500 @@
+package bittrex
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+//BittrexCommission fee charged on the base currency total of every trade (0.25%). Constructors defaulting to it take
+//a copy, so setting it only changes what is created afterwards.
+var BittrexCommission = big.NewFloat(0.0025)
+
+//amountPrecision mantissa bits for balances and totals computed locally, well above the 8 decimals Bittrex uses.
+const amountPrecision = 128
+
+func newAmount() *big.Float {
+	return new(big.Float).SetPrec(amountPrecision)
+}
+
+//Broker places and tracks limit orders for a Strategy. A SimulatedAccount implements it for backtests and
+//Client.Broker for live trading, so the same strategy runs against both.
+type Broker interface {
+	BuyLimit(market string, quantity *big.Float, rate *big.Float) (string, error)
+	SellLimit(market string, quantity *big.Float, rate *big.Float) (string, error)
+	Cancel(uuid string) error
+	OpenOrders(market string) ([]OrderDescription, error)
+	Balance(currency string) (AccountBalance, error)
+}
+
+//Strategy receives every closed candle of a market and trades through broker. Returning an error stops the run.
+type Strategy interface {
+	OnCandle(broker Broker, market string, candle Candle) error
+}
+
+//ErrorHandler optionally implemented by a Strategy run live, to be told about stream errors (ex: TradeGapError).
+type ErrorHandler interface {
+	OnError(err error)
+}
+
+type clientBroker struct {
+	client *Client
+}
+
+//Broker live Broker placing real orders through the client.
+func (c *Client) Broker() Broker {
+	return clientBroker{c}
+}
+
+func (b clientBroker) BuyLimit(market string, quantity *big.Float, rate *big.Float) (string, error) {
+	id, err := b.client.MarketBuyLimit(market, quantity, rate)
+	return id.UUID, err
+}
+
+func (b clientBroker) SellLimit(market string, quantity *big.Float, rate *big.Float) (string, error) {
+	id, err := b.client.MarketSellLimit(market, quantity, rate)
+	return id.UUID, err
+}
+
+func (b clientBroker) Cancel(uuid string) error {
+	_, err := b.client.MarketCancel(uuid)
+	return err
+}
+
+func (b clientBroker) OpenOrders(market string) ([]OrderDescription, error) {
+	return b.client.MarketGetOpenOrders(market)
+}
+
+func (b clientBroker) Balance(currency string) (AccountBalance, error) {
+	return b.client.AccountGetBalance(currency)
+}
+
+//RunStrategy run strategy live: every Final candle of a CandleStream for market and interval is passed to it,
+//with Client.Broker as its broker, until ctx is done or the strategy returns an error.
+func (c *Client) RunStrategy(ctx context.Context, market string, interval time.Duration, strategy Strategy) error {
+	stream := c.WsCandles(market, interval)
+	defer stream.Close(context.Background())
+
+	broker := c.Broker()
+	handler, _ := strategy.(ErrorHandler)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err, ok := <-stream.Error:
+			if ok && handler != nil {
+				handler.OnError(err)
+			}
+		case update, ok := <-stream.Updates:
+			if !ok {
+				return fmt.Errorf("candle stream for %s closed", market)
+			}
+			if !update.Final {
+				continue
+			}
+			if err := strategy.OnCandle(broker, market, update.Candle); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//BacktestFill one executed order in a backtest's trade log. ProfitLoss is set on sells: proceeds after
+//commission minus the average cost of the quantity sold.
+type BacktestFill struct {
+	OrderUUID  string
+	OrderType  OrderType //OrderTypeLimitBuy or OrderTypeLimitSell
+	TimeStamp  BittrexTimestamp
+	Quantity   *big.Float
+	Price      *big.Float
+	Commission *big.Float
+	Total      *big.Float //Quantity * Price, without commission
+	ProfitLoss *big.Float
+}
+
+//EquityPoint account value in the base currency at a candle's close.
+type EquityPoint struct {
+	TimeStamp BittrexTimestamp
+	Equity    *big.Float
+}
+
+//SimulatedAccount a Broker holding balances and limit orders for one market, filled against candles.
+//Orders must meet the market's MinTradeSize and are reserved in full (buys including commission) when placed.
+//An order placed on a candle can fill from the next candle on: a buy fills when the candle's Low reaches its limit,
+//at the limit or at the Open if the candle opened below it; sells mirror this on the High. Orders fill completely.
+type SimulatedAccount struct {
+	Market     MarketDescription
+	Commission *big.Float
+
+	balances map[string]*big.Float
+	orders   []*OrderDescription
+	fills    []BacktestFill
+	now      BittrexTimestamp
+	nextID   int
+
+	//position and cost track the average cost of the market currency held, for ProfitLoss.
+	position *big.Float
+	cost     *big.Float
+}
+
+//NewSimulatedAccount account trading market with the given starting balances, charging BittrexCommission.
+func NewSimulatedAccount(market MarketDescription, balances map[string]*big.Float) *SimulatedAccount {
+	a := &SimulatedAccount{
+		Market:     market,
+		Commission: new(big.Float).Set(BittrexCommission),
+		balances:   make(map[string]*big.Float),
+		position:   newAmount(),
+		cost:       newAmount(),
+	}
+
+	for currency, balance := range balances {
+		a.balances[currency] = newAmount().Set(balance)
+	}
+
+	return a
+}
+
+func (a *SimulatedAccount) balance(currency string) *big.Float {
+	if balance, ok := a.balances[currency]; ok {
+		return balance
+	}
+
+	balance := newAmount()
+	a.balances[currency] = balance
+	return balance
+}
+
+//reserved amount of currency held by open orders.
+func (a *SimulatedAccount) reserved(currency string) *big.Float {
+	total := newAmount()
+
+	for _, order := range a.orders {
+		if order.OrderType == OrderTypeLimitBuy && currency == a.Market.BaseCurrency {
+			total.Add(total, a.buyCost(order.Quantity, order.Limit))
+		} else if order.OrderType == OrderTypeLimitSell && currency == a.Market.MarketCurrency {
+			total.Add(total, order.Quantity)
+		}
+	}
+
+	return total
+}
+
+//buyCost base currency needed to buy quantity at rate, commission included.
+func (a *SimulatedAccount) buyCost(quantity, rate *big.Float) *big.Float {
+	total := newAmount().Mul(quantity, rate)
+	return total.Add(total, a.commission(total))
+}
+
+func (a *SimulatedAccount) commission(total *big.Float) *big.Float {
+	return newAmount().Mul(total, a.Commission)
+}
+
+func (a *SimulatedAccount) place(orderType OrderType, market string, quantity *big.Float, rate *big.Float) (string, error) {
+	location := "backtest - " + orderType.String()
+
+	if market != a.Market.MarketName {
+		return "", &bittrexError{location, "INVALID_MARKET"}
+	}
+
+	if quantity == nil || rate == nil || quantity.Sign() <= 0 || rate.Sign() <= 0 {
+		return "", &bittrexError{location, "INVALID_QUANTITY_OR_RATE"}
+	}
+
+	if a.Market.MinTradeSize != nil && quantity.Cmp(a.Market.MinTradeSize) < 0 {
+		return "", &bittrexError{location, "MIN_TRADE_REQUIREMENT_NOT_MET"}
+	}
+
+	currency, needed := a.Market.BaseCurrency, a.buyCost(quantity, rate)
+	if orderType == OrderTypeLimitSell {
+		currency, needed = a.Market.MarketCurrency, quantity
+	}
+
+	available := newAmount().Sub(a.balance(currency), a.reserved(currency))
+	if available.Cmp(needed) < 0 {
+		return "", &bittrexError{location, "INSUFFICIENT_FUNDS"}
+	}
+
+	a.nextID++
+	uuid := fmt.Sprintf("backtest-%d", a.nextID)
+
+	a.orders = append(a.orders, &OrderDescription{
+		OrderUUID:         uuid,
+		Exchange:          market,
+		OrderType:         orderType,
+		Quantity:          newAmount().Set(quantity),
+		QuantityRemaining: newAmount().Set(quantity),
+		Limit:             newAmount().Set(rate),
+		CommissionPaid:    newAmount(),
+		Price:             newAmount(),
+		Opened:            a.now,
+	})
+
+	return uuid, nil
+}
+
+//BuyLimit place a simulated limit buy.
+func (a *SimulatedAccount) BuyLimit(market string, quantity *big.Float, rate *big.Float) (string, error) {
+	return a.place(OrderTypeLimitBuy, market, quantity, rate)
+}
+
+//SellLimit place a simulated limit sell.
+func (a *SimulatedAccount) SellLimit(market string, quantity *big.Float, rate *big.Float) (string, error) {
+	return a.place(OrderTypeLimitSell, market, quantity, rate)
+}
+
+//Cancel an open simulated order.
+func (a *SimulatedAccount) Cancel(uuid string) error {
+	for i, order := range a.orders {
+		if order.OrderUUID == uuid {
+			a.orders = append(a.orders[:i], a.orders[i+1:]...)
+			return nil
+		}
+	}
+
+	return &bittrexError{"backtest - cancel", "ORDER_NOT_OPEN"}
+}
+
+//OpenOrders open simulated orders, oldest first.
+func (a *SimulatedAccount) OpenOrders(market string) ([]OrderDescription, error) {
+	var open []OrderDescription
+
+	for _, order := range a.orders {
+		if order.Exchange == market {
+			open = append(open, *order)
+		}
+	}
+
+	return open, nil
+}
+
+//Balance of currency. Available excludes what open orders reserve.
+func (a *SimulatedAccount) Balance(currency string) (AccountBalance, error) {
+	balance := newAmount().Set(a.balance(currency))
+
+	return AccountBalance{
+		Currency:  currency,
+		Balance:   balance,
+		Available: newAmount().Sub(balance, a.reserved(currency)),
+		Pending:   newAmount(),
+	}, nil
+}
+
+//Fills the trade log so far.
+func (a *SimulatedAccount) Fills() []BacktestFill {
+	return append([]BacktestFill(nil), a.fills...)
+}
+
+//Equity account value in the base currency with the market currency valued at price.
+func (a *SimulatedAccount) Equity(price *big.Float) *big.Float {
+	equity := newAmount().Mul(a.balance(a.Market.MarketCurrency), price)
+	return equity.Add(equity, a.balance(a.Market.BaseCurrency))
+}
+
+//fillAgainst execute the open orders that candle reaches, oldest first.
+func (a *SimulatedAccount) fillAgainst(candle Candle) {
+	var open []*OrderDescription
+
+	for _, order := range a.orders {
+		var price *big.Float
+
+		if order.OrderType == OrderTypeLimitBuy && candle.Low.Cmp(order.Limit) <= 0 {
+			price = minFloat(candle.Open, order.Limit)
+		} else if order.OrderType == OrderTypeLimitSell && candle.High.Cmp(order.Limit) >= 0 {
+			price = maxFloat(candle.Open, order.Limit)
+		}
+
+		if price == nil {
+			open = append(open, order)
+			continue
+		}
+
+		a.execute(order, price, candle.TimeStamp)
+	}
+
+	a.orders = open
+}
+
+func (a *SimulatedAccount) execute(order *OrderDescription, price *big.Float, timestamp BittrexTimestamp) {
+	quantity := order.Quantity
+	total := newAmount().Mul(quantity, price)
+	commission := a.commission(total)
+
+	base := a.balance(a.Market.BaseCurrency)
+	held := a.balance(a.Market.MarketCurrency)
+
+	fill := BacktestFill{
+		OrderUUID:  order.OrderUUID,
+		OrderType:  order.OrderType,
+		TimeStamp:  timestamp,
+		Quantity:   newAmount().Set(quantity),
+		Price:      newAmount().Set(price),
+		Commission: commission,
+		Total:      total,
+	}
+
+	if order.OrderType == OrderTypeLimitBuy {
+		spent := newAmount().Add(total, commission)
+		base.Sub(base, spent)
+		held.Add(held, quantity)
+
+		a.position.Add(a.position, quantity)
+		a.cost.Add(a.cost, spent)
+	} else {
+		proceeds := newAmount().Sub(total, commission)
+		base.Add(base, proceeds)
+		held.Sub(held, quantity)
+
+		basis := newAmount()
+		if a.position.Sign() > 0 {
+			basis.Quo(newAmount().Mul(a.cost, quantity), a.position)
+			a.cost.Sub(a.cost, basis)
+			a.position.Sub(a.position, quantity)
+		}
+
+		fill.ProfitLoss = proceeds.Sub(proceeds, basis)
+	}
+
+	a.fills = append(a.fills, fill)
+}
+
+//BacktestMetrics summary of a backtest. Return and MaxDrawdown are fractions (0.1 is 10%). Sharpe is the
+//annualized mean over standard deviation of per-candle equity returns, with no risk free rate. WinRate is the
+//fraction of sells with a positive ProfitLoss.
+type BacktestMetrics struct {
+	Return      float64
+	MaxDrawdown float64
+	Sharpe      float64
+	WinRate     float64
+	Trades      int
+}
+
+//BacktestResult output of RunBacktest.
+type BacktestResult struct {
+	Fills   []BacktestFill
+	Equity  []EquityPoint
+	Metrics BacktestMetrics
+}
+
+//RunBacktest replay candles (oldest first, ex: from PubMarketGetTicks or a CandleArchive) through strategy,
+//trading on account. For each candle, orders placed earlier are filled against it first, then the equity at its
+//close is recorded, then the strategy sees it.
+func RunBacktest(account *SimulatedAccount, candles []Candle, strategy Strategy) (BacktestResult, error) {
+	if err := ValidateCandles(candles); err != nil {
+		return BacktestResult{}, err
+	}
+
+	var equity []EquityPoint
+
+	for i, candle := range candles {
+		if i == 0 {
+			//whatever market currency the account starts with is valued at the first open
+			held := account.balance(account.Market.MarketCurrency)
+			account.position.Set(held)
+			account.cost.Mul(held, candle.Open)
+		}
+
+		account.now = candle.TimeStamp
+		account.fillAgainst(candle)
+
+		equity = append(equity, EquityPoint{candle.TimeStamp, account.Equity(candle.Close)})
+
+		if err := strategy.OnCandle(account, account.Market.MarketName, candle); err != nil {
+			return BacktestResult{account.Fills(), equity, backtestMetrics(account.fills, equity)}, err
+		}
+	}
+
+	return BacktestResult{account.Fills(), equity, backtestMetrics(account.fills, equity)}, nil
+}
+
+func backtestMetrics(fills []BacktestFill, equity []EquityPoint) BacktestMetrics {
+	metrics := BacktestMetrics{Trades: len(fills)}
+
+	sells, wins := 0, 0
+	for _, fill := range fills {
+		if fill.ProfitLoss != nil {
+			sells++
+			if fill.ProfitLoss.Sign() > 0 {
+				wins++
+			}
+		}
+	}
+
+	if sells > 0 {
+		metrics.WinRate = float64(wins) / float64(sells)
+	}
+
+	if len(equity) < 2 {
+		return metrics
+	}
+
+	values := make([]float64, len(equity))
+	for i, point := range equity {
+		values[i], _ = point.Equity.Float64()
+	}
+
+	if values[0] != 0 {
+		metrics.Return = values[len(values)-1]/values[0] - 1
+	}
+
+	peak := values[0]
+	for _, value := range values {
+		peak = math.Max(peak, value)
+		if peak > 0 {
+			metrics.MaxDrawdown = math.Max(metrics.MaxDrawdown, (peak-value)/peak)
+		}
+	}
+
+	returns := make([]float64, 0, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		if values[i-1] != 0 {
+			returns = append(returns, values[i]/values[i-1]-1)
+		}
+	}
+
+	mean, deviation := meanDeviation(returns)
+	interval := time.Time(equity[1].TimeStamp).Sub(time.Time(equity[0].TimeStamp))
+
+	if deviation > 0 && interval > 0 {
+		periodsPerYear := float64(365*24*time.Hour) / float64(interval)
+		metrics.Sharpe = mean / deviation * math.Sqrt(periodsPerYear)
+	}
+
+	return metrics
+}
+
+//meanDeviation mean and sample standard deviation.
+func meanDeviation(values []float64) (float64, float64) {
+	if len(values) < 2 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+
+	return mean, math.Sqrt(squares / float64(len(values)-1))
+}
+
+func minFloat(a, b *big.Float) *big.Float {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b *big.Float) *big.Float {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
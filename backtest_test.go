@@ -0,0 +1,146 @@
+package bittrex
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type strategyFunc func(broker Broker, market string, candle Candle) error
+
+func (f strategyFunc) OnCandle(broker Broker, market string, candle Candle) error {
+	return f(broker, market, candle)
+}
+
+func floatValue(f *big.Float) float64 {
+	value, _ := f.Float64()
+	return value
+}
+
+func nearly(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestRunBacktest(t *testing.T) {
+	account := NewSimulatedAccount(MarketDescription{
+		MarketCurrency: "LTC",
+		BaseCurrency:   "BTC",
+		MarketName:     "BTC-LTC",
+		MinTradeSize:   big.NewFloat(0.1),
+	}, map[string]*big.Float{"BTC": big.NewFloat(100)})
+
+	candles := []Candle{
+		testCandle(0, 10, 10, 10, 10, 1),
+		testCandle(time.Hour, 10, 11, 9.5, 9.8, 1), //does not reach the buy limit
+		testCandle(2*time.Hour, 8.5, 9, 8, 8, 1),   //opens below the buy limit
+		testCandle(3*time.Hour, 9, 12, 9, 11.5, 1), //reaches the sell limit
+	}
+
+	strategy := strategyFunc(func(broker Broker, market string, candle Candle) error {
+		switch {
+		case candle.TimeStamp.Equal(candles[0].TimeStamp):
+			if _, err := broker.BuyLimit(market, big.NewFloat(0.05), big.NewFloat(9)); err == nil {
+				t.Errorf("expected a min trade size error")
+			}
+
+			if _, err := broker.BuyLimit(market, big.NewFloat(10), big.NewFloat(9)); err != nil {
+				t.Errorf("unexpected buy error %v", err)
+			}
+
+			if _, err := broker.BuyLimit(market, big.NewFloat(10), big.NewFloat(9)); err == nil {
+				t.Errorf("expected an insufficient funds error")
+			}
+
+			balance, _ := broker.Balance("BTC")
+			if !nearly(floatValue(balance.Available), 100-90.225) || floatValue(balance.Balance) != 100 {
+				t.Errorf("unexpected balance %v / %v", balance.Available, balance.Balance)
+			}
+
+			if open, _ := broker.OpenOrders(market); len(open) != 1 {
+				t.Errorf("expected one open order, got %d", len(open))
+			}
+		case candle.TimeStamp.Equal(candles[2].TimeStamp):
+			if _, err := broker.SellLimit(market, big.NewFloat(10), big.NewFloat(10)); err != nil {
+				t.Errorf("unexpected sell error %v", err)
+			}
+		}
+
+		return nil
+	})
+
+	result, err := RunBacktest(account, candles, strategy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Fills) != 2 {
+		t.Fatalf("expected 2 fills, got %+v", result.Fills)
+	}
+
+	buy, sell := result.Fills[0], result.Fills[1]
+
+	if buy.OrderType != OrderTypeLimitBuy || floatValue(buy.Price) != 8.5 || !buy.TimeStamp.Equal(candles[2].TimeStamp) ||
+		!nearly(floatValue(buy.Commission), 0.2125) {
+		t.Errorf("unexpected buy fill %+v", buy)
+	}
+
+	if sell.OrderType != OrderTypeLimitSell || floatValue(sell.Price) != 10 || !nearly(floatValue(sell.ProfitLoss), 99.75-85.2125) {
+		t.Errorf("unexpected sell fill %+v", sell)
+	}
+
+	expectedEquity := []float64{100, 100, 100 - 85.2125 + 80, 100 - 85.2125 + 99.75}
+	for i, point := range result.Equity {
+		if !nearly(floatValue(point.Equity), expectedEquity[i]) {
+			t.Errorf("equity %d: got %v, wanted %v", i, floatValue(point.Equity), expectedEquity[i])
+		}
+	}
+
+	metrics := result.Metrics
+	if !nearly(metrics.Return, 0.145375) || !nearly(metrics.MaxDrawdown, 0.052125) ||
+		metrics.WinRate != 1 || metrics.Trades != 2 || metrics.Sharpe <= 0 {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+
+	if balance, _ := account.Balance("LTC"); balance.Balance.Sign() != 0 {
+		t.Errorf("expected no LTC left, got %v", balance.Balance)
+	}
+}
+
+func TestRunBacktestStrategyError(t *testing.T) {
+	account := NewSimulatedAccount(MarketDescription{MarketCurrency: "LTC", BaseCurrency: "BTC", MarketName: "BTC-LTC"}, nil)
+
+	stop := errors.New("stop")
+	seen := 0
+
+	result, err := RunBacktest(account, []Candle{
+		testCandle(0, 1, 1, 1, 1, 1),
+		testCandle(time.Hour, 1, 1, 1, 1, 1),
+	}, strategyFunc(func(broker Broker, market string, candle Candle) error {
+		seen++
+		if _, err := broker.BuyLimit("BTC-ETH", big.NewFloat(1), big.NewFloat(1)); err == nil {
+			t.Errorf("expected an invalid market error")
+		}
+		return stop
+	}))
+
+	if err != stop || seen != 1 || len(result.Equity) != 1 {
+		t.Errorf("expected the run to stop after the first candle, got %v after %d candles", err, seen)
+	}
+}
+
+func TestDefaultCommissionCopied(t *testing.T) {
+	account := NewSimulatedAccount(MarketDescription{}, nil)
+	broker := NewPaperBroker(nil)
+
+	account.Commission.SetInt64(0)
+	broker.Commission.SetInt64(0)
+
+	if BittrexCommission.Cmp(big.NewFloat(0.0025)) != 0 {
+		t.Errorf("changing an account's commission changed BittrexCommission to %s", BittrexCommission.String())
+	}
+	if scanner := newArbitrageScanner(nil, nil, nil, nil); scanner.Commission.Cmp(big.NewFloat(0.0025)) != 0 {
+		t.Errorf("expected a new scanner to charge 0.25%%, got %s", scanner.Commission.String())
+	}
+}
@@ -0,0 +1,161 @@
+package bittrex
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+//defaultBatchConcurrency requests PlaceBatch and CancelAll keep in flight unless SetBatchConcurrency says otherwise.
+const defaultBatchConcurrency = 4
+
+//OrderRequest one limit order of a PlaceBatch.
+type OrderRequest struct {
+	Market   string
+	Side     OrderSide
+	Quantity *big.Float
+	Rate     *big.Float
+}
+
+//OrderResult outcome of one OrderRequest: the placed order's UUID, or why it was not placed.
+type OrderResult struct {
+	Request OrderRequest
+	UUID    string
+	Err     error
+}
+
+//CancelResult outcome of cancelling one open order.
+type CancelResult struct {
+	Order OrderDescription
+	Err   error
+}
+
+//BatchError some operations of a batch failed; the per-order results say which.
+type BatchError struct {
+	Failed int
+	Total  int
+}
+
+func (e BatchError) Error() string {
+	return fmt.Sprintf("%d of %d batch operations failed", e.Failed, e.Total)
+}
+
+//SetBatchConcurrency how many requests PlaceBatch and CancelAll send at once. They also obey the rate limit, see
+//SetRateLimit.
+func (c *Client) SetBatchConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	c.batchConcurrency = n
+}
+
+//runBatch call do(session, i) for i in [0, n) on up to the batch concurrency goroutines, each with its own session.
+func (c *Client) runBatch(n int, do func(session *Client, i int)) {
+	workers := c.batchConcurrency
+	if workers < 1 {
+		workers = defaultBatchConcurrency
+	}
+
+	if workers > n {
+		workers = n
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			session := c.session()
+			session.limiter = c.batchLimiter
+
+			for i := range jobs {
+				do(session, i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+}
+
+//PlaceBatch place limit orders (ex: a ladder) concurrently. Every request is attempted: the results, in request
+//order, hold each UUID or error, and a BatchError is returned when any failed. With an OrderValidator set, all
+//requests are validated before any is sent and the invalid ones are skipped.
+func (c *Client) PlaceBatch(requests []OrderRequest) ([]OrderResult, error) {
+	results := make([]OrderResult, len(requests))
+	var valid []int
+
+	for i, request := range requests {
+		results[i].Request = request
+
+		if !request.Side.Valid() {
+			results[i].Err = EnumError{"order side", string(request.Side)}
+		} else if err := c.validateOrder(request.Market, request.Side, request.Quantity, request.Rate); err != nil {
+			results[i].Err = err
+		} else {
+			valid = append(valid, i)
+		}
+	}
+
+	c.runBatch(len(valid), func(session *Client, job int) {
+		//validated above already
+		session.validator = nil
+
+		i := valid[job]
+		request := requests[i]
+
+		var id TransactionID
+		var err error
+
+		if request.Side == OrderSideBuy {
+			id, err = session.MarketBuyLimit(request.Market, request.Quantity, request.Rate)
+		} else {
+			id, err = session.MarketSellLimit(request.Market, request.Quantity, request.Rate)
+		}
+
+		results[i].UUID, results[i].Err = id.UUID, err
+	})
+
+	return results, batchError(len(results), func(i int) error { return results[i].Err })
+}
+
+//CancelAll cancel every open order in market, or across the account when market is empty, concurrently.
+//Every cancel is attempted: the results hold each error, and a BatchError is returned when any failed.
+func (c *Client) CancelAll(market string) ([]CancelResult, error) {
+	orders, err := c.MarketGetOpenOrders(market)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]CancelResult, len(orders))
+
+	c.runBatch(len(orders), func(session *Client, i int) {
+		results[i].Order = orders[i]
+		_, results[i].Err = session.MarketCancel(orders[i].OrderUUID)
+	})
+
+	return results, batchError(len(results), func(i int) error { return results[i].Err })
+}
+
+func batchError(total int, err func(int) error) error {
+	failed := 0
+	for i := 0; i < total; i++ {
+		if err(i) != nil {
+			failed++
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+
+	return BatchError{failed, total}
+}
@@ -0,0 +1,248 @@
+package bittrex
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//redirectTransport sends every request to target instead of the host in its url.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (r redirectTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	request.URL.Scheme, request.URL.Host = r.target.Scheme, r.target.Host
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+//newTestAPI client whose requests go to handler, which is passed the endpoint (ex: "market/cancel") and query.
+func newTestAPI(t *testing.T, handler func(endpoint string, query url.Values) (interface{}, string)) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := strings.TrimPrefix(r.URL.Path, "/api/"+apiVersion+"/")
+		result, message := handler(endpoint, r.URL.Query())
+
+		raw, _ := json.Marshal(result)
+		json.NewEncoder(w).Encode(baseResponse{Success: message == "", Message: message, Result: raw})
+	}))
+
+	target, _ := url.Parse(server.URL)
+	previous := httpClient
+	httpClient = &http.Client{Transport: redirectTransport{target}}
+
+	t.Cleanup(func() {
+		httpClient = previous
+		server.Close()
+	})
+
+	client := New("key", "secret")
+	client.SetRateLimit(0, 1)
+
+	return client
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	limiter := &rateLimiter{}
+	now := time.Unix(0, 0)
+
+	if wait := limiter.reserve(now); wait != 0 {
+		t.Errorf("unlimited limiter waited %v", wait)
+	}
+
+	limiter.set(2, 2)
+
+	expected := []time.Duration{0, 0, 500 * time.Millisecond, time.Second}
+	for i, want := range expected {
+		if wait := limiter.reserve(now); wait != want {
+			t.Errorf("request %d: waited %v, wanted %v", i, wait, want)
+		}
+	}
+
+	//new clients only limit bulk operations, until SetRateLimit covers everything
+	client := New("", "")
+	if client.limiter.interval != 0 || client.batchLimiter.interval != time.Second || client.batchLimiter.burst != defaultBatchRateBurst {
+		t.Errorf("expected unlimited calls and batches at 1 request per second, got %v and %v", client.limiter.interval, client.batchLimiter.interval)
+	}
+
+	client.SetRateLimit(2, 1)
+	if client.batchLimiter != client.limiter {
+		t.Error("expected SetRateLimit to cover batches")
+	}
+
+	//a quiet period refills the burst
+	later := now.Add(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		if wait := limiter.reserve(later); wait != 0 {
+			t.Errorf("request %d after quiet period: waited %v", i, wait)
+		}
+	}
+}
+
+func TestRunBatchConcurrency(t *testing.T) {
+	client := New("", "")
+	client.SetBatchConcurrency(3)
+
+	var mutex sync.Mutex
+	running, most := 0, 0
+	seen := make([]bool, 20)
+	sessions := map[*Client]bool{}
+
+	client.runBatch(len(seen), func(session *Client, i int) {
+		mutex.Lock()
+		running++
+		if running > most {
+			most = running
+		}
+		seen[i] = true
+		sessions[session] = true
+		mutex.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+	})
+
+	if most > 3 {
+		t.Errorf("ran %d at once, limit was 3", most)
+	}
+
+	for i, ok := range seen {
+		if !ok {
+			t.Errorf("job %d never ran", i)
+		}
+	}
+
+	if sessions[client] {
+		t.Error("batch work ran on the calling client instead of a session")
+	}
+
+	for session := range sessions {
+		if session.limiter != client.batchLimiter {
+			t.Error("batch work ran without the batch rate limit")
+		}
+	}
+
+	if sessions := client.session(); sessions.limiter != client.limiter {
+		t.Error("sessions do not share the rate limiter")
+	}
+}
+
+func TestPlaceBatchRejectsBeforeSending(t *testing.T) {
+	client := New("", "")
+
+	results, err := client.PlaceBatch([]OrderRequest{
+		{Market: "BTC-LTC", Side: OrderSide("HOLD"), Quantity: dec("1"), Rate: dec("0.01")},
+		{Market: "BTC-LTC", Side: OrderSide(""), Quantity: dec("1"), Rate: dec("0.01")},
+	})
+
+	if batchErr, ok := err.(BatchError); !ok || batchErr.Failed != 2 || batchErr.Total != 2 {
+		t.Fatalf("expected BatchError 2 of 2, got %v", err)
+	}
+
+	for i, result := range results {
+		if _, ok := result.Err.(EnumError); !ok {
+			t.Errorf("result %d: expected EnumError, got %v", i, result.Err)
+		}
+
+		if result.Request.Market != "BTC-LTC" || result.UUID != "" {
+			t.Errorf("result %d: unexpected %+v", i, result)
+		}
+	}
+
+	if results, err := client.PlaceBatch(nil); err != nil || len(results) != 0 {
+		t.Errorf("empty batch: %v %v", results, err)
+	}
+}
+
+func TestPlaceBatchPartialFailure(t *testing.T) {
+	var mutex sync.Mutex
+	placed := map[string]string{}
+
+	client := newTestAPI(t, func(endpoint string, query url.Values) (interface{}, string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if query.Get("rate") == "0.03" {
+			return nil, "INSUFFICIENT_FUNDS"
+		}
+
+		placed[query.Get("rate")] = endpoint
+		return TransactionID{UUID: "uuid-" + query.Get("rate")}, ""
+	})
+
+	results, err := client.PlaceBatch([]OrderRequest{
+		{Market: "BTC-LTC", Side: OrderSideBuy, Quantity: dec("1"), Rate: dec("0.01")},
+		{Market: "BTC-LTC", Side: OrderSideBuy, Quantity: dec("1"), Rate: dec("0.03")},
+		{Market: "BTC-LTC", Side: OrderSide("HOLD"), Quantity: dec("1"), Rate: dec("0.04")},
+		{Market: "BTC-LTC", Side: OrderSideSell, Quantity: dec("1"), Rate: dec("0.05")},
+	})
+
+	if batchErr, ok := err.(BatchError); !ok || batchErr.Failed != 2 || batchErr.Total != 4 {
+		t.Fatalf("expected BatchError 2 of 4, got %v", err)
+	}
+
+	if results[0].UUID != "uuid-0.01" || results[0].Err != nil || results[3].UUID != "uuid-0.05" || results[3].Err != nil {
+		t.Errorf("expected the first and last orders placed, got %+v", results)
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "INSUFFICIENT_FUNDS") || results[1].UUID != "" {
+		t.Errorf("expected the api error for the second order, got %+v", results[1])
+	}
+	if _, ok := results[2].Err.(EnumError); !ok {
+		t.Errorf("expected the third order rejected before sending, got %+v", results[2])
+	}
+
+	if placed["0.01"] != "market/buylimit" || placed["0.05"] != "market/selllimit" || len(placed) != 2 {
+		t.Errorf("unexpected orders sent %v", placed)
+	}
+}
+
+func TestCancelAllPartialFailure(t *testing.T) {
+	var mutex sync.Mutex
+	var cancelled []string
+
+	client := newTestAPI(t, func(endpoint string, query url.Values) (interface{}, string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch endpoint {
+		case "market/getopenorders":
+			var orders []map[string]interface{}
+			for _, uuid := range []string{"a", "b", "c"} {
+				orders = append(orders, map[string]interface{}{"OrderUuid": uuid, "Exchange": "BTC-LTC", "OrderType": "LIMIT_BUY"})
+			}
+			return orders, ""
+		case "market/cancel":
+			if query.Get("uuid") == "b" {
+				return nil, "ORDER_NOT_OPEN"
+			}
+			cancelled = append(cancelled, query.Get("uuid"))
+			return nil, ""
+		}
+
+		return nil, "unexpected endpoint " + endpoint
+	})
+
+	results, err := client.CancelAll("BTC-LTC")
+
+	if batchErr, ok := err.(BatchError); !ok || batchErr.Failed != 1 || batchErr.Total != 3 {
+		t.Fatalf("expected BatchError 1 of 3, got %v", err)
+	}
+
+	for i, uuid := range []string{"a", "b", "c"} {
+		if results[i].Order.OrderUUID != uuid || (results[i].Err != nil) != (uuid == "b") {
+			t.Errorf("result %d: unexpected %+v", i, results[i])
+		}
+	}
+
+	if len(cancelled) != 2 {
+		t.Errorf("expected 2 cancels to succeed, got %v", cancelled)
+	}
+}
@@ -1,6 +1,7 @@
 package bittrex
 
 import "net/http"
+import "sync"
 import "time"
 
 const (
@@ -22,6 +23,16 @@ type Client struct {
 	apiSecret string
 	err       *bittrexError
 	timeout   time.Duration
+	wsConfig  WebsocketConfig
+	subConfig SubscriptionConfig
+
+	registryOnce sync.Once
+	registry     *Registry
+	validator    *OrderValidator
+
+	limiter          *rateLimiter
+	batchLimiter     *rateLimiter
+	batchConcurrency int
 }
 
 //New initialize the library with a key/secret pair.
@@ -29,13 +40,31 @@ func New(key string, secret string) *Client {
 	return NewWithCustomTimeout(key, secret, defaultTimeout)
 }
 
+//session a client with the same settings and its own error state, for use from another goroutine.
+//It shares the rate limit and the order validator.
+func (c *Client) session() *Client {
+	return &Client{
+		apiKey:           c.apiKey,
+		apiSecret:        c.apiSecret,
+		timeout:          c.timeout,
+		wsConfig:         c.wsConfig,
+		subConfig:        c.subConfig,
+		validator:        c.validator,
+		limiter:          c.limiter,
+		batchLimiter:     c.batchLimiter,
+		batchConcurrency: c.batchConcurrency,
+	}
+}
+
 //NewWithCustomTimeout initialize the library with a key/secret pair and a custom timeout.
 func NewWithCustomTimeout(key string, secret string, seconds int64) *Client {
 	return &Client{
-		apiKey:    key,
-		apiSecret: secret,
-		err:       nil,
-		timeout:   time.Duration(seconds) * time.Second,
+		apiKey:       key,
+		apiSecret:    secret,
+		err:          nil,
+		timeout:      time.Duration(seconds) * time.Second,
+		limiter:      newRateLimiter(0, 1),
+		batchLimiter: newRateLimiter(defaultBatchRateLimit, defaultBatchRateBurst),
 	}
 }
 
@@ -0,0 +1,194 @@
+//Package bot grid and dollar-cost-averaging bots trading through a bittrex.ExecutionBroker: live with
+//bittrex.Client.ExecutionBroker, or on paper with a bittrex.PaperBroker for dry runs.
+//
+//A bot works in steps. Each Step reads the state of the bot's orders from the broker, acts on fills and on its
+//schedule, and saves its state to a JSON file, so a restarted bot carries on where it stopped. A bot created from
+//saved state reconciles it with the broker's open orders (MarketGetOpenOrders when live) first: orders that closed
+//while it was down are accounted for, and an order placed just before a crash, too late to be saved, is adopted.
+//
+//Every bot has a budget, the most base currency it will commit, and a kill switch: Kill, or a price bound in its
+//config, cancels the bot's open orders and stops it for good (the killed state is saved too) until Revive.
+//Run steps a bot periodically.
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/technicalviking/bittrex"
+)
+
+//Precision mantissa bits of amounts computed by the bots.
+const Precision = 128
+
+//Bot a grid or DCA bot.
+type Bot interface {
+	//Step act on fills and the schedule as of now.
+	Step(now time.Time) error
+	//Kill cancel the bot's open orders and stop it.
+	Kill(reason string) error
+	//Killed whether the bot was killed, and why.
+	Killed() (bool, string)
+}
+
+//KillError returned by Run once the bot has been killed.
+type KillError struct {
+	Reason string
+}
+
+func (e KillError) Error() string {
+	return fmt.Sprintf("bot killed: %s", e.Reason)
+}
+
+//Run step b now and then every period until ctx is done or b is killed. A failed step is passed to onError
+//(which may be nil) and retried on the next one.
+func Run(ctx context.Context, b Bot, period time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		if err := b.Step(time.Now()); err != nil && onError != nil {
+			onError(err)
+		}
+
+		if killed, reason := b.Killed(); killed {
+			return KillError{reason}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+//Status the kill switch state shared by every bot, saved with it.
+type Status struct {
+	Killed     bool
+	KillReason string
+}
+
+//killBounds trip the kill switch when price leaves [below, above]; either bound may be nil.
+func killBounds(price, below, above *big.Float) string {
+	if below != nil && price.Cmp(below) < 0 {
+		return fmt.Sprintf("price %s fell below %s", price.Text('f', -1), below.Text('f', -1))
+	}
+
+	if above != nil && price.Cmp(above) > 0 {
+		return fmt.Sprintf("price %s rose above %s", price.Text('f', -1), above.Text('f', -1))
+	}
+
+	return ""
+}
+
+//exact value of f's shortest decimal representation.
+func exact(f *big.Float) *big.Rat {
+	if f == nil {
+		return new(big.Rat)
+	}
+
+	r, _ := new(big.Rat).SetString(f.Text('g', -1))
+	return r
+}
+
+func amount(r *big.Rat) *big.Float {
+	return new(big.Float).SetPrec(Precision).SetRat(r)
+}
+
+//floorDecimals r rounded down to the decimals Bittrex accepts.
+func floorDecimals(r *big.Rat) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(bittrex.BittrexDecimals), nil)
+
+	scaled := new(big.Int).Mul(r.Num(), scale)
+	scaled.Quo(scaled, r.Denom())
+
+	return new(big.Rat).SetFrac(scaled, scale)
+}
+
+//filled quantity and base currency total an order has filled.
+func filled(order bittrex.AccountOrderDescription) (*big.Rat, *big.Rat) {
+	return new(big.Rat).Sub(exact(order.Quantity), exact(order.QuantityRemaining)), exact(order.Price)
+}
+
+//place a limit order on broker.
+func place(broker bittrex.ExecutionBroker, market string, side bittrex.OrderSide, quantity, rate *big.Float) (string, error) {
+	if side == bittrex.OrderSideBuy {
+		return broker.BuyLimit(market, quantity, rate)
+	}
+	return broker.SellLimit(market, quantity, rate)
+}
+
+//openOrders the broker's open orders for market by UUID.
+func openOrders(broker bittrex.ExecutionBroker, market string) (map[string]bittrex.OrderDescription, error) {
+	orders, err := broker.OpenOrders(market)
+	if err != nil {
+		return nil, err
+	}
+
+	byUUID := make(map[string]bittrex.OrderDescription, len(orders))
+	for _, order := range orders {
+		byUUID[order.OrderUUID] = order
+	}
+
+	return byUUID, nil
+}
+
+//load read state saved at path into v. Reports false when nothing was saved yet.
+func load(path string, v interface{}) (bool, error) {
+	if path == "" {
+		return false, nil
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	return true, nil
+}
+
+//save write v to a temporary file and rename it over path, so a crash never leaves a truncated state file.
+func save(path string, v interface{}) error {
+	if path == "" {
+		return nil
+	}
+
+	raw, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(raw); err != nil {
+		temp.Close()
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), path)
+}
@@ -0,0 +1,379 @@
+package bot
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/technicalviking/bittrex"
+)
+
+//DCADip buy Multiplier times the usual amount when the price is at least Drop (ex: 0.1 for 10%) below the last buy.
+type DCADip struct {
+	Drop       *big.Float
+	Multiplier *big.Float
+}
+
+//DCAConfig a dollar-cost-averaging bot's settings.
+type DCAConfig struct {
+	Market string
+
+	//Amount of base currency spent on each buy, every Interval.
+	Amount   *big.Float
+	Interval time.Duration
+
+	//Dips larger buys when the price fell since the last buy. The deepest matching dip applies.
+	Dips []DCADip
+
+	//Budget most base currency the bot spends, before commission. Nil is unlimited. The last buy is cut down to fit
+	//it, and the bot kills itself once it is spent.
+	Budget *big.Float
+
+	//KillBelow trips the kill switch when the price falls below it. May be nil.
+	KillBelow *big.Float
+
+	//StatePath file the bot's state is saved to. Empty keeps it in memory only.
+	StatePath string
+}
+
+//DCABuy a buy placed by a DCA bot. Its UUID is empty while it is being placed.
+type DCABuy struct {
+	UUID     string
+	Time     time.Time
+	Rate     *big.Float
+	Quantity *big.Float
+}
+
+//DCAState what a DCA bot saves. Spent counts the base currency of filled buys and of the Pending one in full;
+//Bought the market currency filled. LastRate, the rate of the last buy, is what Dips are measured from.
+type DCAState struct {
+	Status
+	NextBuy  time.Time
+	LastRate *big.Float
+	Spent    *big.Float
+	Bought   *big.Float
+	Buys     int
+	Pending  *DCABuy
+}
+
+//DCA bot buying Amount worth of the market at the ask every Interval, more after a dip. A buy still open when the
+//next one is due is cancelled first, and the next one waits until it closed. Safe for concurrent use.
+type DCA struct {
+	config DCAConfig
+	broker bittrex.ExecutionBroker
+
+	mutex sync.Mutex
+	state DCAState
+}
+
+//NewDCA DCA bot buying through broker, resuming (and reconciling) the state saved at config.StatePath if any.
+//The first buy is made on the first Step.
+func NewDCA(broker bittrex.ExecutionBroker, config DCAConfig) (*DCA, error) {
+	switch {
+	case config.Market == "":
+		return nil, errors.New("dca - market is required")
+	case config.Amount == nil || config.Amount.Sign() <= 0:
+		return nil, errors.New("dca - amount must be positive")
+	case config.Interval <= 0:
+		return nil, errors.New("dca - interval must be positive")
+	}
+
+	for _, dip := range config.Dips {
+		if dip.Drop == nil || dip.Multiplier == nil || dip.Drop.Sign() <= 0 || dip.Multiplier.Sign() <= 0 {
+			return nil, errors.New("dca - dips need a positive drop and multiplier")
+		}
+	}
+
+	d := &DCA{config: config, broker: broker}
+
+	loaded, err := load(config.StatePath, &d.state)
+	if err != nil {
+		return nil, err
+	}
+
+	if loaded {
+		return d, d.Reconcile()
+	}
+
+	d.state.Spent = amount(new(big.Rat))
+	d.state.Bought = amount(new(big.Rat))
+
+	return d, nil
+}
+
+//State copy of the bot's state.
+func (d *DCA) State() DCAState {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	state := d.state
+	if d.state.Pending != nil {
+		pending := *d.state.Pending
+		state.Pending = &pending
+	}
+
+	return state
+}
+
+//Killed whether the bot was killed, and why.
+func (d *DCA) Killed() (bool, string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.state.Killed, d.state.KillReason
+}
+
+//Kill cancel the pending buy and stop the bot until Revive.
+func (d *DCA) Kill(reason string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.kill(reason)
+}
+
+func (d *DCA) kill(reason string) error {
+	first := d.cancelPending()
+
+	d.state.Killed, d.state.KillReason = true, reason
+
+	if err := save(d.config.StatePath, d.state); err != nil && first == nil {
+		first = err
+	}
+
+	return first
+}
+
+//Revive undo Kill. Buying resumes on the next Step.
+func (d *DCA) Revive() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.state.Killed, d.state.KillReason = false, ""
+
+	return save(d.config.StatePath, d.state)
+}
+
+//Reconcile bring the saved state in line with the broker: a pending buy that closed is accounted for, and one
+//saved while being placed is adopted from the open orders, or dropped when it never made it.
+func (d *DCA) Reconcile() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	first := d.reconcile()
+
+	if err := save(d.config.StatePath, d.state); err != nil && first == nil {
+		first = err
+	}
+
+	return first
+}
+
+func (d *DCA) reconcile() error {
+	pending := d.state.Pending
+	if pending == nil {
+		return nil
+	}
+
+	if pending.UUID != "" {
+		return d.refresh()
+	}
+
+	open, err := openOrders(d.broker, d.config.Market)
+	if err != nil {
+		return err
+	}
+
+	for uuid, order := range open {
+		if order.OrderType.Side() == bittrex.OrderSideBuy &&
+			exact(order.Limit).Cmp(exact(pending.Rate)) == 0 && exact(order.Quantity).Cmp(exact(pending.Quantity)) == 0 {
+			pending.UUID = uuid
+			d.commit(pending)
+			return nil
+		}
+	}
+
+	d.refund(new(big.Rat).Mul(exact(pending.Rate), exact(pending.Quantity)))
+	d.state.Pending = nil
+
+	return nil
+}
+
+//Step account for the pending buy once it closed, and buy when one is due.
+func (d *DCA) Step(now time.Time) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.state.Killed {
+		return nil
+	}
+
+	ticker, err := d.broker.Ticker(d.config.Market)
+	if err != nil {
+		return err
+	}
+
+	if reason := killBounds(ticker.Last, d.config.KillBelow, nil); reason != "" {
+		return d.kill(reason)
+	}
+
+	if err := d.refresh(); err != nil {
+		return err
+	}
+
+	if !d.state.NextBuy.IsZero() && now.Before(d.state.NextBuy) {
+		return save(d.config.StatePath, d.state)
+	}
+
+	if err := d.cancelPending(); err != nil {
+		return err
+	}
+
+	//the cancel has not gone through yet: buy once the pending buy is accounted for, on a later Step.
+	if d.state.Pending != nil {
+		return save(d.config.StatePath, d.state)
+	}
+
+	if err := d.buy(now, ticker.Ask); err != nil {
+		if saveErr := save(d.config.StatePath, d.state); saveErr != nil {
+			return saveErr
+		}
+		return err
+	}
+
+	return save(d.config.StatePath, d.state)
+}
+
+//buy place the buy due now at ask.
+func (d *DCA) buy(now time.Time, ask *big.Float) error {
+	spend := exact(d.config.Amount)
+	spend.Mul(spend, d.multiplier(ask))
+
+	if d.config.Budget != nil {
+		left := new(big.Rat).Sub(exact(d.config.Budget), exact(d.state.Spent))
+		if left.Cmp(spend) < 0 {
+			spend = left
+		}
+	}
+
+	rate := exact(ask)
+	if rate.Sign() <= 0 {
+		return errors.New("dca - no ask price")
+	}
+
+	quantity := floorDecimals(new(big.Rat).Quo(spend, rate))
+	if quantity.Sign() <= 0 {
+		if d.config.Budget != nil {
+			return d.kill("budget spent")
+		}
+		return errors.New("dca - amount too small to buy anything")
+	}
+
+	//save the intent first: after a crash mid-placement, Reconcile adopts the order or drops the intent.
+	pending := &DCABuy{Time: now, Rate: amount(rate), Quantity: amount(quantity)}
+	d.state.Pending = pending
+
+	cost := new(big.Rat).Mul(rate, quantity)
+	spent := exact(d.state.Spent)
+	d.state.Spent = amount(spent.Add(spent, cost))
+
+	if err := save(d.config.StatePath, d.state); err != nil {
+		d.refund(cost)
+		d.state.Pending = nil
+		return err
+	}
+
+	uuid, err := d.broker.BuyLimit(d.config.Market, pending.Quantity, pending.Rate)
+	if err != nil {
+		d.refund(cost)
+		d.state.Pending = nil
+		return err
+	}
+
+	pending.UUID = uuid
+	d.commit(pending)
+
+	return nil
+}
+
+//commit record pending as the last buy.
+func (d *DCA) commit(pending *DCABuy) {
+	d.state.LastRate = pending.Rate
+	d.state.Buys++
+
+	if d.state.NextBuy.Before(pending.Time.Add(d.config.Interval)) {
+		d.state.NextBuy = pending.Time.Add(d.config.Interval)
+	}
+}
+
+//multiplier of the deepest dip price is in since the last buy, 1 when none.
+func (d *DCA) multiplier(price *big.Float) *big.Rat {
+	multiplier, deepest := big.NewRat(1, 1), new(big.Rat)
+
+	if d.state.LastRate == nil || d.state.LastRate.Sign() <= 0 {
+		return multiplier
+	}
+
+	last := exact(d.state.LastRate)
+	drop := new(big.Rat).Sub(last, exact(price))
+	drop.Quo(drop, last)
+
+	for _, dip := range d.config.Dips {
+		threshold := exact(dip.Drop)
+		if drop.Cmp(threshold) >= 0 && threshold.Cmp(deepest) > 0 {
+			multiplier, deepest = exact(dip.Multiplier), threshold
+		}
+	}
+
+	return multiplier
+}
+
+//refresh account for the pending buy once it closed, refunding what did not fill.
+func (d *DCA) refresh() error {
+	pending := d.state.Pending
+	if pending == nil || pending.UUID == "" {
+		return nil
+	}
+
+	order, err := d.broker.Order(pending.UUID)
+	if err != nil {
+		return err
+	}
+
+	if order.IsOpen {
+		return nil
+	}
+
+	quantity, _ := filled(order)
+
+	bought := exact(d.state.Bought)
+	d.state.Bought = amount(bought.Add(bought, quantity))
+
+	unfilled := new(big.Rat).Sub(exact(pending.Quantity), quantity)
+	d.refund(unfilled.Mul(unfilled, exact(pending.Rate)))
+	d.state.Pending = nil
+
+	return nil
+}
+
+//cancelPending cancel the pending buy if still open and account for it.
+func (d *DCA) cancelPending() error {
+	if d.state.Pending == nil || d.state.Pending.UUID == "" {
+		return nil
+	}
+
+	if err := d.refresh(); err != nil || d.state.Pending == nil {
+		return err
+	}
+
+	if err := d.broker.Cancel(d.state.Pending.UUID); err != nil {
+		return err
+	}
+
+	return d.refresh()
+}
+
+func (d *DCA) refund(cost *big.Rat) {
+	spent := exact(d.state.Spent)
+	d.state.Spent = amount(spent.Sub(spent, cost))
+}
@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/technicalviking/bittrex"
+)
+
+func TestDCABuysDipsAndBudget(t *testing.T) {
+	paper := bittrex.NewPaperBroker(map[string]*big.Float{"BTC": dec("1")})
+	paper.OnTrade(trade("0.01", "0"))
+
+	d, err := NewDCA(paper, DCAConfig{
+		Market:   "BTC-LTC",
+		Amount:   dec("0.01"),
+		Interval: time.Hour,
+		Dips:     []DCADip{{dec("0.05"), dec("1.5")}, {dec("0.2"), dec("2")}},
+		Budget:   dec("0.035"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	step := func(hours float64) {
+		t.Helper()
+		if err := d.Step(start.Add(time.Duration(hours * float64(time.Hour)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	step(0)
+	paper.OnTrade(trade("0.01", "10"))
+
+	//not due yet
+	step(0.5)
+	state := d.State()
+	if state.Buys != 1 || state.Pending != nil {
+		t.Fatalf("expected one settled buy, got %+v", state)
+	}
+	expectDecimal(t, "bought", state.Bought, "1")
+
+	//a 20% dip doubles the buy
+	paper.OnTrade(trade("0.008", "0"))
+	step(1)
+	state = d.State()
+	expectDecimal(t, "dip quantity", state.Pending.Quantity, "2.5")
+	expectDecimal(t, "spent with dip", state.Spent, "0.03")
+
+	//still open when the next buy is due: cancelled and refunded first, then bought at the budget's remainder
+	step(2)
+	state = d.State()
+	expectDecimal(t, "next quantity", state.Pending.Quantity, "1.25")
+	expectDecimal(t, "spent after cancel", state.Spent, "0.02")
+
+	paper.OnTrade(trade("0.008", "10"))
+	step(3)
+	paper.OnTrade(trade("0.008", "10"))
+	step(4)
+	state = d.State()
+	expectDecimal(t, "capped quantity", state.Pending.Quantity, "0.625")
+	expectDecimal(t, "spent at budget", state.Spent, "0.035")
+
+	paper.OnTrade(trade("0.008", "10"))
+	step(5)
+	if killed, reason := d.Killed(); !killed || reason != "budget spent" {
+		t.Fatalf("expected the bot to stop once the budget was spent, got %v %q", killed, reason)
+	}
+	expectDecimal(t, "bought total", d.State().Bought, "4.125")
+}
+
+func TestDCAReconcileIntent(t *testing.T) {
+	paper := bittrex.NewPaperBroker(map[string]*big.Float{"BTC": dec("1")})
+	dir := t.TempDir()
+	config := DCAConfig{Market: "BTC-LTC", Amount: dec("0.01"), Interval: time.Hour}
+	placed := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	intent := DCAState{
+		Spent:   dec("0.01"),
+		Bought:  dec("0"),
+		Pending: &DCABuy{Time: placed, Rate: dec("0.01"), Quantity: dec("1")},
+	}
+
+	//placed before the crash: adopted
+	config.StatePath = filepath.Join(dir, "placed.json")
+	if err := save(config.StatePath, intent); err != nil {
+		t.Fatal(err)
+	}
+	uuid, err := paper.BuyLimit("BTC-LTC", dec("1"), dec("0.01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := NewDCA(paper, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := d.State()
+	if state.Pending == nil || state.Pending.UUID != uuid || state.Buys != 1 || !state.NextBuy.Equal(placed.Add(time.Hour)) {
+		t.Fatalf("expected the open order adopted, got %+v", state)
+	}
+
+	//never placed: dropped and refunded
+	config.StatePath = filepath.Join(dir, "lost.json")
+	intent.Spent, intent.Pending.Quantity = dec("0.02"), dec("2")
+	if err := save(config.StatePath, intent); err != nil {
+		t.Fatal(err)
+	}
+
+	if d, err = NewDCA(paper, config); err != nil {
+		t.Fatal(err)
+	}
+
+	state = d.State()
+	if state.Pending != nil || state.Buys != 0 {
+		t.Fatalf("expected the intent dropped, got %+v", state)
+	}
+	expectDecimal(t, "refunded", state.Spent, "0")
+}
+
+func TestDCAWaitsForCancel(t *testing.T) {
+	paper := bittrex.NewPaperBroker(map[string]*big.Float{"BTC": dec("1")})
+	paper.OnTrade(trade("0.01", "0"))
+
+	//the cancel is accepted but the order stays open
+	d, err := NewDCA(stubbornBroker{paper, nil}, DCAConfig{Market: "BTC-LTC", Amount: dec("0.01"), Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := d.Step(start); err != nil {
+		t.Fatal(err)
+	}
+	first := d.State().Pending.UUID
+
+	if err := d.Step(start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	state := d.State()
+	if state.Buys != 1 || state.Pending == nil || state.Pending.UUID != first {
+		t.Fatalf("expected no buy while the pending one is open, got %+v", state)
+	}
+
+	//it fills after all: accounted for, then the next buy goes out
+	paper.OnTrade(trade("0.01", "1"))
+	if err := d.Step(start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	state = d.State()
+	if state.Buys != 2 || state.Pending == nil || state.Pending.UUID == first {
+		t.Fatalf("expected the next buy placed, got %+v", state)
+	}
+	expectDecimal(t, "bought", state.Bought, "1")
+	expectDecimal(t, "spent", state.Spent, "0.02")
+}
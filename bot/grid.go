@@ -0,0 +1,423 @@
+package bot
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/technicalviking/bittrex"
+)
+
+//GridConfig a grid bot's settings.
+type GridConfig struct {
+	Market string
+
+	//Lower and Upper the lowest and highest price levels. Levels (at least 3) levels are spread evenly between them.
+	Lower  *big.Float
+	Upper  *big.Float
+	Levels int
+
+	//Quantity of the market currency every order of the grid trades.
+	Quantity *big.Float
+
+	//Budget most base currency the grid commits: its open buys plus the cost of what it bought and has not sold yet.
+	//Nil is unlimited. Buys that would go over it wait until sells free some up.
+	Budget *big.Float
+
+	//KillBelow and KillAbove trip the kill switch when the price leaves them. Either may be nil.
+	KillBelow *big.Float
+	KillAbove *big.Float
+
+	//StatePath file the grid's state is saved to. Empty keeps it in memory only.
+	StatePath string
+}
+
+//GridLevel one price level of a grid: the Side of the order it holds (empty for the gap next to the price) and the
+//UUID of that order once placed. Filled is what earlier orders of the level traded before closing, ex: cancelled
+//outside the bot; the level's next order trades only the rest of the Quantity.
+type GridLevel struct {
+	Rate   *big.Float
+	Side   bittrex.OrderSide
+	UUID   string
+	Filled *big.Float
+}
+
+//GridState what a grid bot saves. Invested is the base currency cost of what the grid bought and has not sold yet.
+//Profit is what its sells earned over the level below them, before commission, over Trips sells.
+type GridState struct {
+	Status
+	Started  bool
+	Levels   []GridLevel
+	Invested *big.Float
+	Profit   *big.Float
+	Trips    int
+}
+
+//Grid bot keeping a buy on every level below the price and a sell on every level above it, leaving the level
+//nearest the price empty. When a buy fills, a sell is placed on the level above it; when a sell fills, a buy is
+//placed on the level below it, earning the difference. Sells are made from the market currency already held.
+//An order cancelled outside the bot is placed again for what it did not fill. Safe for concurrent use.
+type Grid struct {
+	config GridConfig
+	broker bittrex.ExecutionBroker
+
+	mutex sync.Mutex
+	state GridState
+}
+
+//NewGrid grid bot trading through broker, resuming (and reconciling) the state saved at config.StatePath if any.
+//Orders are placed from the first Step.
+func NewGrid(broker bittrex.ExecutionBroker, config GridConfig) (*Grid, error) {
+	switch {
+	case config.Market == "":
+		return nil, errors.New("grid - market is required")
+	case config.Lower == nil || config.Upper == nil || config.Lower.Sign() <= 0 || config.Lower.Cmp(config.Upper) >= 0:
+		return nil, errors.New("grid - need 0 < Lower < Upper")
+	case config.Levels < 3:
+		return nil, errors.New("grid - need at least 3 levels")
+	case config.Quantity == nil || config.Quantity.Sign() <= 0:
+		return nil, errors.New("grid - quantity must be positive")
+	}
+
+	g := &Grid{config: config, broker: broker}
+
+	loaded, err := load(config.StatePath, &g.state)
+	if err != nil {
+		return nil, err
+	}
+
+	if loaded {
+		if len(g.state.Levels) != config.Levels {
+			return nil, errors.New("grid - saved state has a different number of levels than the config")
+		}
+
+		return g, g.Reconcile()
+	}
+
+	lower := exact(config.Lower)
+	step := new(big.Rat).Sub(exact(config.Upper), lower)
+	step.Quo(step, big.NewRat(int64(config.Levels-1), 1))
+
+	for i := 0; i < config.Levels; i++ {
+		rate := new(big.Rat).Mul(step, big.NewRat(int64(i), 1))
+		g.state.Levels = append(g.state.Levels, GridLevel{Rate: amount(floorDecimals(rate.Add(rate, lower)))})
+	}
+
+	g.state.Invested = amount(new(big.Rat))
+	g.state.Profit = amount(new(big.Rat))
+
+	return g, nil
+}
+
+//State copy of the grid's state.
+func (g *Grid) State() GridState {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	state := g.state
+	state.Levels = append([]GridLevel(nil), g.state.Levels...)
+
+	return state
+}
+
+//Killed whether the grid was killed, and why.
+func (g *Grid) Killed() (bool, string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.state.Killed, g.state.KillReason
+}
+
+//Kill cancel the grid's open orders and stop it until Revive.
+func (g *Grid) Kill(reason string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.kill(reason)
+}
+
+func (g *Grid) kill(reason string) error {
+	//fills up to now are accounted for before cancelling.
+	first := g.refresh(nil)
+
+	for i := range g.state.Levels {
+		level := &g.state.Levels[i]
+		if level.UUID == "" {
+			continue
+		}
+
+		if err := g.broker.Cancel(level.UUID); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	//cancelled orders are accounted for once closed, here or by the first Step after Revive. An order that failed
+	//to cancel stays open and keeps its level.
+	if err := g.refresh(nil); err != nil && first == nil {
+		first = err
+	}
+
+	g.state.Killed, g.state.KillReason = true, reason
+
+	if err := save(g.config.StatePath, g.state); err != nil && first == nil {
+		first = err
+	}
+
+	return first
+}
+
+//Revive undo Kill. The grid places its orders again on the next Step.
+func (g *Grid) Revive() error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.state.Killed, g.state.KillReason = false, ""
+
+	return save(g.config.StatePath, g.state)
+}
+
+//Reconcile bring the saved state in line with the broker: orders that closed are accounted for, and open orders
+//matching an empty slot of the grid (placed before a crash, too late to be saved) are adopted.
+func (g *Grid) Reconcile() error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	open, err := openOrders(g.broker, g.config.Market)
+	if err != nil {
+		return err
+	}
+
+	first := g.refresh(open)
+
+	adopted := make(map[string]bool)
+	for i := range g.state.Levels {
+		level := &g.state.Levels[i]
+		if level.UUID != "" {
+			adopted[level.UUID] = true
+		}
+	}
+
+	for i := range g.state.Levels {
+		level := &g.state.Levels[i]
+		if level.Side == "" || level.UUID != "" {
+			continue
+		}
+
+		for uuid, order := range open {
+			if !adopted[uuid] && order.OrderType.Side() == level.Side &&
+				exact(order.Limit).Cmp(exact(level.Rate)) == 0 && exact(order.Quantity).Cmp(g.remaining(*level)) == 0 {
+				level.UUID = uuid
+				adopted[uuid] = true
+				break
+			}
+		}
+	}
+
+	if err := save(g.config.StatePath, g.state); err != nil && first == nil {
+		first = err
+	}
+
+	return first
+}
+
+//Step arm the grid on the first step, then re-arm the levels whose orders filled and place missing orders.
+func (g *Grid) Step(now time.Time) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.state.Killed {
+		return nil
+	}
+
+	ticker, err := g.broker.Ticker(g.config.Market)
+	if err != nil {
+		return err
+	}
+
+	if reason := killBounds(ticker.Last, g.config.KillBelow, g.config.KillAbove); reason != "" {
+		return g.kill(reason)
+	}
+
+	if !g.state.Started {
+		g.arm(ticker.Last)
+	}
+
+	first := g.refresh(nil)
+
+	if err := g.placeMissing(); err != nil && first == nil {
+		first = err
+	}
+
+	if err := save(g.config.StatePath, g.state); err != nil && first == nil {
+		first = err
+	}
+
+	return first
+}
+
+//arm buys below the level nearest price, sells above it.
+func (g *Grid) arm(price *big.Float) {
+	gap := 0
+	var nearest *big.Rat
+
+	for i, level := range g.state.Levels {
+		distance := new(big.Rat).Sub(exact(level.Rate), exact(price))
+		distance.Abs(distance)
+
+		if nearest == nil || distance.Cmp(nearest) < 0 {
+			gap, nearest = i, distance
+		}
+	}
+
+	for i := range g.state.Levels {
+		switch {
+		case i < gap:
+			g.state.Levels[i].Side = bittrex.OrderSideBuy
+		case i > gap:
+			g.state.Levels[i].Side = bittrex.OrderSideSell
+		}
+	}
+
+	g.state.Started = true
+}
+
+//refresh account for every level order that is no longer open, skipping the lookup for those in open.
+func (g *Grid) refresh(open map[string]bittrex.OrderDescription) error {
+	var first error
+
+	for i := range g.state.Levels {
+		uuid := g.state.Levels[i].UUID
+		if uuid == "" {
+			continue
+		}
+
+		if _, ok := open[uuid]; ok {
+			continue
+		}
+
+		order, err := g.broker.Order(uuid)
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+
+		if order.IsOpen {
+			continue
+		}
+
+		quantity, _ := filled(order)
+		if g.account(i, quantity) {
+			g.fill(i)
+		} else {
+			g.state.Levels[i].UUID = ""
+		}
+	}
+
+	return first
+}
+
+//remaining quantity level's next order trades.
+func (g *Grid) remaining(level GridLevel) *big.Rat {
+	return new(big.Rat).Sub(exact(g.config.Quantity), exact(level.Filled))
+}
+
+//account record quantity traded by the order of level i, reporting whether the level traded its whole Quantity.
+func (g *Grid) account(i int, quantity *big.Rat) bool {
+	levels := g.state.Levels
+	level := &levels[i]
+	invested := exact(g.state.Invested)
+
+	if level.Side == bittrex.OrderSideBuy {
+		invested.Add(invested, new(big.Rat).Mul(exact(level.Rate), quantity))
+	} else if i > 0 {
+		bought := new(big.Rat).Mul(exact(levels[i-1].Rate), quantity)
+
+		invested.Sub(invested, bought)
+		if invested.Sign() < 0 {
+			invested.SetInt64(0)
+		}
+
+		profit := exact(g.state.Profit)
+		profit.Add(profit, new(big.Rat).Sub(new(big.Rat).Mul(exact(level.Rate), quantity), bought))
+		g.state.Profit = amount(profit)
+	}
+
+	g.state.Invested = amount(invested)
+
+	total := new(big.Rat).Add(exact(level.Filled), quantity)
+	if total.Cmp(exact(g.config.Quantity)) >= 0 {
+		level.Filled = nil
+		return true
+	}
+
+	if total.Sign() > 0 {
+		level.Filled = amount(total)
+	}
+
+	return false
+}
+
+//fill re-arm the grid around level i, whose order filled.
+func (g *Grid) fill(i int) {
+	levels := g.state.Levels
+	level := &levels[i]
+
+	if level.Side == bittrex.OrderSideBuy {
+		if i+1 < len(levels) && levels[i+1].Side == "" {
+			levels[i+1].Side = bittrex.OrderSideSell
+		}
+	} else if i > 0 {
+		g.state.Trips++
+
+		if levels[i-1].Side == "" {
+			levels[i-1].Side = bittrex.OrderSideBuy
+		}
+	}
+
+	level.Side, level.UUID = "", ""
+}
+
+//placeMissing place the order of every armed level that has none, buys only within the budget.
+func (g *Grid) placeMissing() error {
+	committed := exact(g.state.Invested)
+	for _, level := range g.state.Levels {
+		if level.Side == bittrex.OrderSideBuy && level.UUID != "" {
+			committed.Add(committed, new(big.Rat).Mul(exact(level.Rate), g.remaining(level)))
+		}
+	}
+
+	var first error
+
+	for i := range g.state.Levels {
+		level := &g.state.Levels[i]
+		if level.Side == "" || level.UUID != "" {
+			continue
+		}
+
+		quantity := g.remaining(*level)
+		cost := new(big.Rat).Mul(exact(level.Rate), quantity)
+		if level.Side == bittrex.OrderSideBuy && g.config.Budget != nil {
+			if new(big.Rat).Add(committed, cost).Cmp(exact(g.config.Budget)) > 0 {
+				continue
+			}
+		}
+
+		uuid, err := place(g.broker, g.config.Market, level.Side, amount(quantity), level.Rate)
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+
+		level.UUID = uuid
+		if level.Side == bittrex.OrderSideBuy {
+			committed.Add(committed, cost)
+		}
+	}
+
+	return first
+}
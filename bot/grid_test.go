@@ -0,0 +1,298 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/technicalviking/bittrex"
+)
+
+func dec(s string) *big.Float {
+	f, _, err := big.ParseFloat(s, 10, Precision, big.ToNearestEven)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func expectDecimal(t *testing.T, label string, got *big.Float, want string) {
+	t.Helper()
+
+	if got == nil || got.Text('f', -1) != want {
+		t.Errorf("%s: expected %s, got %v", label, want, got)
+	}
+}
+
+func trade(price, quantity string) bittrex.Trade {
+	return bittrex.Trade{Market: "BTC-LTC", Price: dec(price), Quantity: dec(quantity)}
+}
+
+func openRates(t *testing.T, paper *bittrex.PaperBroker) map[string]bittrex.OrderSide {
+	t.Helper()
+
+	open, err := paper.OpenOrders("BTC-LTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rates := make(map[string]bittrex.OrderSide)
+	for _, order := range open {
+		rates[order.Limit.Text('f', -1)] = order.OrderType.Side()
+	}
+
+	return rates
+}
+
+//stubbornBroker a PaperBroker whose Cancel returns err without cancelling anything.
+type stubbornBroker struct {
+	*bittrex.PaperBroker
+	err error
+}
+
+func (b stubbornBroker) Cancel(uuid string) error {
+	return b.err
+}
+
+func testGridConfig() GridConfig {
+	return GridConfig{Market: "BTC-LTC", Lower: dec("0.009"), Upper: dec("0.011"), Levels: 3, Quantity: dec("1")}
+}
+
+func TestGridRearmsOnFill(t *testing.T) {
+	paper := bittrex.NewPaperBroker(map[string]*big.Float{"BTC": dec("1"), "LTC": dec("1")})
+	paper.OnTrade(trade("0.0101", "0"))
+
+	g, err := NewGrid(paper, testGridConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := g.Step(now); err != nil {
+		t.Fatal(err)
+	}
+
+	if rates := openRates(t, paper); len(rates) != 2 || rates["0.009"] != bittrex.OrderSideBuy || rates["0.011"] != bittrex.OrderSideSell {
+		t.Fatalf("expected a buy below and a sell above the price, got %v", rates)
+	}
+
+	//the sell fills: the level below it gets a buy
+	paper.OnTrade(trade("0.011", "1"))
+	if err := g.Step(now); err != nil {
+		t.Fatal(err)
+	}
+
+	if rates := openRates(t, paper); len(rates) != 2 || rates["0.009"] != bittrex.OrderSideBuy || rates["0.01"] != bittrex.OrderSideBuy {
+		t.Fatalf("expected buys on both lower levels, got %v", rates)
+	}
+
+	//that buy fills: the sell is back above it
+	paper.OnTrade(trade("0.0095", "1"))
+	if err := g.Step(now); err != nil {
+		t.Fatal(err)
+	}
+
+	if rates := openRates(t, paper); len(rates) != 2 || rates["0.009"] != bittrex.OrderSideBuy || rates["0.011"] != bittrex.OrderSideSell {
+		t.Fatalf("expected the grid re-armed, got %v", rates)
+	}
+
+	state := g.State()
+	if state.Trips != 1 {
+		t.Errorf("expected 1 trip, got %d", state.Trips)
+	}
+	expectDecimal(t, "profit", state.Profit, "0.001")
+	expectDecimal(t, "invested", state.Invested, "0.01")
+}
+
+func TestGridBudget(t *testing.T) {
+	paper := bittrex.NewPaperBroker(map[string]*big.Float{"BTC": dec("1"), "LTC": dec("1")})
+	paper.OnTrade(trade("0.0101", "0"))
+
+	config := testGridConfig()
+	config.Budget = dec("0.015")
+
+	g, err := NewGrid(paper, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	g.Step(now)
+	paper.OnTrade(trade("0.011", "1"))
+	g.Step(now)
+
+	//0.009 + 0.01 would go over 0.015: only the first buy is placed
+	if rates := openRates(t, paper); len(rates) != 1 || rates["0.009"] != bittrex.OrderSideBuy {
+		t.Fatalf("expected only the buy within budget, got %v", rates)
+	}
+}
+
+func TestGridResumeAndReconcile(t *testing.T) {
+	paper := bittrex.NewPaperBroker(map[string]*big.Float{"BTC": dec("1"), "LTC": dec("1")})
+	paper.OnTrade(trade("0.0101", "0"))
+
+	config := testGridConfig()
+	config.StatePath = filepath.Join(t.TempDir(), "grid.json")
+
+	g, err := NewGrid(paper, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Step(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	//the sell fills while the bot is down, and the buy's UUID never made it to disk
+	paper.OnTrade(trade("0.011", "1"))
+
+	state := g.State()
+	for i := range state.Levels {
+		if state.Levels[i].Side == bittrex.OrderSideBuy {
+			state.Levels[i].UUID = ""
+		}
+	}
+	if err := save(config.StatePath, state); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := NewGrid(paper, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	levels := resumed.State().Levels
+	if levels[0].UUID == "" || levels[1].Side != bittrex.OrderSideBuy || levels[2].Side != "" {
+		t.Fatalf("expected the open buy adopted and the sell accounted for, got %+v", levels)
+	}
+
+	if err := resumed.Step(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if rates := openRates(t, paper); len(rates) != 2 || rates["0.009"] != bittrex.OrderSideBuy || rates["0.01"] != bittrex.OrderSideBuy {
+		t.Fatalf("expected no duplicate orders after resuming, got %v", rates)
+	}
+}
+
+func TestGridKillSwitch(t *testing.T) {
+	paper := bittrex.NewPaperBroker(map[string]*big.Float{"BTC": dec("1"), "LTC": dec("1")})
+	paper.OnTrade(trade("0.0101", "0"))
+
+	config := testGridConfig()
+	config.KillBelow = dec("0.0085")
+	config.StatePath = filepath.Join(t.TempDir(), "grid.json")
+
+	g, err := NewGrid(paper, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Step(time.Now())
+
+	paper.OnTrade(trade("0.008", "0"))
+
+	err = Run(context.Background(), g, time.Hour, nil)
+	if _, ok := err.(KillError); !ok {
+		t.Fatalf("expected Run to stop with a KillError, got %v", err)
+	}
+
+	if rates := openRates(t, paper); len(rates) != 0 {
+		t.Errorf("expected the grid's orders cancelled, got %v", rates)
+	}
+
+	//the kill survives a restart
+	resumed, err := NewGrid(paper, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if killed, _ := resumed.Killed(); !killed {
+		t.Error("expected the resumed grid to stay killed")
+	}
+}
+
+func TestGridPartialFill(t *testing.T) {
+	paper := bittrex.NewPaperBroker(map[string]*big.Float{"BTC": dec("1"), "LTC": dec("1")})
+	paper.OnTrade(trade("0.0101", "0"))
+
+	g, err := NewGrid(paper, testGridConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if err := g.Step(now); err != nil {
+		t.Fatal(err)
+	}
+
+	//the buy fills in part, then is cancelled outside the bot: only the rest is bought again
+	paper.OnTrade(trade("0.009", "0.4"))
+	if err := paper.Cancel(g.State().Levels[0].UUID); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Step(now); err != nil {
+		t.Fatal(err)
+	}
+
+	state := g.State()
+	expectDecimal(t, "invested after the partial fill", state.Invested, "0.0036")
+	expectDecimal(t, "level filled", state.Levels[0].Filled, "0.4")
+
+	order, err := paper.Order(state.Levels[0].UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDecimal(t, "replacement quantity", order.Quantity, "0.6")
+
+	//the rest fills: the level is done and a sell goes above it
+	paper.OnTrade(trade("0.009", "1"))
+	if err := g.Step(now); err != nil {
+		t.Fatal(err)
+	}
+
+	state = g.State()
+	expectDecimal(t, "invested after the fill", state.Invested, "0.009")
+	if state.Levels[0].Filled != nil || state.Levels[1].Side != bittrex.OrderSideSell {
+		t.Fatalf("expected the level re-armed, got %+v", state.Levels)
+	}
+}
+
+func TestGridKillKeepsFailedCancels(t *testing.T) {
+	paper := bittrex.NewPaperBroker(map[string]*big.Float{"BTC": dec("1"), "LTC": dec("1")})
+	paper.OnTrade(trade("0.0101", "0"))
+
+	broker := stubbornBroker{paper, errors.New("cancel failed")}
+
+	g, err := NewGrid(broker, testGridConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Step(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	//the sell fills before the kill
+	paper.OnTrade(trade("0.011", "1"))
+
+	if err := g.Kill("test"); err == nil {
+		t.Error("expected the failed cancel reported")
+	}
+
+	state := g.State()
+	if state.Trips != 1 || state.Levels[0].UUID == "" || state.Levels[1].Side != bittrex.OrderSideBuy {
+		t.Fatalf("expected the fill accounted for and the buy that failed to cancel kept, got %+v", state)
+	}
+	expectDecimal(t, "profit", state.Profit, "0.001")
+
+	//revived, the grid carries on with them rather than placing more
+	if err := g.Revive(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Step(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if open, _ := paper.OpenOrders("BTC-LTC"); len(open) != 2 {
+		t.Errorf("expected no duplicate orders after Revive, got %d open", len(open))
+	}
+}
@@ -0,0 +1,378 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//candleArchiverErrorBuffer errors an unread CandleArchiver.Error holds before dropping them.
+const candleArchiverErrorBuffer = 16
+
+//CandleArchive file based candle store, one CSV file per market and tick interval under a directory.
+//Safe for concurrent use within a process.
+type CandleArchive struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+//OpenCandleArchive open (creating if needed) the archive rooted at dir.
+func OpenCandleArchive(dir string) (*CandleArchive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &CandleArchive{dir: dir}, nil
+}
+
+func (a *CandleArchive) path(market, interval string) string {
+	return filepath.Join(a.dir, market, interval+".csv")
+}
+
+//Load every archived candle for market and interval, oldest first.
+func (a *CandleArchive) Load(market, interval string) ([]Candle, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.load(market, interval)
+}
+
+//Query archived candles for market and interval with from <= TimeStamp < to, oldest first.
+func (a *CandleArchive) Query(market, interval string, from, to time.Time) ([]Candle, error) {
+	candles, err := a.Load(market, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return candlesBetween(candles, from, to), nil
+}
+
+//Merge add candles to the archive. A candle with the TimeStamp of an archived one replaces it, since the
+//latest candle served by the api is still open. Returns the number of candles that were not archived before.
+func (a *CandleArchive) Merge(market, interval string, candles []Candle) (int, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	archived, err := a.load(market, interval)
+	if err != nil {
+		return 0, err
+	}
+
+	byTime := make(map[int64]Candle, len(archived)+len(candles))
+	for _, candle := range archived {
+		byTime[time.Time(candle.TimeStamp).UnixNano()] = candle
+	}
+
+	added := 0
+	for _, candle := range candles {
+		key := time.Time(candle.TimeStamp).UnixNano()
+		if _, ok := byTime[key]; !ok {
+			added++
+		}
+		byTime[key] = candle
+	}
+
+	merged := make([]Candle, 0, len(byTime))
+	for _, candle := range byTime {
+		merged = append(merged, candle)
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].TimeStamp.Before(merged[j].TimeStamp)
+	})
+
+	return added, a.store(market, interval, merged)
+}
+
+func (a *CandleArchive) load(market, interval string) ([]Candle, error) {
+	file, err := os.Open(a.path(market, interval))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 7
+
+	var candles []Candle
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return candles, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		candle, err := parseCandleRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", a.path(market, interval), err.Error())
+		}
+
+		candles = append(candles, candle)
+	}
+}
+
+//store write to a temporary file and rename it over the old one, so a crash never leaves a truncated archive.
+func (a *CandleArchive) store(market, interval string, candles []Candle) error {
+	path := a.path(market, interval)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(path), interval+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	writer := csv.NewWriter(temp)
+	for _, candle := range candles {
+		writer.Write(candleRecord(candle))
+	}
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		temp.Close()
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), path)
+}
+
+func candleRecord(candle Candle) []string {
+	text := func(f *big.Float) string {
+		if f == nil {
+			return "0"
+		}
+		return f.Text('g', -1)
+	}
+
+	return []string{
+		time.Time(candle.TimeStamp).UTC().Format(time.RFC3339Nano),
+		text(candle.Open),
+		text(candle.High),
+		text(candle.Low),
+		text(candle.Close),
+		text(candle.Volume),
+		text(candle.BaseVolume),
+	}
+}
+
+func parseCandleRecord(record []string) (Candle, error) {
+	timestamp, err := time.Parse(time.RFC3339Nano, record[0])
+	if err != nil {
+		return Candle{}, err
+	}
+
+	values := make([]*big.Float, 6)
+	for i := range values {
+		var ok bool
+		if values[i], ok = new(big.Float).SetString(record[i+1]); !ok {
+			return Candle{}, fmt.Errorf("invalid number %q", record[i+1])
+		}
+	}
+
+	return Candle{
+		TimeStamp:  BittrexTimestamp(timestamp),
+		Open:       values[0],
+		High:       values[1],
+		Low:        values[2],
+		Close:      values[3],
+		Volume:     values[4],
+		BaseVolume: values[5],
+	}, nil
+}
+
+//tickIntervalDuration length of a TickInterval const.
+func tickIntervalDuration(interval string) (time.Duration, bool) {
+	for _, seed := range seedIntervals {
+		if seed.name == interval {
+			return seed.duration, true
+		}
+	}
+
+	return 0, false
+}
+
+func candlesBetween(candles []Candle, from, to time.Time) []Candle {
+	var between []Candle
+
+	for _, candle := range candles {
+		timestamp := time.Time(candle.TimeStamp)
+		if !timestamp.Before(from) && timestamp.Before(to) {
+			between = append(between, candle)
+		}
+	}
+
+	return between
+}
+
+//CandleArchiver keeps a CandleArchive up to date by periodically fetching PubMarketGetTicks for a set of
+//markets and tick intervals. Run it with Start, stop it with Close. Error is buffered; failures are dropped and
+//counted (see DroppedErrors) while it is full, so an unread Error never stalls archiving.
+type CandleArchiver struct {
+	Archive   *CandleArchive
+	Markets   []string
+	Intervals []string
+	Period    time.Duration
+	Error     chan error
+
+	droppedErrors uint64
+
+	//ticks source of fresh candles, PubMarketGetTicks outside tests.
+	ticks func(market string, interval string) ([]Candle, error)
+
+	syncMutex sync.Mutex
+	startOnce sync.Once
+	closeOnce sync.Once
+	closing   chan struct{}
+	closed    chan struct{}
+}
+
+//NewCandleArchiver archiver for markets and intervals (TickInterval consts). Period should be well below the
+//history the api keeps for the smallest interval (about 10 days for TickIntervalOneMin).
+func (c *Client) NewCandleArchiver(archive *CandleArchive, markets []string, intervals []string, period time.Duration) *CandleArchiver {
+	//Sync runs from the archiver's goroutine as well as the caller's, so each fetch gets a session of its own.
+	base := c.session()
+	ticks := func(market string, interval string) ([]Candle, error) {
+		return base.session().PubMarketGetTicks(market, interval)
+	}
+
+	return &CandleArchiver{
+		Archive:   archive,
+		Markets:   markets,
+		Intervals: intervals,
+		Period:    period,
+		Error:     make(chan error, candleArchiverErrorBuffer),
+		ticks:     ticks,
+		closing:   make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+}
+
+//Sync fetch and archive every market and interval once. Keeps going after a failure and returns the first error.
+func (a *CandleArchiver) Sync() error {
+	var firstErr error
+
+	for _, market := range a.Markets {
+		for _, interval := range a.Intervals {
+			if err := a.sync(market, interval); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+func (a *CandleArchiver) sync(market, interval string) error {
+	a.syncMutex.Lock()
+	defer a.syncMutex.Unlock()
+
+	candles, err := a.ticks(market, interval)
+	if err != nil {
+		return fmt.Errorf("archive %s %s: %s", market, interval, err.Error())
+	}
+
+	_, err = a.Archive.Merge(market, interval, candles)
+	return err
+}
+
+//Query candles for market and interval with from <= TimeStamp < to. When the range reaches past the newest
+//archived candle, fresh candles are fetched and archived first, so the result spans both sources.
+func (a *CandleArchiver) Query(market, interval string, from, to time.Time) ([]Candle, error) {
+	archived, err := a.Archive.Load(market, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, _ := tickIntervalDuration(interval)
+
+	if len(archived) == 0 || time.Time(archived[len(archived)-1].TimeStamp).Add(duration).Before(to) {
+		if err := a.sync(market, interval); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.Archive.Query(market, interval, from, to)
+}
+
+//DroppedErrors number of failures discarded because Error was full.
+func (a *CandleArchiver) DroppedErrors() uint64 {
+	return atomic.LoadUint64(&a.droppedErrors)
+}
+
+//Start sync now and then every Period, in the background. Failures are sent to Error.
+func (a *CandleArchiver) Start() {
+	a.startOnce.Do(func() {
+		go a.run()
+	})
+}
+
+//Close stop a started archiver, waiting for an in-progress sync to finish.
+func (a *CandleArchiver) Close(ctx context.Context) error {
+	started := true
+	a.startOnce.Do(func() {
+		started = false
+		close(a.closed)
+	})
+
+	a.closeOnce.Do(func() {
+		close(a.closing)
+	})
+
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-a.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *CandleArchiver) run() {
+	defer close(a.closed)
+
+	ticker := time.NewTicker(a.Period)
+	defer ticker.Stop()
+
+	for {
+		for _, market := range a.Markets {
+			for _, interval := range a.Intervals {
+				if err := a.sync(market, interval); err != nil {
+					select {
+					case a.Error <- err:
+					default:
+						atomic.AddUint64(&a.droppedErrors, 1)
+					}
+				}
+			}
+		}
+
+		select {
+		case <-a.closing:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCandleArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "candles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive, err := OpenCandleArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetches := 0
+	served := []Candle{
+		testCandle(0, 1, 1, 1, 1, 1),
+		testCandle(time.Minute, 2, 2, 2, 2, 1),
+	}
+
+	archiver := New("", "").NewCandleArchiver(archive, []string{"BTC-LTC"}, []string{TickIntervalOneMin}, time.Hour)
+	archiver.ticks = func(market, interval string) ([]Candle, error) {
+		fetches++
+		return served, nil
+	}
+
+	if err := archiver.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	//history moves on: the first candle ages out, the open candle closes and a new one opens
+	served = []Candle{
+		testCandle(time.Minute, 2, 3, 2, 3, 2),
+		testCandle(2*time.Minute, 3, 3, 3, 3, 1),
+	}
+
+	if err := archiver.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	candles, err := archive.Load("BTC-LTC", TickIntervalOneMin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(candles) != 3 {
+		t.Fatalf("expected 3 archived candles, got %d", len(candles))
+	}
+	checkCandle(t, "aged out", candles[0], 0, 1, 1, 1, 1, 1)
+	checkCandle(t, "replaced", candles[1], time.Minute, 2, 3, 2, 3, 2)
+
+	added, err := archive.Merge("BTC-LTC", TickIntervalOneMin, served)
+	if err != nil || added != 0 {
+		t.Errorf("expected no new candles, got %d (%v)", added, err)
+	}
+
+	//covered by the archive, no fetch
+	fetches = 0
+	ranged, err := archiver.Query("BTC-LTC", TickIntervalOneMin, candleEpoch, candleEpoch.Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranged) != 2 || fetches != 0 {
+		t.Errorf("expected 2 candles without fetching, got %d candles and %d fetches", len(ranged), fetches)
+	}
+
+	//reaches past the archive, fetches fresh candles
+	served = []Candle{testCandle(3*time.Minute, 4, 4, 4, 4, 1)}
+	ranged, err = archiver.Query("BTC-LTC", TickIntervalOneMin, candleEpoch.Add(time.Minute), candleEpoch.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranged) != 3 || fetches != 1 {
+		t.Errorf("expected 3 candles with one fetch, got %d candles and %d fetches", len(ranged), fetches)
+	}
+
+	if err := archiver.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCandleArchiverUnreadErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "candles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive, err := OpenCandleArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetches := make(chan struct{}, 1)
+	archiver := New("", "").NewCandleArchiver(archive, []string{"BTC-LTC"}, []string{TickIntervalOneMin}, time.Millisecond)
+	archiver.ticks = func(market, interval string) ([]Candle, error) {
+		select {
+		case fetches <- struct{}{}:
+		default:
+		}
+		return nil, errors.New("unavailable")
+	}
+
+	archiver.Start()
+
+	//nobody reads Error: syncing carries on past its buffer
+	for i := 0; i < 2*candleArchiverErrorBuffer; i++ {
+		select {
+		case <-fetches:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("archiving stalled after %d fetches", i)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := archiver.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(archiver.Error) != candleArchiverErrorBuffer || archiver.DroppedErrors() == 0 {
+		t.Errorf("expected a full Error and dropped errors, got %d buffered and %d dropped", len(archiver.Error), archiver.DroppedErrors())
+	}
+}
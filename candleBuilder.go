@@ -0,0 +1,364 @@
+package bittrex
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+)
+
+var (
+	//candleFinalizeDelay how long after a boundary a CandleStream waits for late trades before closing the candle.
+	candleFinalizeDelay = 2 * time.Second
+
+	//seedIntervals Bittrex tick intervals, largest first.
+	seedIntervals = []struct {
+		name     string
+		duration time.Duration
+	}{
+		{TickIntervalDay, 24 * time.Hour},
+		{TickIntervalHour, time.Hour},
+		{TickIntervalThirtyMin, 30 * time.Minute},
+		{TickIntervalFiveMin, 5 * time.Minute},
+		{TickIntervalOneMin, time.Minute},
+	}
+)
+
+//CandleUpdate a candle being built by a CandleBuilder. Final is set once the candle's interval is over,
+//after which that candle never changes again.
+type CandleUpdate struct {
+	Market   string
+	Interval time.Duration
+	Candle   Candle
+	Final    bool
+}
+
+//CandleBuilder aggregates trades into candles of any interval (ex: 15 * time.Second, 4 * time.Hour).
+//Intervals are aligned to UTC midnight. Intervals without trades produce a flat candle at the previous close
+//with zero volume, once a previous close is known.
+type CandleBuilder struct {
+	Market   string
+	Interval time.Duration
+	start    time.Time
+	current  *Candle
+	last     *big.Float
+	seeded   time.Time
+}
+
+//NewCandleBuilder builder for market with the given interval.
+func NewCandleBuilder(market string, interval time.Duration) *CandleBuilder {
+	return &CandleBuilder{
+		Market:   market,
+		Interval: interval,
+	}
+}
+
+//SeedTickInterval the largest TickInterval const that evenly divides interval, for seeding a builder
+//from PubMarketGetTicks. ok is false for intervals below a minute or not a whole number of minutes.
+func SeedTickInterval(interval time.Duration) (tickInterval string, ok bool) {
+	for _, seed := range seedIntervals {
+		if interval >= seed.duration && interval%seed.duration == 0 {
+			return seed.name, true
+		}
+	}
+
+	return "", false
+}
+
+//Seed initialize the builder from historical candles (oldest first) of a smaller interval that divides Interval,
+//ex: from PubMarketGetTicks with the interval returned by SeedTickInterval. The candles falling in the same
+//interval as the last one become the current, still open, candle. The last candle itself may still be open, so it
+//is left out: the trades from its start on are expected through AddTrade (ex: a market history backfill), and the
+//trades before it are ignored as already seeded.
+func (b *CandleBuilder) Seed(candles []Candle) {
+	if len(candles) == 0 {
+		return
+	}
+
+	latest := candles[len(candles)-1]
+	b.seeded = time.Time(latest.TimeStamp)
+	b.start = b.seeded.Truncate(b.Interval)
+	b.current = nil
+
+	//the price before the open tick: the close of the one before it, else its own open.
+	if len(candles) > 1 {
+		b.last = new(big.Float).Set(candles[len(candles)-2].Close)
+	} else {
+		b.last = new(big.Float).Set(latest.Open)
+	}
+
+	for _, candle := range candles[:len(candles)-1] {
+		if !time.Time(candle.TimeStamp).Truncate(b.Interval).Equal(b.start) {
+			continue
+		}
+
+		if b.current == nil {
+			seeded := copyCandle(candle)
+			seeded.TimeStamp = BittrexTimestamp(b.start)
+			b.current = &seeded
+			continue
+		}
+
+		mergeCandle(b.current, candle)
+	}
+}
+
+//AddTrade add a trade to the current candle. Returns the final candles of any intervals that ended before the
+//trade, followed by the updated current candle. Trades older than the current interval, or already seeded, are
+//ignored.
+func (b *CandleBuilder) AddTrade(trade Trade) []CandleUpdate {
+	if trade.Price == nil || trade.Quantity == nil || time.Time(trade.TimeStamp).Before(b.seeded) {
+		return nil
+	}
+
+	bucket := time.Time(trade.TimeStamp).Truncate(b.Interval)
+
+	if b.start.IsZero() {
+		b.start = bucket
+	}
+
+	if bucket.Before(b.start) {
+		return nil
+	}
+
+	updates := b.advanceTo(bucket)
+
+	baseVolume := new(big.Float).Mul(trade.Quantity, trade.Price)
+
+	if b.current == nil {
+		b.current = &Candle{
+			TimeStamp:  BittrexTimestamp(b.start),
+			Open:       new(big.Float).Set(trade.Price),
+			Close:      new(big.Float).Set(trade.Price),
+			High:       new(big.Float).Set(trade.Price),
+			Low:        new(big.Float).Set(trade.Price),
+			Volume:     new(big.Float).Set(trade.Quantity),
+			BaseVolume: baseVolume,
+		}
+	} else {
+		mergeCandle(b.current, Candle{
+			Open:       trade.Price,
+			Close:      trade.Price,
+			High:       trade.Price,
+			Low:        trade.Price,
+			Volume:     trade.Quantity,
+			BaseVolume: baseVolume,
+		})
+	}
+
+	b.last = new(big.Float).Set(trade.Price)
+
+	return append(updates, b.update(*b.current, false))
+}
+
+//Advance close every interval that ended at or before now, including intervals without trades.
+func (b *CandleBuilder) Advance(now time.Time) []CandleUpdate {
+	if b.start.IsZero() {
+		return nil
+	}
+
+	return b.advanceTo(now.Truncate(b.Interval))
+}
+
+//Current the open candle, if the current interval has seen a trade (or was seeded).
+func (b *CandleBuilder) Current() (Candle, bool) {
+	if b.current == nil {
+		return Candle{}, false
+	}
+
+	return copyCandle(*b.current), true
+}
+
+//NextBoundary end of the current interval.
+func (b *CandleBuilder) NextBoundary(now time.Time) time.Time {
+	if b.start.IsZero() {
+		return now.Truncate(b.Interval).Add(b.Interval)
+	}
+
+	return b.start.Add(b.Interval)
+}
+
+func (b *CandleBuilder) advanceTo(bucket time.Time) []CandleUpdate {
+	var updates []CandleUpdate
+
+	for b.start.Before(bucket) {
+		if b.current != nil {
+			updates = append(updates, b.update(*b.current, true))
+		} else if b.last != nil {
+			updates = append(updates, b.update(flatCandle(b.start, b.last), true))
+		}
+
+		b.start = b.start.Add(b.Interval)
+		b.current = nil
+	}
+
+	return updates
+}
+
+func (b *CandleBuilder) update(candle Candle, final bool) CandleUpdate {
+	return CandleUpdate{
+		Market:   b.Market,
+		Interval: b.Interval,
+		Candle:   copyCandle(candle),
+		Final:    final,
+	}
+}
+
+func flatCandle(start time.Time, price *big.Float) Candle {
+	return Candle{
+		TimeStamp:  BittrexTimestamp(start),
+		Open:       new(big.Float).Set(price),
+		Close:      new(big.Float).Set(price),
+		High:       new(big.Float).Set(price),
+		Low:        new(big.Float).Set(price),
+		Volume:     new(big.Float),
+		BaseVolume: new(big.Float),
+	}
+}
+
+//copyCandle deep copy, so the copy's big.Floats can be modified independently.
+func copyCandle(candle Candle) Candle {
+	copyFloat := func(f *big.Float) *big.Float {
+		if f == nil {
+			return nil
+		}
+		return new(big.Float).Set(f)
+	}
+
+	return Candle{
+		TimeStamp:  candle.TimeStamp,
+		Open:       copyFloat(candle.Open),
+		Close:      copyFloat(candle.Close),
+		High:       copyFloat(candle.High),
+		Low:        copyFloat(candle.Low),
+		Volume:     copyFloat(candle.Volume),
+		BaseVolume: copyFloat(candle.BaseVolume),
+	}
+}
+
+//mergeCandle fold next, which comes after into, into into.
+func mergeCandle(into *Candle, next Candle) {
+	if next.High.Cmp(into.High) > 0 {
+		into.High.Set(next.High)
+	}
+
+	if next.Low.Cmp(into.Low) < 0 {
+		into.Low.Set(next.Low)
+	}
+
+	into.Close.Set(next.Close)
+	into.Volume.Add(into.Volume, next.Volume)
+	into.BaseVolume.Add(into.BaseVolume, next.BaseVolume)
+}
+
+//CandleStream live candles for one market and interval, built from a TradeStream.
+type CandleStream struct {
+	Updates chan CandleUpdate
+	Error   chan error
+	builder *CandleBuilder
+	trades  *TradeStream
+
+	//ticks source for seeding, PubMarketGetTicks outside tests.
+	ticks func(market string, interval string) ([]Candle, error)
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	closed    chan struct{}
+}
+
+//WsCandles start a CandleStream. It is seeded from PubMarketGetTicks when the interval is a whole number
+//of minutes, then updated on every trade, with a Final update at every interval boundary. Stop it with Close.
+func (c *Client) WsCandles(market string, interval time.Duration) *CandleStream {
+	//the stream seeds from its own goroutine, so it gets its own session; the trade stream makes its own too.
+	s := newCandleStream(market, interval, c.session().PubMarketGetTicks)
+	s.trades = c.WsTradeStream(market)
+
+	go s.run()
+
+	return s
+}
+
+func newCandleStream(market string, interval time.Duration, ticks func(string, string) ([]Candle, error)) *CandleStream {
+	return &CandleStream{
+		Updates: make(chan CandleUpdate),
+		Error:   make(chan error),
+		builder: NewCandleBuilder(market, interval),
+		ticks:   ticks,
+		closing: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+//Close stop the stream and its trade stream. Updates and Error are closed when it returns nil.
+func (s *CandleStream) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+	})
+
+	select {
+	case <-s.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *CandleStream) run() {
+	defer func() {
+		s.trades.Close(context.Background())
+		close(s.Updates)
+		close(s.Error)
+		close(s.closed)
+	}()
+
+	if tickInterval, ok := SeedTickInterval(s.builder.Interval); ok {
+		if candles, err := s.ticks(s.builder.Market, tickInterval); err != nil {
+			s.sendError(err)
+		} else {
+			s.builder.Seed(candles)
+		}
+	}
+
+	boundary := time.NewTimer(time.Until(s.builder.NextBoundary(time.Now()).Add(candleFinalizeDelay)))
+	defer boundary.Stop()
+
+	errors := s.trades.Error
+
+	for {
+		var updates []CandleUpdate
+
+		select {
+		case <-s.closing:
+			return
+		case err, ok := <-errors:
+			if !ok {
+				errors = nil
+				continue
+			}
+			s.sendError(err)
+		case trade, ok := <-s.trades.Trades:
+			if !ok {
+				return
+			}
+			updates = s.builder.AddTrade(trade)
+		case now := <-boundary.C:
+			updates = s.builder.Advance(now.Add(-candleFinalizeDelay))
+			boundary.Reset(time.Until(s.builder.NextBoundary(now.Add(-candleFinalizeDelay)).Add(candleFinalizeDelay)))
+		}
+
+		for _, update := range updates {
+			select {
+			case s.Updates <- update:
+			case <-s.closing:
+				return
+			}
+		}
+	}
+}
+
+func (s *CandleStream) sendError(err error) {
+	select {
+	case s.Error <- err:
+	case <-s.closing:
+	}
+}
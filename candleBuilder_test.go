@@ -0,0 +1,153 @@
+package bittrex
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+var candleEpoch = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testTrade(offset time.Duration, price, quantity float64) Trade {
+	return Trade{
+		TimeStamp: BittrexTimestamp(candleEpoch.Add(offset)),
+		Price:     big.NewFloat(price),
+		Quantity:  big.NewFloat(quantity),
+		OrderType: "BUY",
+	}
+}
+
+func testCandle(offset time.Duration, open, high, low, close, volume float64) Candle {
+	return Candle{
+		TimeStamp:  BittrexTimestamp(candleEpoch.Add(offset)),
+		Open:       big.NewFloat(open),
+		High:       big.NewFloat(high),
+		Low:        big.NewFloat(low),
+		Close:      big.NewFloat(close),
+		Volume:     big.NewFloat(volume),
+		BaseVolume: big.NewFloat(volume * close),
+	}
+}
+
+func checkCandle(t *testing.T, label string, candle Candle, offset time.Duration, open, high, low, close, volume float64) {
+	got := func(f *big.Float) float64 {
+		v, _ := f.Float64()
+		return v
+	}
+
+	if !time.Time(candle.TimeStamp).Equal(candleEpoch.Add(offset)) ||
+		got(candle.Open) != open || got(candle.High) != high || got(candle.Low) != low ||
+		got(candle.Close) != close || got(candle.Volume) != volume {
+		t.Errorf(
+			"%s: got %s O%v H%v L%v C%v V%v, wanted %s O%v H%v L%v C%v V%v",
+			label,
+			time.Time(candle.TimeStamp), got(candle.Open), got(candle.High), got(candle.Low), got(candle.Close), got(candle.Volume),
+			candleEpoch.Add(offset), open, high, low, close, volume,
+		)
+	}
+}
+
+func TestCandleBuilderTrades(t *testing.T) {
+	b := NewCandleBuilder("BTC-LTC", 15*time.Second)
+
+	updates := b.AddTrade(testTrade(1*time.Second, 10, 1))
+	if len(updates) != 1 || updates[0].Final {
+		t.Fatalf("expected one open update, got %+v", updates)
+	}
+	checkCandle(t, "first trade", updates[0].Candle, 0, 10, 10, 10, 10, 1)
+
+	b.AddTrade(testTrade(5*time.Second, 12, 2))
+	updates = b.AddTrade(testTrade(14*time.Second, 9, 1))
+	checkCandle(t, "third trade", updates[0].Candle, 0, 10, 12, 9, 9, 4)
+
+	//emitted candles don't change afterwards
+	emitted := updates[0].Candle
+	b.AddTrade(testTrade(14*time.Second, 20, 1))
+	checkCandle(t, "emitted copy", emitted, 0, 10, 12, 9, 9, 4)
+
+	//next trade skips an empty interval
+	updates = b.AddTrade(testTrade(31*time.Second, 11, 1))
+	if len(updates) != 3 || !updates[0].Final || !updates[1].Final || updates[2].Final {
+		t.Fatalf("expected two final updates and one open, got %+v", updates)
+	}
+	checkCandle(t, "closed", updates[0].Candle, 0, 10, 20, 9, 20, 5)
+	checkCandle(t, "empty", updates[1].Candle, 15*time.Second, 20, 20, 20, 20, 0)
+	checkCandle(t, "open", updates[2].Candle, 30*time.Second, 11, 11, 11, 11, 1)
+
+	//late trades are ignored
+	if updates := b.AddTrade(testTrade(29*time.Second, 1, 1)); updates != nil {
+		t.Errorf("expected late trade to be ignored, got %+v", updates)
+	}
+
+	//the clock closes intervals without trades
+	updates = b.Advance(candleEpoch.Add(61 * time.Second))
+	if len(updates) != 2 {
+		t.Fatalf("expected two final updates, got %+v", updates)
+	}
+	checkCandle(t, "advance closed", updates[0].Candle, 30*time.Second, 11, 11, 11, 11, 1)
+	checkCandle(t, "advance empty", updates[1].Candle, 45*time.Second, 11, 11, 11, 11, 0)
+
+	if next := b.NextBoundary(candleEpoch.Add(61 * time.Second)); !next.Equal(candleEpoch.Add(75 * time.Second)) {
+		t.Errorf("unexpected next boundary %s", next)
+	}
+}
+
+func TestCandleBuilderSeed(t *testing.T) {
+	if interval, ok := SeedTickInterval(15 * time.Minute); !ok || interval != TickIntervalFiveMin {
+		t.Errorf("unexpected seed interval %s for 15m", interval)
+	}
+	if interval, ok := SeedTickInterval(4 * time.Hour); !ok || interval != TickIntervalHour {
+		t.Errorf("unexpected seed interval %s for 4h", interval)
+	}
+	if _, ok := SeedTickInterval(15 * time.Second); ok {
+		t.Errorf("15s should not be seedable")
+	}
+
+	b := NewCandleBuilder("BTC-LTC", 15*time.Minute)
+
+	b.Seed([]Candle{
+		testCandle(5*time.Minute, 1, 2, 1, 2, 1),
+		testCandle(15*time.Minute, 2, 3, 2, 3, 1),
+		testCandle(20*time.Minute, 3, 5, 1, 4, 2),
+	})
+
+	//the last tick may still be open, so only the ones before it are seeded
+	current, ok := b.Current()
+	if !ok {
+		t.Fatalf("expected a seeded candle")
+	}
+	checkCandle(t, "seeded", current, 15*time.Minute, 2, 3, 2, 3, 1)
+
+	//the backfill repeats trades the seed counted, then brings those of the last tick
+	backfill := []Trade{
+		testTrade(17*time.Minute, 3, 1),
+		testTrade(20*time.Minute, 5, 1),
+		testTrade(22*time.Minute, 1, 1),
+	}
+	for _, trade := range backfill {
+		b.AddTrade(trade)
+	}
+
+	current, _ = b.Current()
+	checkCandle(t, "seeded + backfill", current, 15*time.Minute, 2, 5, 1, 1, 3)
+
+	updates := b.AddTrade(testTrade(26*time.Minute, 6, 1))
+	checkCandle(t, "seeded + backfill + trade", updates[0].Candle, 15*time.Minute, 2, 6, 1, 6, 4)
+
+	//a seed whose last tick opens a new interval
+	b = NewCandleBuilder("BTC-LTC", 15*time.Minute)
+	b.Seed([]Candle{
+		testCandle(10*time.Minute, 1, 2, 1, 2, 1),
+		testCandle(15*time.Minute, 2, 3, 2, 3, 1),
+	})
+
+	if _, ok := b.Current(); ok {
+		t.Errorf("expected no current candle before the backfill")
+	}
+
+	updates = b.Advance(candleEpoch.Add(30 * time.Minute))
+	if len(updates) != 1 || !updates[0].Final {
+		t.Fatalf("expected one final flat candle, got %+v", updates)
+	}
+	checkCandle(t, "flat", updates[0].Candle, 15*time.Minute, 2, 2, 2, 2, 0)
+}
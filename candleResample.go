@@ -0,0 +1,112 @@
+package bittrex
+
+import (
+	"fmt"
+	"time"
+)
+
+//CandleOrderError candles are not in strictly increasing TimeStamp order at Index.
+type CandleOrderError struct {
+	Index    int
+	Previous time.Time
+	Current  time.Time
+}
+
+func (e CandleOrderError) Error() string {
+	return fmt.Sprintf("candle %d at %s is not after the previous candle at %s", e.Index, e.Current.Format(time.RFC3339), e.Previous.Format(time.RFC3339))
+}
+
+//CandleGap Count missing candles starting at From.
+type CandleGap struct {
+	From  time.Time
+	Count int
+}
+
+//ValidateCandles check that TimeStamps are strictly increasing.
+func ValidateCandles(candles []Candle) error {
+	for i := 1; i < len(candles); i++ {
+		previous := time.Time(candles[i-1].TimeStamp)
+		current := time.Time(candles[i].TimeStamp)
+
+		if !current.After(previous) {
+			return CandleOrderError{i, previous, current}
+		}
+	}
+
+	return nil
+}
+
+//FindCandleGaps missing periods in candles of the given interval (ex: a minute with no oneMin candle).
+func FindCandleGaps(candles []Candle, interval time.Duration) ([]CandleGap, error) {
+	if err := ValidateCandles(candles); err != nil {
+		return nil, err
+	}
+
+	var gaps []CandleGap
+
+	for i := 1; i < len(candles); i++ {
+		expected := time.Time(candles[i-1].TimeStamp).Add(interval)
+		current := time.Time(candles[i].TimeStamp)
+
+		if current.After(expected) {
+			gaps = append(gaps, CandleGap{expected, int(current.Sub(expected) / interval)})
+		}
+	}
+
+	return gaps, nil
+}
+
+//FillCandleGaps forward-fill missing periods with flat candles at the previous close and zero volume.
+//The input is not modified.
+func FillCandleGaps(candles []Candle, interval time.Duration) ([]Candle, error) {
+	if err := ValidateCandles(candles); err != nil {
+		return nil, err
+	}
+
+	var filled []Candle
+
+	for i, candle := range candles {
+		if i > 0 {
+			previous := candles[i-1]
+			for missing := time.Time(previous.TimeStamp).Add(interval); missing.Before(time.Time(candle.TimeStamp)); missing = missing.Add(interval) {
+				filled = append(filled, flatCandle(missing, previous.Close))
+			}
+		}
+
+		filled = append(filled, copyCandle(candle))
+	}
+
+	return filled, nil
+}
+
+//ResampleCandles aggregate candles into a larger interval (ex: fiveMin into 15 * time.Minute, hour into 4 * time.Hour,
+//day into 7 * 24 * time.Hour). Intervals are aligned to UTC midnight, weeks start on Monday.
+//Open is the first open, Close the last close, High/Low the extremes and Volume/BaseVolume the sums.
+//When fillGaps is set, periods with no source candles are forward-filled like FillCandleGaps.
+//The input is not modified.
+func ResampleCandles(candles []Candle, interval time.Duration, fillGaps bool) ([]Candle, error) {
+	if err := ValidateCandles(candles); err != nil {
+		return nil, err
+	}
+
+	var resampled []Candle
+
+	for _, candle := range candles {
+		bucket := time.Time(candle.TimeStamp).Truncate(interval)
+
+		if last := len(resampled) - 1; last >= 0 && time.Time(resampled[last].TimeStamp).Equal(bucket) {
+			mergeCandle(&resampled[last], candle)
+			continue
+		}
+
+		next := copyCandle(candle)
+		next.TimeStamp = BittrexTimestamp(bucket)
+		resampled = append(resampled, next)
+	}
+
+	if fillGaps {
+		return FillCandleGaps(resampled, interval)
+	}
+
+	return resampled, nil
+}
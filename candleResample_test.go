@@ -0,0 +1,83 @@
+package bittrex
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResampleCandles(t *testing.T) {
+	fiveMin := []Candle{
+		testCandle(0, 1, 2, 1, 2, 1),
+		testCandle(5*time.Minute, 2, 4, 2, 3, 2),
+		testCandle(10*time.Minute, 3, 3, 0.5, 1, 3),
+		//15m bucket is missing entirely
+		testCandle(30*time.Minute, 5, 6, 5, 6, 1),
+	}
+
+	resampled, err := ResampleCandles(fiveMin, 15*time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resampled) != 2 {
+		t.Fatalf("expected 2 candles, got %d", len(resampled))
+	}
+	checkCandle(t, "first 15m", resampled[0], 0, 1, 4, 0.5, 1, 6)
+	checkCandle(t, "second 15m", resampled[1], 30*time.Minute, 5, 6, 5, 6, 1)
+
+	if base, _ := resampled[0].BaseVolume.Float64(); base != 2+6+3 {
+		t.Errorf("unexpected base volume %v", base)
+	}
+
+	//the input is untouched
+	checkCandle(t, "input", fiveMin[0], 0, 1, 2, 1, 2, 1)
+
+	filled, err := ResampleCandles(fiveMin, 15*time.Minute, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(filled) != 3 {
+		t.Fatalf("expected 3 candles, got %d", len(filled))
+	}
+	checkCandle(t, "filled 15m", filled[1], 15*time.Minute, 1, 1, 1, 1, 0)
+
+	weekly, err := ResampleCandles([]Candle{
+		testCandle(0, 1, 1, 1, 1, 1),              //Monday 2018-01-01
+		testCandle(6*24*time.Hour, 2, 2, 2, 2, 1), //Sunday
+		testCandle(7*24*time.Hour, 3, 3, 3, 3, 1), //next Monday
+	}, 7*24*time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(weekly) != 2 {
+		t.Fatalf("expected 2 weekly candles, got %d", len(weekly))
+	}
+	checkCandle(t, "week", weekly[0], 0, 1, 2, 1, 2, 2)
+}
+
+func TestCandleGaps(t *testing.T) {
+	candles := []Candle{
+		testCandle(0, 1, 1, 1, 1, 1),
+		testCandle(time.Minute, 1, 1, 1, 1, 1),
+		testCandle(4*time.Minute, 1, 1, 1, 1, 1),
+	}
+
+	gaps, err := FindCandleGaps(candles, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(gaps) != 1 || gaps[0].Count != 2 || !gaps[0].From.Equal(candleEpoch.Add(2*time.Minute)) {
+		t.Errorf("unexpected gaps %+v", gaps)
+	}
+
+	unordered := []Candle{candles[1], candles[0]}
+
+	if _, err := ResampleCandles(unordered, time.Hour, false); err == nil {
+		t.Errorf("expected an ordering error")
+	} else if orderErr, ok := err.(CandleOrderError); !ok || orderErr.Index != 1 {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -6,9 +6,14 @@ import (
 )
 
 // MarketBuyLimit - market/buylimit
+// checked by the OrderValidator first, when one is set with SetOrderValidator
 func (c *Client) MarketBuyLimit(market string, quantity *big.Float, rate *big.Float) (TransactionID, error) {
 	defer c.clearError()
 
+	if err := c.validateOrder(market, OrderSideBuy, quantity, rate); err != nil {
+		return TransactionID{}, err
+	}
+
 	params := map[string]string{
 		"apikey":   c.apiKey,
 		"market":   market,
@@ -40,9 +45,14 @@ func (c *Client) MarketBuyLimit(market string, quantity *big.Float, rate *big.Fl
 }
 
 // MarketSellLimit - market/selllimit
+// checked by the OrderValidator first, when one is set with SetOrderValidator
 func (c *Client) MarketSellLimit(market string, quantity *big.Float, rate *big.Float) (TransactionID, error) {
 	defer c.clearError()
 
+	if err := c.validateOrder(market, OrderSideSell, quantity, rate); err != nil {
+		return TransactionID{}, err
+	}
+
 	params := map[string]string{
 		"apikey":   c.apiKey,
 		"market":   market,
@@ -1,6 +1,10 @@
 package bittrex
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
 
 // PublicGetMarkets - public/getmarkets
 func (c *Client) PublicGetMarkets() ([]MarketDescription, error) {
@@ -192,22 +196,56 @@ func (c *Client) PublicGetMarketSummary(market string) (MarketSummary, error) {
 }
 
 // PublicGetOrderBook - public/getorderbook
-func (c *Client) PublicGetOrderBook(market string, orderType string) (OrderBook, error) {
+func (c *Client) PublicGetOrderBook(market string, orderType OrderBookSide) (OrderBook, error) {
+	return c.PublicGetOrderBookDepth(market, orderType, 0)
+}
+
+// PublicGetOrderBookDepth - public/getorderbook with the depth parameter: how many orders per side to return.
+// The api defaults to 20 and allows at most 50. A depth of 0 leaves the parameter out.
+func (c *Client) PublicGetOrderBookDepth(market string, orderType OrderBookSide, depth int) (OrderBook, error) {
 	defer c.clearError()
 
+	defaultValue := OrderBook{}
+
+	if !orderType.Valid() {
+		c.setError("validate request", fmt.Sprintf("invalid order book type %q", orderType))
+		return defaultValue, c.err
+	}
+
+	params := map[string]string{"market": market, "type": string(orderType)}
+
+	if depth > 0 {
+		params["depth"] = strconv.Itoa(depth)
+	}
+
 	var parsedResponse *baseResponse
 
-	parsedResponse = c.sendRequest("/public/getorderbook", map[string]string{"market": market, "type": orderType})
-	defaultValue := OrderBook{}
+	parsedResponse = c.sendRequest("/public/getorderbook", params)
+
+	if c.err != nil {
+		return defaultValue, c.err
+	}
+
 	if parsedResponse.Success != true {
 		c.setError("api error - /public/getorderbook", parsedResponse.Message)
 		return defaultValue, c.err
 	}
 
 	var response OrderBook
+	var parseErr error
+
+	//a single side comes back as a bare list of orders.
+	switch orderType {
+	case OrderBookBuy:
+		parseErr = json.Unmarshal(parsedResponse.Result, &response.Buy)
+	case OrderBookSell:
+		parseErr = json.Unmarshal(parsedResponse.Result, &response.Sell)
+	default:
+		parseErr = json.Unmarshal(parsedResponse.Result, &response)
+	}
 
-	if err := json.Unmarshal(parsedResponse.Result, &response); err != nil {
-		c.setError("api error - public/getorderbook", err.Error())
+	if parseErr != nil {
+		c.setError("api error - public/getorderbook", parseErr.Error())
 		return defaultValue, c.err
 	}
 
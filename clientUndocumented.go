@@ -1,6 +1,9 @@
 package bittrex
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"math/big"
+)
 
 const (
 	//TickIntervalOneMin oneMin = 10 days worth of candles
@@ -19,6 +22,14 @@ const (
 	TickIntervalDay = "day"
 )
 
+const (
+	//TimeInEffectGoodTilCancelled the order rests on the book until it fills or is cancelled
+	TimeInEffectGoodTilCancelled = "GOOD_TIL_CANCELLED"
+
+	//TimeInEffectImmediateOrCancel whatever does not fill at once is cancelled
+	TimeInEffectImmediateOrCancel = "IMMEDIATE_OR_CANCEL"
+)
+
 // PubMarketGetTicks - /pub/market/getticks
 // interval must be one of the TickInterval consts
 func (c *Client) PubMarketGetTicks(market string, interval string) ([]Candle, error) {
@@ -101,3 +112,64 @@ func (c *Client) PubMarketGetLatestTick(market string, interval string) (Candle,
 
 	return response[0], nil
 }
+
+// KeyMarketTradeBuy - /key/market/tradebuy
+// places a limit buy; timeInEffect must be one of the TimeInEffect consts
+func (c *Client) KeyMarketTradeBuy(market string, quantity *big.Float, rate *big.Float, timeInEffect string) (TransactionID, error) {
+	return c.keyMarketTrade("key/market/tradebuy", OrderSideBuy, market, quantity, rate, timeInEffect)
+}
+
+// KeyMarketTradeSell - /key/market/tradesell
+// places a limit sell; timeInEffect must be one of the TimeInEffect consts
+func (c *Client) KeyMarketTradeSell(market string, quantity *big.Float, rate *big.Float, timeInEffect string) (TransactionID, error) {
+	return c.keyMarketTrade("key/market/tradesell", OrderSideSell, market, quantity, rate, timeInEffect)
+}
+
+func (c *Client) keyMarketTrade(endpoint string, side OrderSide, market string, quantity *big.Float, rate *big.Float, timeInEffect string) (TransactionID, error) {
+	defer c.clearError()
+
+	if err := c.validateOrder(market, side, quantity, rate); err != nil {
+		return TransactionID{}, err
+	}
+
+	params := map[string]string{
+		"apikey":        c.apiKey,
+		"MarketName":    market,
+		"OrderType":     "LIMIT",
+		"Quantity":      quantity.String(),
+		"Rate":          rate.String(),
+		"TimeInEffect":  timeInEffect,
+		"ConditionType": "NONE",
+		"Target":        "0",
+		"useApi2":       "true",
+	}
+
+	var parsedResponse *baseResponse
+
+	parsedResponse = c.sendRequest(endpoint, params)
+
+	if c.err != nil {
+		return TransactionID{}, c.err
+	}
+
+	if parsedResponse.Success != true {
+		c.setError("api error - "+endpoint, parsedResponse.Message)
+		return TransactionID{}, c.err
+	}
+
+	var response struct {
+		OrderID string `json:"OrderId"`
+	}
+
+	if err := json.Unmarshal(parsedResponse.Result, &response); err != nil {
+		c.setError("api error - "+endpoint, err.Error())
+		return TransactionID{}, c.err
+	}
+
+	if response.OrderID == "" {
+		c.setError("validate response", "trade response had no order id")
+		return TransactionID{}, c.err
+	}
+
+	return TransactionID{UUID: response.OrderID}, nil
+}
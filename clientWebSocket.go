@@ -2,15 +2,91 @@ package bittrex
 
 // placeholder
 import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
 	"time"
 
-	"github.com/thebotguys/signalr"
+	"github.com/gorilla/websocket"
+	"github.com/technicalviking/bittrex/signalr"
 )
 
 type clientMethod = func(string, string, []json.RawMessage)
 
+//WebsocketConfig transport options for the SignalR client behind websocket subscriptions.
+//Cookies in Jar are shared between /signalr/negotiate and /signalr/connect, which is what
+//lets a cloudflare clearance cookie obtained by the application get past the DDOS protection.
+//When HTTPClient has a Jar of its own, that one is shared instead of Jar.
+//Cloudflare ties cf_clearance to the user agent that solved the challenge, so set UserAgent to match.
+type WebsocketConfig struct {
+	HTTPClient *http.Client
+	Dialer     *websocket.Dialer
+	Header     http.Header
+	Jar        http.CookieJar
+	UserAgent  string
+	//Scheme and Host override https://socket.bittrex.com (ex: a proxy, or a signalrtest server)
+	Scheme string
+	Host   string
+}
+
+//SetWebsocketConfig replace the transport options used by subsequent websocket subscriptions.
+func (c *Client) SetWebsocketConfig(config WebsocketConfig) {
+	c.wsConfig = config
+}
+
+//SetWebsocketCookies add cookies (ex: cf_clearance and __cfduid) for the configured socket host to the websocket cookie
+//jar. A jar is created if none was configured. Call it after SetWebsocketConfig, which may change the host and jar.
+func (c *Client) SetWebsocketCookies(cookies ...*http.Cookie) {
+	jar := c.wsConfig.jar()
+	if jar == nil {
+		c.wsConfig.Jar, _ = cookiejar.New(nil)
+		jar = c.wsConfig.Jar
+	}
+
+	scheme, host := c.wsConfig.endpoint()
+	jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: "/"}, cookies)
+}
+
+//endpoint scheme and host subscriptions connect to.
+func (w WebsocketConfig) endpoint() (string, string) {
+	scheme, host := "https", websocketBaseURI
+	if w.Scheme != "" {
+		scheme = w.Scheme
+	}
+	if w.Host != "" {
+		host = w.Host
+	}
+
+	return scheme, host
+}
+
+//jar the cookie jar negotiate and connect share, nil when none was configured.
+func (w WebsocketConfig) jar() http.CookieJar {
+	if w.HTTPClient != nil && w.HTTPClient.Jar != nil {
+		return w.HTTPClient.Jar
+	}
+	return w.Jar
+}
+
+func (w WebsocketConfig) apply(client *signalr.Client) {
+	client.HTTPClient = w.HTTPClient
+	client.Dialer = w.Dialer
+	client.Header = w.Header
+	client.UserAgent = w.UserAgent
+
+	if w.Jar != nil {
+		client.Jar = w.Jar
+	}
+}
+
 type bittrexSubParseError struct {
 	hub        string
 	method     string
@@ -32,7 +108,8 @@ func (b bittrexSubParseError) Error() string {
 }
 
 //BittrexSubscription struct representing a connection to the Bittrex websocket API.
-//sending a value to Done or closing Done
+//Call Close (or send a value to / close Done) to shut it down. Data and Error are closed
+//once every goroutine that could send on them has exited.
 type BittrexSubscription struct {
 	Data     chan ExchangeState
 	Error    chan error
@@ -40,91 +117,257 @@ type BittrexSubscription struct {
 	market   string
 	wsClient *signalr.Client
 	timeout  time.Duration
+	scheme   string
+	host     string
+	buffer   subscriptionBuffer
+
+	//onFinish closes the channels of a subscription built on this one, ex: SummarySubscription.Data.
+	onFinish func()
+
+	mutex     sync.Mutex
+	stopping  bool
+	connected bool
+	inflight  sync.WaitGroup
+	closeOnce sync.Once
+	closing   chan struct{}
+	closed    chan struct{}
 }
 
-func newSub(market string, timeout time.Duration) *BittrexSubscription {
+func newSub(market string, timeout time.Duration, config WebsocketConfig, subConfig SubscriptionConfig) *BittrexSubscription {
+	wsClient := signalr.NewWebsocketClient()
+	config.apply(wsClient)
+
+	scheme, host := config.endpoint()
+
+	dataBuffer := subConfig.DataBuffer
+	if subConfig.Overflow != OverflowBlock && dataBuffer < 1 {
+		dataBuffer = 1
+	}
+
 	return &BittrexSubscription{
-		make(chan ExchangeState),
-		make(chan error),
-		make(chan bool),
-		market,
-		signalr.NewWebsocketClient(),
-		timeout,
+		Data:     make(chan ExchangeState, dataBuffer),
+		Error:    make(chan error, subConfig.ErrorBuffer),
+		Done:     make(chan bool),
+		market:   market,
+		wsClient: wsClient,
+		timeout:  timeout,
+		scheme:   scheme,
+		host:     host,
+		buffer:   subscriptionBuffer{policy: subConfig.Overflow},
+		closing:  make(chan struct{}),
+		closed:   make(chan struct{}),
 	}
 }
 
-func (b *BittrexSubscription) setSubClientMethods(filter string) {
+func (b *BittrexSubscription) setSubClientMethods(filter string, parse func(hub, method string, msg json.RawMessage)) {
 	b.wsClient.OnClientMethod = func(hub string, method string, msgs []json.RawMessage) {
 		if hub != websocketHub || method != filter {
 			return
 		}
+		if !b.enter() {
+			return
+		}
+		defer b.inflight.Done()
+
 		for _, msg := range msgs {
-			b.parseMessage(hub, method, msg)
+			parse(hub, method, msg)
 		}
 
 	}
 
 	b.wsClient.OnMessageError = func(err error) {
-		b.Error <- fmt.Errorf("Remote Error: %s", err.Error())
+		if !b.enter() {
+			return
+		}
+		defer b.inflight.Done()
+
+		b.sendError(fmt.Errorf("Remote Error: %s", err.Error()))
 	}
 }
 
-func (b *BittrexSubscription) parseMessage(hub, method string, msg json.RawMessage) {
+//enter register an in-flight sender. Returns false once shutdown has started, in which case nothing may be sent.
+func (b *BittrexSubscription) enter() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 
-	var exchangeState ExchangeState
+	if b.stopping {
+		return false
+	}
+
+	b.inflight.Add(1)
+	return true
+}
+
+//shutdown stop accepting work and close the socket. Safe to call any number of times, from any goroutine.
+func (b *BittrexSubscription) shutdown() {
+	b.closeOnce.Do(func() {
+		b.mutex.Lock()
+		b.stopping = true
+		connected := b.connected
+		b.mutex.Unlock()
+
+		close(b.closing)
+
+		if connected {
+			b.wsClient.Close()
+		}
+	})
+}
+
+//finish wait for dispatch and every in-flight sender, then close the channels exactly once.
+func (b *BittrexSubscription) finish() {
+	b.shutdown()
+	b.inflight.Wait()
+
+	b.mutex.Lock()
+	connected := b.connected
+	b.mutex.Unlock()
+
+	if connected {
+		<-b.wsClient.DisconnectedChannel
+	}
+
+	close(b.Data)
+	close(b.Error)
+	if b.onFinish != nil {
+		b.onFinish()
+	}
+	close(b.closed)
+}
+
+//Close stop the subscription and wait for its goroutines to exit. Data and Error are closed when it returns nil.
+//If ctx expires first, shutdown continues in the background and ctx.Err() is returned.
+func (b *BittrexSubscription) Close(ctx context.Context) error {
+	b.shutdown()
+
+	select {
+	case <-b.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	if parseErr := json.Unmarshal(msg, &exchangeState); parseErr != nil {
-		b.Error <- newSubError(hub, method, msg, parseErr)
+func (b *BittrexSubscription) parseMessage(hub, method string, msg json.RawMessage) {
+	exchangeState, err := b.decodeState(hub, method, msg)
+	if err != nil {
+		b.sendError(err)
 		return
 	}
 
 	if b.market == "" || exchangeState.MarketName == b.market {
-		b.Data <- exchangeState
+		b.sendData(exchangeState)
 	}
 }
 
-func (b *BittrexSubscription) connect() {
+func (b *BittrexSubscription) decodeState(hub, method string, msg json.RawMessage) (ExchangeState, error) {
 
-	connectDone := make(chan error)
+	var exchangeState ExchangeState
+
+	if decoded, decodeErr := decodeMessage(msg); decodeErr != nil {
+		return exchangeState, newSubError(hub, method, msg, decodeErr)
+	} else if parseErr := json.Unmarshal(decoded, &exchangeState); parseErr != nil {
+		return exchangeState, newSubError(hub, method, msg, parseErr)
+	}
+
+	return exchangeState, nil
+}
+
+//decodeMessage compressed payloads arrive as a JSON string holding base64 encoded raw deflate of the JSON body.
+//anything else is passed through untouched.
+func decodeMessage(msg json.RawMessage) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(msg)
+	if len(trimmed) == 0 || trimmed[0] != '"' {
+		return msg, nil
+	}
+
+	var encoded string
+	if err := json.Unmarshal(trimmed, &encoded); err != nil {
+		return nil, err
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := flate.NewReader(bytes.NewReader(compressed))
+	defer reader.Close()
+
+	return ioutil.ReadAll(reader)
+}
+
+func (b *BittrexSubscription) connect() bool {
+
+	if !b.enter() {
+		return false
+	}
+
+	connectDone := make(chan error, 1)
 
 	go func() {
-		connectDone <- b.wsClient.Connect("https", websocketBaseURI, []string{websocketHub})
+		defer b.inflight.Done()
+
+		connectErr := b.wsClient.Connect(b.scheme, b.host, []string{websocketHub})
+
+		b.mutex.Lock()
+		abandoned := b.stopping
+		b.connected = connectErr == nil && !abandoned
+		b.mutex.Unlock()
+
+		if connectErr == nil && abandoned {
+			//connected after a timeout or Close, nobody else will tear this socket down.
+			b.wsClient.Close()
+			<-b.wsClient.DisconnectedChannel
+		}
+
+		connectDone <- connectErr
 	}()
 
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+
 	select {
-	case <-time.After(b.timeout):
-		b.Error <- fmt.Errorf("timeout error")
+	case <-timer.C:
+		b.sendError(fmt.Errorf("timeout error"))
+		return false
+	case <-b.closing:
+		return false
 	case connectErr := <-connectDone:
-		b.Error <- fmt.Errorf("connection error: %v", connectErr)
+		if connectErr != nil {
+			b.sendError(fmt.Errorf("connection error: %v", connectErr))
+			return false
+		}
 	}
+
+	return true
 }
 
 func (b *BittrexSubscription) subToMarket() {
 	subMethod := "SubscribeToExchangeDeltas"
-	queryMethod := "QueryExchangeState" //return
 
-	var callHubErr error
 	var param interface{}
 
 	if b.market != "" {
 		param = b.market
 	}
 
-	if _, callHubErr = b.wsClient.CallHub(websocketHub, subMethod, param); callHubErr != nil {
-		b.Error <- fmt.Errorf("SubToMarket Error: %s", callHubErr.Error())
+	if _, callHubErr := b.wsClient.CallHub(websocketHub, subMethod, param); callHubErr != nil {
+		b.sendError(fmt.Errorf("SubToMarket Error: %s", callHubErr.Error()))
 		//b.wsClient.Close()
 		return
 	}
 
-	var queryResponse json.RawMessage
-
-	if queryResponse, callHubErr = b.wsClient.CallHub(websocketHub, queryMethod, param); callHubErr != nil {
-		b.Error <- fmt.Errorf("QueryExchangeState Error: %s", callHubErr.Error())
-		b.wsClient.Close()
+	exchangeState, queryErr := b.queryExchangeState()
+	if queryErr != nil {
+		b.sendError(queryErr)
+		b.shutdown()
 		return
 	}
 
-	b.parseMessage(websocketHub, queryMethod, queryResponse)
+	if b.market == "" || exchangeState.MarketName == b.market {
+		b.sendData(exchangeState)
+	}
 }
 
 func newSubError(hub, method string, msg json.RawMessage, parseErr error) error {
@@ -146,26 +389,53 @@ func newSubError(hub, method string, msg json.RawMessage, parseErr error) error
 //WsSubExchangeUpdates - Undocumented websocket endpoint for bittrex
 //market is an optional parameter.  passing an empty string subscribes to changes for all markets.
 //(actually that happens anyway, the param just filters what gets sent to the returned chan)
-//***DO NOT USE!  Have to figure out a way around the cloudflare DDOS protection, or wait
-//until bittrex deploys an official documented websocket API.***
+//The first state is a QueryExchangeState snapshot with Initial set, ready for LocalOrderBook.Apply.
+//Cloudflare blocks /signalr/negotiate unless the request carries a valid clearance cookie;
+//supply one with SetWebsocketCookies (and the matching user agent via SetWebsocketConfig) first.
 func (c *Client) WsSubExchangeUpdates(market string) *BittrexSubscription {
-	sub := newSub(market, c.timeout)
+	sub := newSub(market, c.timeout, c.wsConfig, c.subConfig)
 
-	sub.setSubClientMethods("updateExchangeState")
+	sub.setSubClientMethods("updateExchangeState", sub.parseMessage)
 
+	//closing Done is an alternative to Close.
+	sub.inflight.Add(1)
 	go func() {
-		sub.connect()
-		sub.subToMarket()
+		defer sub.inflight.Done()
 
 		select {
 		case <-sub.Done:
-			sub.wsClient.Close()
-			close(sub.Data)
-			close(sub.Error)
+			sub.shutdown()
+		case <-sub.closing:
+		}
+	}()
+
+	if market == "" && sub.buffer.policy == OverflowDropAndResync {
+		go func() {
+			defer sub.finish()
+
+			//sent blocking, an ErrorBuffer of 0 would drop it.
+			select {
+			case sub.Error <- ErrResyncNeedsMarket:
+			case <-sub.closing:
+			}
+		}()
+
+		return sub
+	}
+
+	go func() {
+		defer sub.finish()
+
+		if !sub.connect() {
+			return
+		}
+
+		sub.subToMarket()
+
+		select {
+		case <-sub.closing:
 		case <-sub.wsClient.DisconnectedChannel:
-			sub.Error <- fmt.Errorf("socket closed by remote host")
-			close(sub.Data)
-			close(sub.Error)
+			sub.sendError(fmt.Errorf("socket closed by remote host"))
 		}
 	}()
 
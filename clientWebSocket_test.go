@@ -1,23 +1,383 @@
 package bittrex
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/technicalviking/bittrex/signalr/signalrtest"
 )
 
+func newTestHub() (*signalrtest.Server, *Client) {
+	server := signalrtest.NewServer(50 * time.Millisecond)
+
+	server.Handle(websocketHub, "SubscribeToExchangeDeltas", func(args []json.RawMessage) (interface{}, error) {
+		return true, nil
+	})
+	//like the real hub, the snapshot names no market and is not flagged Initial.
+	server.Handle(websocketHub, "QueryExchangeState", func(args []json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"MarketName": nil, "Nounce": 1}, nil
+	})
+
+	client := NewWithCustomTimeout("", "", 5)
+	client.SetWebsocketConfig(WebsocketConfig{Scheme: "http", Host: server.Host})
+
+	return server, client
+}
+
+func expectState(t *testing.T, sub *BittrexSubscription, nounce int) {
+	select {
+	case d := <-sub.Data:
+		if d.MarketName != "BTC-LTC" || d.Nounce != nounce {
+			t.Errorf("unexpected exchange state %+v, wanted nounce %d", d, nounce)
+		}
+	case e := <-sub.Error:
+		t.Fatalf("subscription error %s", e.Error())
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for nounce %d", nounce)
+	}
+}
+
 func TestWsSubExchangeUpdates(t *testing.T) {
-	/* bittrexClient := New("", "")
+	server, client := newTestHub()
+	defer server.Close()
+
+	sub := client.WsSubExchangeUpdates("BTC-LTC")
+
+	expectState(t, sub, 1)
+
+	if err := server.Invoke(websocketHub, "updateExchangeState", map[string]interface{}{"MarketName": "BTC-ETH", "Nounce": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Invoke(websocketHub, "updateExchangeState", map[string]interface{}{"MarketName": "BTC-LTC", "Nounce": 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectState(t, sub, 2)
+
+	if err := server.InvokeCompressed(websocketHub, "updateExchangeState", map[string]interface{}{"MarketName": "BTC-LTC", "Nounce": 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectState(t, sub, 3)
+
+	close(sub.Done)
+}
+
+func TestWsSubLocalOrderBook(t *testing.T) {
+	server, client := newTestHub()
+	defer server.Close()
 
-	sub := bittrexClient.WsSubExchangeUpdates("")
+	sub := client.WsSubExchangeUpdates("BTC-LTC")
+	defer close(sub.Done)
+
+	book := NewLocalOrderBook("BTC-LTC")
+
+	apply := func() {
+		t.Helper()
 
-	for i := 0; i < 5; i++ {
 		select {
 		case d := <-sub.Data:
-			fmt.Printf("What is this: %v\n", d)
+			if err := book.Apply(d); err != nil {
+				t.Fatal(err)
+			}
 		case e := <-sub.Error:
-			t.Errorf("Error lol %s \n", e.Error())
-			i = 999
+			t.Fatalf("subscription error %s", e.Error())
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for an exchange state")
+		}
+	}
+
+	//the snapshot syncs the book, then deltas follow on
+	apply()
+	if !book.Synced() {
+		t.Fatal("expected the book synced from the snapshot")
+	}
+
+	if err := server.Invoke(websocketHub, "updateExchangeState", map[string]interface{}{"MarketName": "BTC-LTC", "Nounce": 2}); err != nil {
+		t.Fatal(err)
+	}
+	apply()
+
+	if !book.Synced() {
+		t.Error("expected the book still synced after the next delta")
+	}
+}
+
+func TestWsSubExchangeUpdatesDrop(t *testing.T) {
+	server, client := newTestHub()
+	defer server.Close()
+
+	sub := client.WsSubExchangeUpdates("BTC-LTC")
+
+	expectState(t, sub, 1)
+
+	server.Drop()
+
+	select {
+	case e := <-sub.Error:
+		if e == nil {
+			t.Errorf("expected disconnect error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for disconnect")
+	}
+}
+
+func waitForDropped(t *testing.T, sub *BittrexSubscription, dropped uint64) {
+	deadline := time.Now().Add(5 * time.Second)
+	for sub.Dropped() < dropped {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %d dropped messages, have %d", dropped, sub.Dropped())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func waitForBuffered(t *testing.T, sub *BittrexSubscription, buffered int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for len(sub.Data) < buffered {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %d buffered messages", buffered)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWsSubDropOldest(t *testing.T) {
+	server, client := newTestHub()
+	defer server.Close()
+
+	client.SetSubscriptionConfig(SubscriptionConfig{DataBuffer: 2, Overflow: OverflowDropOldest})
+
+	sub := client.WsSubExchangeUpdates("BTC-LTC")
+	defer close(sub.Done)
+
+	waitForBuffered(t, sub, 1)
+
+	//snapshot is nounce 1, only the last two of 1-5 survive
+	for nounce := 2; nounce <= 5; nounce++ {
+		if err := server.Invoke(websocketHub, "updateExchangeState", map[string]interface{}{"MarketName": "BTC-LTC", "Nounce": nounce}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitForDropped(t, sub, 3)
+
+	expectState(t, sub, 4)
+	expectState(t, sub, 5)
+}
+
+func TestWsSubDropAndResync(t *testing.T) {
+	server, client := newTestHub()
+	defer server.Close()
+
+	var snapshotNounce int32 = 1
+
+	server.Handle(websocketHub, "QueryExchangeState", func(args []json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"MarketName": nil, "Nounce": atomic.LoadInt32(&snapshotNounce)}, nil
+	})
+
+	client.SetSubscriptionConfig(SubscriptionConfig{DataBuffer: 1, Overflow: OverflowDropAndResync})
+
+	sub := client.WsSubExchangeUpdates("BTC-LTC")
+	defer close(sub.Done)
+
+	waitForBuffered(t, sub, 1)
+
+	invoke := func(nounce int) {
+		if err := server.Invoke(websocketHub, "updateExchangeState", map[string]interface{}{"MarketName": "BTC-LTC", "Nounce": nounce}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//buffer holds the snapshot, so nounce 2 is dropped and a resync is wanted
+	invoke(2)
+	waitForDropped(t, sub, 1)
+	expectState(t, sub, 1)
+
+	//room again: nounce 3 is dropped and replaced by a fresh snapshot
+	atomic.StoreInt32(&snapshotNounce, 3)
+	invoke(3)
+
+	select {
+	case d := <-sub.Data:
+		if !d.Initial || d.Nounce != 3 {
+			t.Errorf("expected resync snapshot at nounce 3, got %+v", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for resync snapshot")
+	}
+
+	invoke(4)
+	expectState(t, sub, 4)
+
+	if sub.Dropped() != 2 {
+		t.Errorf("expected 2 dropped messages, have %d", sub.Dropped())
+	}
+}
+
+func TestWsSubResyncNeedsMarket(t *testing.T) {
+	server, client := newTestHub()
+	defer server.Close()
+
+	client.SetSubscriptionConfig(SubscriptionConfig{Overflow: OverflowDropAndResync})
+	sub := client.WsSubExchangeUpdates("")
+
+	select {
+	case err := <-sub.Error:
+		if err != ErrResyncNeedsMarket {
+			t.Fatalf("expected ErrResyncNeedsMarket, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the subscription to be rejected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := sub.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-sub.Data; ok {
+		t.Error("expected Data closed")
+	}
+}
+
+var goroutineHeader = regexp.MustCompile(`^goroutine (\d+) `)
+
+//goroutines stacks of every running goroutine, keyed by goroutine id.
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+
+	stacks := make(map[string]string)
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		if match := goroutineHeader.FindStringSubmatch(stack); match != nil {
+			stacks[match[1]] = stack
+		}
+	}
+	return stacks
+}
+
+//verifyNoLeaks fail if goroutines not running before the test are still running after a grace period.
+func verifyNoLeaks(t *testing.T, before map[string]string) {
+	var leaked []string
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		leaked = leaked[:0]
+		for id, stack := range goroutines() {
+			if _, ok := before[id]; !ok && !strings.Contains(stack, "verifyNoLeaks") {
+				leaked = append(leaked, stack)
+			}
+		}
+		if len(leaked) == 0 {
+			return
+		}
+	}
+
+	t.Errorf("%d leaked goroutines:\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+}
+
+func TestWsSubClose(t *testing.T) {
+	before := goroutines()
+
+	server, client := newTestHub()
+
+	sub := client.WsSubExchangeUpdates("BTC-LTC")
+
+	expectState(t, sub, 1)
+
+	//nobody reads these, so dispatch is blocked inside the client method callback
+	for nounce := 2; nounce <= 4; nounce++ {
+		if err := server.Invoke(websocketHub, "updateExchangeState", map[string]interface{}{"MarketName": "BTC-LTC", "Nounce": nounce}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := sub.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %s", err.Error())
+	}
+
+	//closing twice, and closing Done as well, is harmless.
+	close(sub.Done)
+	if err := sub.Close(ctx); err != nil {
+		t.Fatalf("second Close failed: %s", err.Error())
+	}
+
+	for range sub.Data {
+	}
+	for range sub.Error {
+	}
+
+	server.Close()
+
+	verifyNoLeaks(t, before)
+}
+
+func TestWsSubCloseAfterConnectFailure(t *testing.T) {
+	before := goroutines()
+
+	server, client := newTestHub()
+	server.Close()
+
+	client.SetSubscriptionConfig(SubscriptionConfig{ErrorBuffer: 1, Overflow: OverflowDropOldest})
+
+	sub := client.WsSubExchangeUpdates("BTC-LTC")
+
+	select {
+	case e := <-sub.Error:
+		if e == nil {
+			t.Errorf("expected connection error")
 		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for connection error")
 	}
 
-	close(sub.Done) */
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := sub.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %s", err.Error())
+	}
+
+	verifyNoLeaks(t, before)
+}
+
+func TestSetWebsocketCookies(t *testing.T) {
+	client := NewWithCustomTimeout("", "", 5)
+	client.SetWebsocketConfig(WebsocketConfig{Scheme: "http", Host: "127.0.0.1:8080"})
+	client.SetWebsocketCookies(&http.Cookie{Name: "cf_clearance", Value: "cleared"})
+
+	configured := &url.URL{Scheme: "http", Host: "127.0.0.1:8080", Path: "/signalr/negotiate"}
+	if cookies := client.wsConfig.Jar.Cookies(configured); len(cookies) != 1 || cookies[0].Value != "cleared" {
+		t.Errorf("expected the cookie for the configured host, got %v", cookies)
+	}
+
+	if cookies := client.wsConfig.Jar.Cookies(&url.URL{Scheme: "https", Host: websocketBaseURI, Path: "/"}); len(cookies) != 0 {
+		t.Errorf("expected no cookie for %s, got %v", websocketBaseURI, cookies)
+	}
+
+	//an HTTPClient's own jar is the one negotiate and connect share
+	jar, _ := cookiejar.New(nil)
+	client.SetWebsocketConfig(WebsocketConfig{Scheme: "http", Host: "127.0.0.1:8080", HTTPClient: &http.Client{Jar: jar}})
+	client.SetWebsocketCookies(&http.Cookie{Name: "cf_clearance", Value: "again"})
+
+	if cookies := jar.Cookies(configured); len(cookies) != 1 || cookies[0].Value != "again" {
+		t.Errorf("expected the cookie in the HTTPClient's jar, got %v", cookies)
+	}
+	if client.wsConfig.Jar != nil {
+		t.Error("expected no second jar to be created")
+	}
 }
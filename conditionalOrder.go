@@ -0,0 +1,933 @@
+package bittrex
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+//TriggerType what makes a ConditionalOrder fire.
+type TriggerType string
+
+const (
+	//TriggerStopLoss fire once the price moves against the order's side past Price:
+	//at or below Price for a SELL, at or above it for a BUY.
+	TriggerStopLoss TriggerType = "STOP_LOSS"
+
+	//TriggerTakeProfit fire once the price moves in favour of the order's side past Price:
+	//at or above Price for a SELL, at or below it for a BUY.
+	TriggerTakeProfit TriggerType = "TAKE_PROFIT"
+
+	//TriggerTrailingStop a stop-loss that follows the best price seen (the highest for a SELL, the lowest
+	//for a BUY) at a distance of TrailPercent or TrailAmount.
+	TriggerTrailingStop TriggerType = "TRAILING_STOP"
+)
+
+//Valid whether t is one of the TriggerType consts.
+func (t TriggerType) Valid() bool {
+	return t == TriggerStopLoss || t == TriggerTakeProfit || t == TriggerTrailingStop
+}
+
+func (t TriggerType) String() string {
+	return string(t)
+}
+
+func (t TriggerType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(string(t), "trigger type", t.Valid())
+}
+
+func (t *TriggerType) UnmarshalJSON(raw []byte) error {
+	value, err := unmarshalEnum(raw, "trigger type", func(v string) bool { return TriggerType(v).Valid() })
+	*t = TriggerType(value)
+	return err
+}
+
+//ConditionalOrder a limit order held locally by a ConditionalEngine until its trigger hits.
+//The v1.1 api has no conditional orders of its own.
+type ConditionalOrder struct {
+	ID     string
+	Market string
+	//Side of the limit order placed when the trigger hits. SELL protects or takes profit on a holding.
+	Side    OrderSide
+	Trigger TriggerType
+
+	//Price the trigger price of a stop-loss or take-profit.
+	Price *big.Float
+
+	//TrailPercent (ex: 0.05 for 5%) or TrailAmount, the distance a trailing stop keeps from Best. Set one.
+	TrailPercent *big.Float
+	TrailAmount  *big.Float
+
+	//Best the best price a trailing stop has seen. Maintained by the engine.
+	Best *big.Float
+
+	Quantity *big.Float
+	//Rate limit price of the placed order. Nil places it at the price that hit the trigger.
+	Rate *big.Float
+
+	//OCO ID of the other half of a one-cancels-the-other pair, cancelled when this one fires.
+	OCO string
+
+	Created time.Time
+
+	//Firing set, and saved, before the order's limit order is sent. Maintained by the engine.
+	Firing *ConditionalFiring
+
+	//Attempts failed placements so far, and RetryAt when the order may fire again. Maintained by the engine.
+	Attempts int
+	RetryAt  time.Time
+}
+
+//ConditionalFiring a limit order being placed for a ConditionalOrder: its Rate, and when it was sent. An order
+//loaded still firing may or may not have been placed, so the engine looks for it on the account before firing again.
+type ConditionalFiring struct {
+	Rate *big.Float
+	Time time.Time
+}
+
+//StopPrice price at or past which a trailing stop fires, nil until it has seen a price.
+//For other triggers, Price.
+func (o ConditionalOrder) StopPrice() *big.Float {
+	if o.Trigger != TriggerTrailingStop {
+		return o.Price
+	}
+
+	if o.Best == nil {
+		return nil
+	}
+
+	return ratAmount(o.trailingStop())
+}
+
+//trailingStop exact stop price of a trailing stop that has seen a price.
+func (o ConditionalOrder) trailingStop() *big.Rat {
+	best := decimal(o.Best)
+
+	distance := decimal(o.TrailAmount)
+	if o.TrailPercent != nil {
+		distance = new(big.Rat).Mul(best, decimal(o.TrailPercent))
+	}
+
+	if o.Side == OrderSideSell {
+		return new(big.Rat).Sub(best, distance)
+	}
+
+	return new(big.Rat).Add(best, distance)
+}
+
+//observe update Best with price, reporting whether it moved and whether the order fires at price.
+func (o *ConditionalOrder) observe(price *big.Float) (fire bool, moved bool) {
+	sell := o.Side == OrderSideSell
+
+	switch o.Trigger {
+	case TriggerStopLoss:
+		if sell {
+			return price.Cmp(o.Price) <= 0, false
+		}
+		return price.Cmp(o.Price) >= 0, false
+	case TriggerTakeProfit:
+		if sell {
+			return price.Cmp(o.Price) >= 0, false
+		}
+		return price.Cmp(o.Price) <= 0, false
+	}
+
+	if o.Best == nil || (sell && price.Cmp(o.Best) > 0) || (!sell && price.Cmp(o.Best) < 0) {
+		o.Best = new(big.Float).Set(price)
+		moved = true
+	}
+
+	position := decimal(price).Cmp(o.trailingStop())
+	if sell {
+		return position <= 0, moved
+	}
+	return position >= 0, moved
+}
+
+//ConditionalOrderError a conditional order was rejected or not found.
+type ConditionalOrderError struct {
+	ID     string
+	Reason string
+}
+
+func (e ConditionalOrderError) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("conditional order - %s", e.Reason)
+	}
+	return fmt.Sprintf("conditional order %s - %s", e.ID, e.Reason)
+}
+
+func (o ConditionalOrder) validate() error {
+	positive := func(f *big.Float) bool { return f != nil && f.Sign() > 0 }
+
+	switch {
+	case o.Market == "":
+		return ConditionalOrderError{o.ID, "market is required"}
+	case !o.Side.Valid():
+		return EnumError{"order side", string(o.Side)}
+	case !o.Trigger.Valid():
+		return EnumError{"trigger type", string(o.Trigger)}
+	case !positive(o.Quantity):
+		return ConditionalOrderError{o.ID, "quantity must be positive"}
+	case o.Rate != nil && !positive(o.Rate):
+		return ConditionalOrderError{o.ID, "rate must be positive"}
+	case o.Trigger != TriggerTrailingStop && !positive(o.Price):
+		return ConditionalOrderError{o.ID, "trigger price must be positive"}
+	case o.Trigger == TriggerTrailingStop && (o.TrailPercent == nil) == (o.TrailAmount == nil):
+		return ConditionalOrderError{o.ID, "trailing stop needs one of TrailPercent and TrailAmount"}
+	case o.TrailPercent != nil && (o.TrailPercent.Sign() <= 0 || o.TrailPercent.Cmp(big.NewFloat(1)) >= 0):
+		return ConditionalOrderError{o.ID, "TrailPercent must be between 0 and 1"}
+	case o.TrailAmount != nil && !positive(o.TrailAmount):
+		return ConditionalOrderError{o.ID, "TrailAmount must be positive"}
+	}
+
+	return nil
+}
+
+//copyOrder o with its own copies of every amount, so callers can't change an order behind the engine's back.
+func copyOrder(o ConditionalOrder) ConditionalOrder {
+	clone := func(f *big.Float) *big.Float {
+		if f == nil {
+			return nil
+		}
+		return new(big.Float).Set(f)
+	}
+
+	o.Price = clone(o.Price)
+	o.TrailPercent = clone(o.TrailPercent)
+	o.TrailAmount = clone(o.TrailAmount)
+	o.Best = clone(o.Best)
+	o.Quantity = clone(o.Quantity)
+	o.Rate = clone(o.Rate)
+
+	if o.Firing != nil {
+		firing := *o.Firing
+		firing.Rate = clone(firing.Rate)
+		o.Firing = &firing
+	}
+
+	return o
+}
+
+//ConditionalEventType what happened to a conditional order.
+type ConditionalEventType int
+
+const (
+	//ConditionalTriggered the trigger hit and the limit order was placed, see UUID. Terminal.
+	ConditionalTriggered ConditionalEventType = iota
+
+	//ConditionalPlaceFailed the trigger hit but placing the order failed, see Err. The order stays pending
+	//and is placed on the next price that hits the trigger once RetryAt has passed.
+	ConditionalPlaceFailed
+
+	//ConditionalCancelled the order was dropped because its OCO partner fired. Terminal.
+	ConditionalCancelled
+
+	//ConditionalTrailed a trailing stop's Best moved.
+	ConditionalTrailed
+
+	//ConditionalEngineFailed polling a ticker or saving the engine's state failed, see Err. Order may be empty.
+	ConditionalEngineFailed
+
+	//ConditionalGaveUp placing the order failed MaxAttempts times in a row, see Err. The order was dropped. Terminal.
+	ConditionalGaveUp
+)
+
+func (t ConditionalEventType) String() string {
+	switch t {
+	case ConditionalTriggered:
+		return "ConditionalTriggered"
+	case ConditionalPlaceFailed:
+		return "ConditionalPlaceFailed"
+	case ConditionalCancelled:
+		return "ConditionalCancelled"
+	case ConditionalTrailed:
+		return "ConditionalTrailed"
+	case ConditionalEngineFailed:
+		return "ConditionalEngineFailed"
+	case ConditionalGaveUp:
+		return "ConditionalGaveUp"
+	}
+	return "ConditionalEventType(?)"
+}
+
+//ConditionalEvent a change to a conditional order. Price is the price that caused it, nil for orders settled by
+//Reconcile.
+type ConditionalEvent struct {
+	Type  ConditionalEventType
+	Order ConditionalOrder
+	Price *big.Float
+	UUID  string
+	Err   error
+}
+
+//ConditionalEngine holds ConditionalOrders and places their limit orders when prices hit their triggers.
+//Prices come from OnPrice, from trade streams passed to Follow, and from ticker polling once started.
+//Pending orders are saved to a JSON file after every change, so they survive restarts. Safe for concurrent use.
+type ConditionalEngine struct {
+	//MaxAttempts failed placements in a row after which an order is given up on. 0 never gives up.
+	MaxAttempts int
+	//RetryDelay wait after a failed placement before the order may fire again, doubling with every further failure.
+	RetryDelay time.Duration
+
+	path string
+
+	//MarketBuyLimit / MarketSellLimit, PublicGetTicker and findPlaced outside tests.
+	place  func(order ConditionalOrder, rate *big.Float) (string, error)
+	ticker func(market string) (Ticker, error)
+	find   func(order ConditionalOrder) (string, error)
+	now    func() time.Time
+
+	mutex  sync.Mutex
+	orders map[string]*ConditionalOrder
+	//firing orders this engine is placing right now.
+	firing map[string]bool
+
+	watchMutex sync.Mutex
+	watchers   map[int]func(ConditionalEvent)
+	nextWatch  int
+
+	follows   sync.WaitGroup
+	startOnce sync.Once
+	closeOnce sync.Once
+	closing   chan struct{}
+	closed    chan struct{}
+}
+
+//NewConditionalEngine engine keeping its state in the file at path, loading any orders saved there. An empty path
+//keeps orders in memory only. Orders are checked by the client's OrderValidator, if one was set before the call.
+//Orders saved while Firing don't fire until Reconcile settles them; call it after Watch to see what it settles.
+func (c *Client) NewConditionalEngine(path string) (*ConditionalEngine, error) {
+	//orders fire from whichever goroutine saw the price, so each request gets a session of its own.
+	base := c.session()
+
+	place := func(order ConditionalOrder, rate *big.Float) (string, error) {
+		var id TransactionID
+		var err error
+
+		if order.Side == OrderSideBuy {
+			id, err = base.session().MarketBuyLimit(order.Market, order.Quantity, rate)
+		} else {
+			id, err = base.session().MarketSellLimit(order.Market, order.Quantity, rate)
+		}
+
+		return id.UUID, err
+	}
+
+	ticker := func(market string) (Ticker, error) {
+		return base.session().PublicGetTicker(market)
+	}
+
+	e := newConditionalEngine(path, place, ticker)
+	e.find = func(order ConditionalOrder) (string, error) {
+		return findPlaced(base.session(), order)
+	}
+
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+//findPlaced UUID of the limit order the account shows firing order placed, empty when there is none.
+func findPlaced(c *Client, order ConditionalOrder) (string, error) {
+	matches := func(orderType OrderType, quantity, limit *big.Float, at BittrexTimestamp) bool {
+		//allow for the exchange's clock running a little behind ours.
+		return orderType.Side() == order.Side && quantity != nil && limit != nil &&
+			quantity.Text('f', BittrexDecimals) == order.Quantity.Text('f', BittrexDecimals) &&
+			limit.Text('f', BittrexDecimals) == order.Firing.Rate.Text('f', BittrexDecimals) &&
+			!time.Time(at).Before(order.Firing.Time.Add(-time.Minute))
+	}
+
+	open, err := c.MarketGetOpenOrders(order.Market)
+	if err != nil {
+		return "", err
+	}
+
+	for _, placed := range open {
+		if matches(placed.OrderType, placed.Quantity, placed.Limit, placed.Opened) {
+			return placed.OrderUUID, nil
+		}
+	}
+
+	history, err := c.AccountGetOrderHistory(order.Market)
+	if err != nil {
+		return "", err
+	}
+
+	for _, placed := range history {
+		if matches(placed.OrderType, placed.Quantity, placed.Limit, placed.TimeStamp) {
+			return placed.OrderUUID, nil
+		}
+	}
+
+	return "", nil
+}
+
+func newConditionalEngine(path string, place func(ConditionalOrder, *big.Float) (string, error), ticker func(string) (Ticker, error)) *ConditionalEngine {
+	return &ConditionalEngine{
+		MaxAttempts: 5,
+		RetryDelay:  10 * time.Second,
+		path:        path,
+		place:       place,
+		ticker:      ticker,
+		now:         time.Now,
+		orders:      make(map[string]*ConditionalOrder),
+		firing:      make(map[string]bool),
+		watchers:    make(map[int]func(ConditionalEvent)),
+		closing:     make(chan struct{}),
+		closed:      make(chan struct{}),
+	}
+}
+
+func newConditionalID() string {
+	raw := make([]byte, 8)
+	rand.Read(raw)
+	return hex.EncodeToString(raw)
+}
+
+//Add hold order until its trigger hits. An empty ID is generated. Returns the order as held.
+func (e *ConditionalEngine) Add(order ConditionalOrder) (ConditionalOrder, error) {
+	held, err := e.add(order)
+	if err != nil {
+		return ConditionalOrder{}, err
+	}
+
+	return held[0], nil
+}
+
+//AddOCO hold a one-cancels-the-other pair (ex: a stop-loss and a take-profit on the same holding).
+//When either fires the other is dropped. Both must be for the same market.
+func (e *ConditionalEngine) AddOCO(first, second ConditionalOrder) (ConditionalOrder, ConditionalOrder, error) {
+	if first.Market != second.Market {
+		return ConditionalOrder{}, ConditionalOrder{}, ConditionalOrderError{"", "OCO orders must be for the same market"}
+	}
+
+	if first.ID == "" {
+		first.ID = newConditionalID()
+	}
+	if second.ID == "" {
+		second.ID = newConditionalID()
+	}
+
+	first.OCO, second.OCO = second.ID, first.ID
+
+	held, err := e.add(first, second)
+	if err != nil {
+		return ConditionalOrder{}, ConditionalOrder{}, err
+	}
+
+	return held[0], held[1], nil
+}
+
+func (e *ConditionalEngine) add(orders ...ConditionalOrder) ([]ConditionalOrder, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	held := make([]ConditionalOrder, len(orders))
+
+	for i, order := range orders {
+		order = copyOrder(order)
+
+		if order.ID == "" {
+			order.ID = newConditionalID()
+		}
+		if order.Created.IsZero() {
+			order.Created = time.Now()
+		}
+		if order.Trigger != TriggerTrailingStop {
+			order.Best = nil
+		}
+		order.Firing, order.Attempts, order.RetryAt = nil, 0, time.Time{}
+
+		if err := order.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := e.orders[order.ID]; ok {
+			return nil, ConditionalOrderError{order.ID, "id already in use"}
+		}
+
+		held[i] = order
+	}
+
+	for i := range held {
+		order := held[i]
+		e.orders[order.ID] = &order
+	}
+
+	if err := e.save(); err != nil {
+		for _, order := range held {
+			delete(e.orders, order.ID)
+		}
+		return nil, err
+	}
+
+	for i := range held {
+		held[i] = copyOrder(held[i])
+	}
+
+	return held, nil
+}
+
+//Cancel drop the order with id, and its OCO partner.
+func (e *ConditionalEngine) Cancel(id string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	order, ok := e.orders[id]
+	if !ok {
+		return ConditionalOrderError{id, "not found"}
+	}
+
+	delete(e.orders, id)
+	delete(e.orders, order.OCO)
+
+	return e.save()
+}
+
+//Order the pending order with id.
+func (e *ConditionalEngine) Order(id string) (ConditionalOrder, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	order, ok := e.orders[id]
+	if !ok {
+		return ConditionalOrder{}, false
+	}
+
+	return copyOrder(*order), true
+}
+
+//Orders every pending order, oldest first.
+func (e *ConditionalEngine) Orders() []ConditionalOrder {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	orders := e.sorted("")
+	for i := range orders {
+		orders[i] = copyOrder(orders[i])
+	}
+
+	return orders
+}
+
+//sorted pending orders for market (every market when empty), oldest first. Callers hold mutex.
+func (e *ConditionalEngine) sorted(market string) []ConditionalOrder {
+	var orders []ConditionalOrder
+	for _, order := range e.orders {
+		if market == "" || order.Market == market {
+			orders = append(orders, *order)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].Created.Equal(orders[j].Created) {
+			return orders[i].Created.Before(orders[j].Created)
+		}
+		return orders[i].ID < orders[j].ID
+	})
+
+	return orders
+}
+
+//Watch call handler with every ConditionalEvent, from the goroutine that saw the price. Call the returned func to stop.
+func (e *ConditionalEngine) Watch(handler func(ConditionalEvent)) (unwatch func()) {
+	e.watchMutex.Lock()
+	defer e.watchMutex.Unlock()
+
+	id := e.nextWatch
+	e.nextWatch++
+	e.watchers[id] = handler
+
+	return func() {
+		e.watchMutex.Lock()
+		defer e.watchMutex.Unlock()
+
+		delete(e.watchers, id)
+	}
+}
+
+func (e *ConditionalEngine) emit(events []ConditionalEvent) {
+	e.watchMutex.Lock()
+	handlers := make([]func(ConditionalEvent), 0, len(e.watchers))
+	for _, handler := range e.watchers {
+		handlers = append(handlers, handler)
+	}
+	e.watchMutex.Unlock()
+
+	for _, event := range events {
+		for _, handler := range handlers {
+			handler(event)
+		}
+	}
+}
+
+//OnTrade OnPrice with a trade's market and price (ex: from a TradeStream).
+func (e *ConditionalEngine) OnTrade(trade Trade) {
+	e.OnPrice(trade.Market, trade.Price)
+}
+
+//OnPrice check the pending orders for market against price, placing the limit order of each one that fires.
+//Orders fire oldest first; once one half of an OCO pair fires the other won't. An order only fires once it was
+//saved as Firing, so a crash while placing it can't place it twice.
+func (e *ConditionalEngine) OnPrice(market string, price *big.Float) {
+	if price == nil {
+		return
+	}
+
+	e.mutex.Lock()
+
+	now := e.now()
+	var fire []string
+	var events []ConditionalEvent
+	changed := false
+
+	for _, order := range e.sorted(market) {
+		held := e.orders[order.ID]
+		if held.Firing != nil {
+			continue
+		}
+		if partner, ok := e.orders[held.OCO]; ok && partner.Firing != nil {
+			continue
+		}
+
+		fires, moved := held.observe(price)
+
+		if moved {
+			changed = true
+			events = append(events, ConditionalEvent{Type: ConditionalTrailed, Order: copyOrder(*held), Price: price})
+		}
+
+		if fires && !now.Before(held.RetryAt) {
+			rate := held.Rate
+			if rate == nil {
+				rate = price
+			}
+
+			held.Firing = &ConditionalFiring{Rate: new(big.Float).Set(rate), Time: now}
+			changed = true
+			fire = append(fire, held.ID)
+		}
+	}
+
+	if changed {
+		if err := e.save(); err != nil {
+			events = append(events, ConditionalEvent{Type: ConditionalEngineFailed, Price: price, Err: err})
+
+			for _, id := range fire {
+				e.orders[id].Firing = nil
+			}
+			fire = nil
+		}
+	}
+
+	firing := make([]ConditionalOrder, len(fire))
+	for i, id := range fire {
+		e.firing[id] = true
+		firing[i] = copyOrder(*e.orders[id])
+	}
+
+	e.mutex.Unlock()
+	e.emit(events)
+
+	for _, order := range firing {
+		e.fire(order, price)
+	}
+}
+
+//fire place order's limit order, then drop it and its OCO partner. A failed order stays pending until RetryAt,
+//or is dropped after MaxAttempts.
+func (e *ConditionalEngine) fire(order ConditionalOrder, price *big.Float) {
+	uuid, err := e.place(order, order.Firing.Rate)
+
+	e.mutex.Lock()
+
+	delete(e.firing, order.ID)
+
+	var events []ConditionalEvent
+
+	if err != nil {
+		held, ok := e.orders[order.ID]
+		if !ok {
+			//cancelled while being placed
+			events = append(events, ConditionalEvent{Type: ConditionalPlaceFailed, Order: order, Price: price, Err: err})
+		} else {
+			held.Firing = nil
+			held.Attempts++
+
+			if e.MaxAttempts > 0 && held.Attempts >= e.MaxAttempts {
+				delete(e.orders, order.ID)
+				events = append(events, ConditionalEvent{Type: ConditionalGaveUp, Order: copyOrder(*held), Price: price, Err: err})
+			} else {
+				held.RetryAt = e.now().Add(e.retryDelay(held.Attempts))
+				events = append(events, ConditionalEvent{Type: ConditionalPlaceFailed, Order: copyOrder(*held), Price: price, Err: err})
+			}
+		}
+	} else {
+		delete(e.orders, order.ID)
+		events = append(events, ConditionalEvent{Type: ConditionalTriggered, Order: order, Price: price, UUID: uuid})
+
+		if partner, ok := e.orders[order.OCO]; ok {
+			delete(e.orders, order.OCO)
+			events = append(events, ConditionalEvent{Type: ConditionalCancelled, Order: copyOrder(*partner), Price: price})
+		}
+	}
+
+	if saveErr := e.save(); saveErr != nil {
+		events = append(events, ConditionalEvent{Type: ConditionalEngineFailed, Order: order, Price: price, Err: saveErr})
+	}
+
+	e.mutex.Unlock()
+	e.emit(events)
+}
+
+//retryDelay wait after the attempts-th failed placement in a row.
+func (e *ConditionalEngine) retryDelay(attempts int) time.Duration {
+	delay := e.RetryDelay
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+//Reconcile settle the orders a previous engine saved while Firing: those the account shows were placed are
+//dropped, reported as ConditionalTriggered, along with their OCO partner, reported as ConditionalCancelled. The
+//others are pending again. Orders that could not be looked up stay Firing, and don't fire, until Reconcile
+//succeeds. Returns the first lookup error.
+func (e *ConditionalEngine) Reconcile() error {
+	e.mutex.Lock()
+	var unsettled []ConditionalOrder
+	for _, order := range e.sorted("") {
+		if order.Firing != nil && !e.firing[order.ID] {
+			unsettled = append(unsettled, copyOrder(order))
+		}
+	}
+	e.mutex.Unlock()
+
+	//looked up without the lock: the orders stay Firing meanwhile, so they can't fire.
+	found := make(map[string]string, len(unsettled))
+	var first error
+
+	for _, order := range unsettled {
+		uuid, err := e.find(order)
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		found[order.ID] = uuid
+	}
+
+	e.mutex.Lock()
+
+	var events []ConditionalEvent
+	changed := false
+
+	for _, order := range unsettled {
+		uuid, ok := found[order.ID]
+		held, pending := e.orders[order.ID]
+		if !ok || !pending || held.Firing == nil {
+			//not looked up, or settled meanwhile (ex: cancelled, or by another Reconcile)
+			continue
+		}
+
+		if uuid != "" {
+			delete(e.orders, order.ID)
+			events = append(events, ConditionalEvent{Type: ConditionalTriggered, Order: copyOrder(*held), UUID: uuid})
+
+			if partner, ok := e.orders[held.OCO]; ok {
+				delete(e.orders, held.OCO)
+				events = append(events, ConditionalEvent{Type: ConditionalCancelled, Order: copyOrder(*partner)})
+			}
+		} else {
+			held.Firing = nil
+		}
+		changed = true
+	}
+
+	if changed {
+		if err := e.save(); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	e.mutex.Unlock()
+	e.emit(events)
+
+	return first
+}
+
+//Follow feed every trade from trades (ex: a TradeStream's Trades) to the engine in the background,
+//until trades is closed or the engine is.
+func (e *ConditionalEngine) Follow(trades <-chan Trade) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	select {
+	case <-e.closing:
+		return
+	default:
+	}
+
+	e.follows.Add(1)
+	go func() {
+		defer e.follows.Done()
+
+		for {
+			select {
+			case <-e.closing:
+				return
+			case trade, ok := <-trades:
+				if !ok {
+					return
+				}
+				e.OnTrade(trade)
+			}
+		}
+	}()
+}
+
+//Poll fetch the ticker of every market with pending orders and check them against its Last price.
+//Failures reach watchers as ConditionalEngineFailed.
+func (e *ConditionalEngine) Poll() {
+	e.mutex.Lock()
+	markets := make(map[string]bool)
+	for _, order := range e.orders {
+		markets[order.Market] = true
+	}
+	e.mutex.Unlock()
+
+	names := make([]string, 0, len(markets))
+	for market := range markets {
+		names = append(names, market)
+	}
+	sort.Strings(names)
+
+	for _, market := range names {
+		ticker, err := e.ticker(market)
+		if err != nil {
+			e.emit([]ConditionalEvent{{Type: ConditionalEngineFailed, Order: ConditionalOrder{Market: market}, Err: err}})
+			continue
+		}
+
+		e.OnPrice(market, ticker.Last)
+	}
+}
+
+//Start Poll every period in the background.
+func (e *ConditionalEngine) Start(period time.Duration) {
+	e.startOnce.Do(func() {
+		go e.run(period)
+	})
+}
+
+//Close stop polling and following trade streams. Pending orders stay saved for the next engine.
+func (e *ConditionalEngine) Close(ctx context.Context) error {
+	e.startOnce.Do(func() {
+		close(e.closed)
+	})
+
+	e.closeOnce.Do(func() {
+		e.mutex.Lock()
+		close(e.closing)
+		e.mutex.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		<-e.closed
+		e.follows.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *ConditionalEngine) run(period time.Duration) {
+	defer close(e.closed)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.closing:
+			return
+		case <-ticker.C:
+			e.Poll()
+		}
+	}
+}
+
+func (e *ConditionalEngine) load() error {
+	raw, err := ioutil.ReadFile(e.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var orders []ConditionalOrder
+	if err := json.Unmarshal(raw, &orders); err != nil {
+		return fmt.Errorf("%s: %s", e.path, err.Error())
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for i := range orders {
+		e.orders[orders[i].ID] = &orders[i]
+	}
+
+	return nil
+}
+
+//save write the pending orders to a temporary file and rename it over the old one, so a crash never leaves
+//a truncated state file. Callers hold mutex.
+func (e *ConditionalEngine) save() error {
+	if e.path == "" {
+		return nil
+	}
+
+	raw, err := json.MarshalIndent(e.sorted(""), "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(e.path), filepath.Base(e.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(raw); err != nil {
+		temp.Close()
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), e.path)
+}
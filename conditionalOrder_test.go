@@ -0,0 +1,406 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testPlacement struct {
+	mutex  sync.Mutex
+	placed []ConditionalOrder
+	rates  []string
+	fail   bool
+}
+
+func (p *testPlacement) place(order ConditionalOrder, rate *big.Float) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.fail {
+		return "", errors.New("INSUFFICIENT_FUNDS")
+	}
+
+	p.placed = append(p.placed, order)
+	p.rates = append(p.rates, rate.Text('g', -1))
+	return "uuid-" + order.ID, nil
+}
+
+func TestConditionalOCO(t *testing.T) {
+	placement := &testPlacement{}
+	engine := newConditionalEngine("", placement.place, nil)
+
+	var events []ConditionalEvent
+	engine.Watch(func(event ConditionalEvent) {
+		events = append(events, event)
+	})
+
+	stop, take, err := engine.AddOCO(
+		ConditionalOrder{Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerStopLoss, Price: dec("0.009"), Quantity: dec("5"), Rate: dec("0.0089")},
+		ConditionalOrder{Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerTakeProfit, Price: dec("0.012"), Quantity: dec("5")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stop.OCO != take.ID || take.OCO != stop.ID {
+		t.Fatalf("orders not linked: %+v %+v", stop, take)
+	}
+
+	engine.OnPrice("BTC-LTC", dec("0.01"))
+	engine.OnPrice("BTC-ETH", dec("0.02"))
+
+	if len(placement.placed) != 0 || len(events) != 0 {
+		t.Fatalf("fired early: %+v %+v", placement.placed, events)
+	}
+
+	engine.OnPrice("BTC-LTC", dec("0.0125"))
+
+	if len(placement.placed) != 1 || placement.placed[0].ID != take.ID || placement.rates[0] != "0.0125" {
+		t.Fatalf("expected the take-profit at the trigger price, got %+v %v", placement.placed, placement.rates)
+	}
+
+	if len(events) != 2 || events[0].Type != ConditionalTriggered || events[0].UUID != "uuid-"+take.ID ||
+		events[1].Type != ConditionalCancelled || events[1].Order.ID != stop.ID {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	if orders := engine.Orders(); len(orders) != 0 {
+		t.Errorf("expected no pending orders, got %+v", orders)
+	}
+}
+
+func TestConditionalTrailingStop(t *testing.T) {
+	placement := &testPlacement{}
+	engine := newConditionalEngine("", placement.place, nil)
+
+	order, err := engine.Add(ConditionalOrder{Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerTrailingStop, TrailPercent: dec("0.1"), Quantity: dec("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, price := range []string{"100", "120", "110", "108.5"} {
+		engine.OnPrice("BTC-LTC", dec(price))
+	}
+
+	held, ok := engine.Order(order.ID)
+	if !ok {
+		t.Fatal("trailing stop fired early")
+	}
+	expectDecimal(t, "best", held.Best, "120")
+	expectDecimal(t, "stop", held.StopPrice(), "108")
+
+	engine.OnPrice("BTC-LTC", dec("108"))
+	if len(placement.placed) != 1 || placement.rates[0] != "108" {
+		t.Fatalf("expected the stop to fire at 108, got %v", placement.rates)
+	}
+
+	//a buy trailing stop follows the low
+	buy, _ := engine.Add(ConditionalOrder{Market: "BTC-LTC", Side: OrderSideBuy, Trigger: TriggerTrailingStop, TrailAmount: dec("2"), Quantity: dec("1")})
+	for _, price := range []string{"100", "95", "96.5"} {
+		engine.OnPrice("BTC-LTC", dec(price))
+	}
+
+	if held, _ := engine.Order(buy.ID); held.StopPrice() == nil {
+		t.Fatal("buy stop missing")
+	} else {
+		expectDecimal(t, "buy stop", held.StopPrice(), "97")
+	}
+
+	engine.OnPrice("BTC-LTC", dec("97.1"))
+	if len(placement.placed) != 2 {
+		t.Fatalf("expected the buy stop to fire, got %+v", placement.placed)
+	}
+}
+
+func TestConditionalPlaceFailureBacksOff(t *testing.T) {
+	placement := &testPlacement{fail: true}
+	engine := newConditionalEngine("", placement.place, nil)
+	engine.MaxAttempts, engine.RetryDelay = 3, time.Minute
+
+	now := time.Unix(1000, 0)
+	engine.now = func() time.Time { return now }
+
+	var events []ConditionalEvent
+	engine.Watch(func(event ConditionalEvent) {
+		events = append(events, event)
+	})
+
+	order, _ := engine.Add(ConditionalOrder{Market: "BTC-LTC", Side: OrderSideBuy, Trigger: TriggerStopLoss, Price: dec("0.02"), Quantity: dec("1")})
+
+	engine.OnPrice("BTC-LTC", dec("0.021"))
+	if _, ok := engine.Order(order.ID); !ok {
+		t.Fatal("failed order was dropped")
+	}
+
+	//not retried on every price, but after one, then two minutes
+	engine.OnPrice("BTC-LTC", dec("0.022"))
+	now = now.Add(time.Minute)
+	engine.OnPrice("BTC-LTC", dec("0.022"))
+	now = now.Add(time.Minute)
+	engine.OnPrice("BTC-LTC", dec("0.022"))
+
+	placement.fail = false
+	now = now.Add(time.Minute)
+	engine.OnPrice("BTC-LTC", dec("0.022"))
+
+	expected := []ConditionalEventType{ConditionalPlaceFailed, ConditionalPlaceFailed, ConditionalTriggered}
+	if len(events) != len(expected) {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	for i := range expected {
+		if events[i].Type != expected[i] {
+			t.Errorf("event %d: got %s, wanted %s", i, events[i].Type, expected[i])
+		}
+	}
+	if events[1].Order.Attempts != 2 || !events[1].Order.RetryAt.Equal(now) {
+		t.Errorf("expected the second failure to back off until %s, got %+v", now, events[1].Order)
+	}
+
+	//an order failing MaxAttempts times in a row is given up on
+	events = nil
+	placement.fail = true
+	order, _ = engine.Add(ConditionalOrder{Market: "BTC-LTC", Side: OrderSideBuy, Trigger: TriggerStopLoss, Price: dec("0.02"), Quantity: dec("1")})
+
+	for _, wait := range []time.Duration{0, time.Minute, 2 * time.Minute} {
+		now = now.Add(wait)
+		engine.OnPrice("BTC-LTC", dec("0.021"))
+	}
+
+	if len(events) != 3 || events[2].Type != ConditionalGaveUp || events[2].Err == nil {
+		t.Fatalf("expected the third failure to give up, got %+v", events)
+	}
+	if _, ok := engine.Order(order.ID); ok {
+		t.Error("expected the order given up on to be dropped")
+	}
+}
+
+func TestConditionalFiringReconciled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "conditional.json")
+	crashed := filepath.Join(dir, "crashed.json")
+
+	engine := newConditionalEngine(path, func(order ConditionalOrder, rate *big.Float) (string, error) {
+		//the state a crash while placing leaves behind
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "uuid", ioutil.WriteFile(crashed, raw, 0644)
+	}, nil)
+
+	stop, take, err := engine.AddOCO(
+		ConditionalOrder{Market: "BTC-ETH", Side: OrderSideSell, Trigger: TriggerStopLoss, Price: dec("0.03"), Quantity: dec("1")},
+		ConditionalOrder{Market: "BTC-ETH", Side: OrderSideSell, Trigger: TriggerTakeProfit, Price: dec("0.05"), Quantity: dec("1")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine.OnPrice("BTC-ETH", dec("0.029"))
+
+	restart := func(found string, findErr error) *ConditionalEngine {
+		raw, _ := ioutil.ReadFile(crashed)
+		restarted := filepath.Join(t.TempDir(), "conditional.json")
+		ioutil.WriteFile(restarted, raw, 0644)
+
+		e := newConditionalEngine(restarted, (&testPlacement{}).place, nil)
+		if err := e.load(); err != nil {
+			t.Fatal(err)
+		}
+
+		e.find = func(order ConditionalOrder) (string, error) {
+			if order.ID != stop.ID || order.Firing == nil {
+				t.Errorf("looked up %+v, wanted the firing stop", order)
+			}
+			expectDecimal(t, "firing rate", order.Firing.Rate, "0.029")
+
+			//the engine stays usable while orders are looked up
+			e.Orders()
+
+			return found, findErr
+		}
+
+		return e
+	}
+
+	//placed before the crash: both halves are done
+	e := restart("uuid", nil)
+
+	var settled []ConditionalEvent
+	e.Watch(func(event ConditionalEvent) {
+		settled = append(settled, event)
+	})
+
+	if err := e.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	if orders := e.Orders(); len(orders) != 0 {
+		t.Errorf("expected the placed pair dropped, got %+v", orders)
+	}
+	if len(settled) != 2 || settled[0].Type != ConditionalTriggered || settled[0].UUID != "uuid" || settled[0].Order.ID != stop.ID ||
+		settled[1].Type != ConditionalCancelled || settled[1].Order.ID != take.ID {
+		t.Errorf("expected the stop reported placed and the take profit cancelled, got %+v", settled)
+	}
+
+	//never placed: pending again
+	e = restart("", nil)
+	if err := e.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	if held, ok := e.Order(stop.ID); !ok || held.Firing != nil {
+		t.Errorf("expected the stop pending again, got %+v", held)
+	}
+	if _, ok := e.Order(take.ID); !ok {
+		t.Error("expected the take profit kept")
+	}
+
+	//unknown: held back until Reconcile succeeds
+	e = restart("", errors.New("timeout"))
+	if err := e.Reconcile(); err == nil {
+		t.Error("expected the lookup error")
+	}
+
+	var types []ConditionalEventType
+	e.Watch(func(event ConditionalEvent) {
+		types = append(types, event.Type)
+	})
+
+	e.OnPrice("BTC-ETH", dec("0.06"))
+	e.OnPrice("BTC-ETH", dec("0.02"))
+	if len(types) != 0 {
+		t.Errorf("expected neither half to fire while the stop is unsettled, got %v", types)
+	}
+}
+
+func TestConditionalValidate(t *testing.T) {
+	engine := newConditionalEngine("", nil, nil)
+
+	for name, order := range map[string]ConditionalOrder{
+		"no market":       {Side: OrderSideSell, Trigger: TriggerStopLoss, Price: dec("1"), Quantity: dec("1")},
+		"no quantity":     {Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerStopLoss, Price: dec("1")},
+		"no price":        {Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerTakeProfit, Quantity: dec("1")},
+		"both trails":     {Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerTrailingStop, TrailPercent: dec("0.1"), TrailAmount: dec("1"), Quantity: dec("1")},
+		"percent too big": {Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerTrailingStop, TrailPercent: dec("1"), Quantity: dec("1")},
+		"bad trigger":     {Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerType("STOP"), Price: dec("1"), Quantity: dec("1")},
+	} {
+		if _, err := engine.Add(order); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if _, _, err := engine.AddOCO(
+		ConditionalOrder{Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerStopLoss, Price: dec("1"), Quantity: dec("1")},
+		ConditionalOrder{Market: "BTC-ETH", Side: OrderSideSell, Trigger: TriggerTakeProfit, Price: dec("2"), Quantity: dec("1")},
+	); err == nil {
+		t.Error("expected OCO across markets to fail")
+	}
+
+	if err := engine.Cancel("missing"); err == nil {
+		t.Error("expected cancelling an unknown order to fail")
+	}
+}
+
+func TestConditionalPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "conditional.json")
+
+	first := newConditionalEngine(path, nil, nil)
+	trailing, err := first.Add(ConditionalOrder{Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerTrailingStop, TrailAmount: dec("0.001"), Quantity: dec("3")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop, _, err := first.AddOCO(
+		ConditionalOrder{Market: "BTC-ETH", Side: OrderSideSell, Trigger: TriggerStopLoss, Price: dec("0.03"), Quantity: dec("1")},
+		ConditionalOrder{Market: "BTC-ETH", Side: OrderSideSell, Trigger: TriggerTakeProfit, Price: dec("0.05"), Quantity: dec("1")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.OnPrice("BTC-LTC", dec("0.0125"))
+
+	second := newConditionalEngine(path, nil, nil)
+	if err := second.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	orders := second.Orders()
+	if len(orders) != 3 {
+		t.Fatalf("expected 3 orders after reload, got %+v", orders)
+	}
+
+	held, _ := second.Order(trailing.ID)
+	expectDecimal(t, "reloaded best", held.Best, "0.0125")
+	expectDecimal(t, "reloaded quantity", held.Quantity, "3")
+
+	if err := second.Cancel(stop.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	third := newConditionalEngine(path, nil, nil)
+	third.load()
+	if orders := third.Orders(); len(orders) != 1 || orders[0].ID != trailing.ID {
+		t.Errorf("expected only the trailing stop after cancelling the OCO pair, got %+v", orders)
+	}
+}
+
+func TestConditionalFollowAndPoll(t *testing.T) {
+	placement := &testPlacement{}
+	engine := newConditionalEngine("", placement.place, func(market string) (Ticker, error) {
+		if market == "BTC-ETH" {
+			return Ticker{}, errors.New("INVALID_MARKET")
+		}
+		return Ticker{Last: dec("0.5")}, nil
+	})
+
+	var mutex sync.Mutex
+	var types []ConditionalEventType
+	engine.Watch(func(event ConditionalEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		types = append(types, event.Type)
+	})
+
+	engine.Add(ConditionalOrder{Market: "BTC-LTC", Side: OrderSideSell, Trigger: TriggerStopLoss, Price: dec("1"), Quantity: dec("1")})
+	engine.Add(ConditionalOrder{Market: "BTC-ETH", Side: OrderSideSell, Trigger: TriggerStopLoss, Price: dec("1"), Quantity: dec("1")})
+	engine.Add(ConditionalOrder{Market: "BTC-XRP", Side: OrderSideBuy, Trigger: TriggerTakeProfit, Price: dec("0.0001"), Quantity: dec("1")})
+
+	engine.Poll()
+
+	trades := make(chan Trade)
+	engine.Follow(trades)
+	trades <- Trade{Market: "BTC-XRP", Price: dec("0.00009")}
+	close(trades)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := engine.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	//nothing is followed after Close
+	engine.Follow(make(chan Trade))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	expected := []ConditionalEventType{ConditionalEngineFailed, ConditionalTriggered, ConditionalTriggered}
+	if len(types) != len(expected) {
+		t.Fatalf("unexpected events %v", types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Errorf("event %d: got %s, wanted %s", i, types[i], expected[i])
+		}
+	}
+
+	if orders := engine.Orders(); len(orders) != 1 || orders[0].Market != "BTC-ETH" {
+		t.Errorf("unexpected pending orders %+v", orders)
+	}
+}
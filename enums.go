@@ -0,0 +1,254 @@
+package bittrex
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+//EnumError a value outside of an enum's known values, ex: an OrderType the api started sending.
+type EnumError struct {
+	Enum  string
+	Value string
+}
+
+func (e EnumError) Error() string {
+	return fmt.Sprintf("unknown %s %q", e.Enum, e.Value)
+}
+
+//unmarshalEnum decode a JSON string enum. null decodes to the zero value, unknown values are an EnumError.
+func unmarshalEnum(raw []byte, enum string, valid func(string) bool) (string, error) {
+	if string(raw) == "null" {
+		return "", nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", err
+	}
+
+	if !valid(value) {
+		return "", EnumError{enum, value}
+	}
+
+	return value, nil
+}
+
+//marshalEnum encode a JSON string enum. The zero value encodes to null, unknown values are an EnumError.
+func marshalEnum(value string, enum string, valid bool) ([]byte, error) {
+	if value == "" {
+		return []byte("null"), nil
+	}
+
+	if !valid {
+		return nil, EnumError{enum, value}
+	}
+
+	return json.Marshal(value)
+}
+
+//OrderType type of an account order, see OrderDescription.OrderType and AccountOrderDescription.Type
+type OrderType string
+
+const (
+	//OrderTypeLimitBuy LIMIT_BUY
+	OrderTypeLimitBuy OrderType = "LIMIT_BUY"
+
+	//OrderTypeLimitSell LIMIT_SELL
+	OrderTypeLimitSell OrderType = "LIMIT_SELL"
+
+	//OrderTypeMarketBuy MARKET_BUY, only found on old orders
+	OrderTypeMarketBuy OrderType = "MARKET_BUY"
+
+	//OrderTypeMarketSell MARKET_SELL, only found on old orders
+	OrderTypeMarketSell OrderType = "MARKET_SELL"
+)
+
+//Valid whether t is one of the OrderType consts.
+func (t OrderType) Valid() bool {
+	switch t {
+	case OrderTypeLimitBuy, OrderTypeLimitSell, OrderTypeMarketBuy, OrderTypeMarketSell:
+		return true
+	}
+	return false
+}
+
+//Side BUY or SELL.
+func (t OrderType) Side() OrderSide {
+	switch t {
+	case OrderTypeLimitBuy, OrderTypeMarketBuy:
+		return OrderSideBuy
+	case OrderTypeLimitSell, OrderTypeMarketSell:
+		return OrderSideSell
+	}
+	return ""
+}
+
+func (t OrderType) String() string {
+	return string(t)
+}
+
+func (t OrderType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(string(t), "order type", t.Valid())
+}
+
+func (t *OrderType) UnmarshalJSON(raw []byte) error {
+	value, err := unmarshalEnum(raw, "order type", func(s string) bool { return OrderType(s).Valid() })
+	*t = OrderType(value)
+	return err
+}
+
+//OrderSide side of a trade, see Trade.OrderType and Fill.OrderType
+type OrderSide string
+
+const (
+	//OrderSideBuy BUY
+	OrderSideBuy OrderSide = "BUY"
+
+	//OrderSideSell SELL
+	OrderSideSell OrderSide = "SELL"
+)
+
+//Valid whether s is one of the OrderSide consts.
+func (s OrderSide) Valid() bool {
+	return s == OrderSideBuy || s == OrderSideSell
+}
+
+func (s OrderSide) String() string {
+	return string(s)
+}
+
+func (s OrderSide) MarshalJSON() ([]byte, error) {
+	return marshalEnum(string(s), "order side", s.Valid())
+}
+
+func (s *OrderSide) UnmarshalJSON(raw []byte) error {
+	value, err := unmarshalEnum(raw, "order side", func(v string) bool { return OrderSide(v).Valid() })
+	*s = OrderSide(value)
+	return err
+}
+
+//FillType whether a trade filled its order completely, see Trade.FillType
+type FillType string
+
+const (
+	//FillTypeFill FILL
+	FillTypeFill FillType = "FILL"
+
+	//FillTypePartialFill PARTIAL_FILL
+	FillTypePartialFill FillType = "PARTIAL_FILL"
+)
+
+//Valid whether f is one of the FillType consts.
+func (f FillType) Valid() bool {
+	return f == FillTypeFill || f == FillTypePartialFill
+}
+
+func (f FillType) String() string {
+	return string(f)
+}
+
+func (f FillType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(string(f), "fill type", f.Valid())
+}
+
+func (f *FillType) UnmarshalJSON(raw []byte) error {
+	value, err := unmarshalEnum(raw, "fill type", func(v string) bool { return FillType(v).Valid() })
+	*f = FillType(value)
+	return err
+}
+
+//OrderCondition condition of a conditional order, see the Condition fields. The api sends null instead of NONE
+//for some orders, which decodes to the zero value.
+type OrderCondition string
+
+const (
+	//ConditionNone NONE
+	ConditionNone OrderCondition = "NONE"
+
+	//ConditionGreaterThan GREATER_THAN
+	ConditionGreaterThan OrderCondition = "GREATER_THAN"
+
+	//ConditionLessThan LESS_THAN
+	ConditionLessThan OrderCondition = "LESS_THAN"
+
+	//ConditionStopLossFixed STOP_LOSS_FIXED
+	ConditionStopLossFixed OrderCondition = "STOP_LOSS_FIXED"
+
+	//ConditionStopLossPercentage STOP_LOSS_PERCENTAGE
+	ConditionStopLossPercentage OrderCondition = "STOP_LOSS_PERCENTAGE"
+)
+
+//Valid whether c is one of the OrderCondition consts.
+func (c OrderCondition) Valid() bool {
+	switch c {
+	case ConditionNone, ConditionGreaterThan, ConditionLessThan, ConditionStopLossFixed, ConditionStopLossPercentage:
+		return true
+	}
+	return false
+}
+
+func (c OrderCondition) String() string {
+	return string(c)
+}
+
+func (c OrderCondition) MarshalJSON() ([]byte, error) {
+	return marshalEnum(string(c), "order condition", c.Valid())
+}
+
+func (c *OrderCondition) UnmarshalJSON(raw []byte) error {
+	value, err := unmarshalEnum(raw, "order condition", func(v string) bool { return OrderCondition(v).Valid() })
+	*c = OrderCondition(value)
+	return err
+}
+
+//OrderUpdateType what an OrderUpdate does to its rate in the book.
+type OrderUpdateType int
+
+const (
+	//OrderUpdateAdd a new rate
+	OrderUpdateAdd OrderUpdateType = 0
+
+	//OrderUpdateRemove the rate has no orders left
+	OrderUpdateRemove OrderUpdateType = 1
+
+	//OrderUpdateChange the quantity at the rate changed
+	OrderUpdateChange OrderUpdateType = 2
+)
+
+//Valid whether t is one of the OrderUpdateType consts.
+func (t OrderUpdateType) Valid() bool {
+	return t >= OrderUpdateAdd && t <= OrderUpdateChange
+}
+
+func (t OrderUpdateType) String() string {
+	switch t {
+	case OrderUpdateAdd:
+		return "ADD"
+	case OrderUpdateRemove:
+		return "REMOVE"
+	case OrderUpdateChange:
+		return "CHANGE"
+	}
+	return fmt.Sprintf("OrderUpdateType(%d)", int(t))
+}
+
+func (t OrderUpdateType) MarshalJSON() ([]byte, error) {
+	if !t.Valid() {
+		return nil, EnumError{"order update type", fmt.Sprint(int(t))}
+	}
+	return json.Marshal(int(t))
+}
+
+func (t *OrderUpdateType) UnmarshalJSON(raw []byte) error {
+	var value int
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return err
+	}
+
+	if !OrderUpdateType(value).Valid() {
+		return EnumError{"order update type", string(raw)}
+	}
+
+	*t = OrderUpdateType(value)
+	return nil
+}
@@ -0,0 +1,77 @@
+package bittrex
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnumUnmarshal(t *testing.T) {
+	var order OrderDescription
+
+	raw := `{"OrderUuid":"a","Exchange":"BTC-LTC","OrderType":"LIMIT_SELL","Quantity":1,"Condition":null}`
+	if err := json.Unmarshal([]byte(raw), &order); err != nil {
+		t.Fatal(err)
+	}
+
+	if order.OrderType != OrderTypeLimitSell || order.OrderType.Side() != OrderSideSell || order.Condition != "" {
+		t.Errorf("unexpected order %+v", order)
+	}
+
+	raw = `{"OrderUuid":"a","OrderType":"LIMIT_SHORT"}`
+	if err := json.Unmarshal([]byte(raw), &order); err == nil {
+		t.Errorf("expected an unknown order type error")
+	} else if enumErr, ok := err.(EnumError); !ok || enumErr.Value != "LIMIT_SHORT" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	var trade Trade
+	if err := json.Unmarshal([]byte(`{"Id":1,"FillType":"PARTIAL_FILL","OrderType":"BUY"}`), &trade); err != nil {
+		t.Fatal(err)
+	}
+
+	if trade.FillType != FillTypePartialFill || trade.OrderType != OrderSideBuy {
+		t.Errorf("unexpected trade %+v", trade)
+	}
+
+	if err := json.Unmarshal([]byte(`{"Id":1,"FillType":"FILL","OrderType":"buy"}`), &trade); err == nil {
+		t.Errorf("expected an unknown order side error")
+	}
+
+	var update OrderUpdate
+	if err := json.Unmarshal([]byte(`{"Type":3,"Rate":1,"Quantity":1}`), &update); err == nil {
+		t.Errorf("expected an unknown order update type error")
+	}
+
+	var history AccountOrderHistoryDescription
+	if err := json.Unmarshal([]byte(`{"OrderType":"LIMIT_BUY","Condition":"STOP_LOSS_FIXED"}`), &history); err != nil {
+		t.Fatal(err)
+	}
+
+	if history.Condition != ConditionStopLossFixed {
+		t.Errorf("unexpected condition %s", history.Condition)
+	}
+}
+
+func TestEnumMarshal(t *testing.T) {
+	encoded, err := json.Marshal(struct {
+		Type      OrderType
+		Side      OrderSide
+		Condition OrderCondition
+		Update    OrderUpdateType
+	}{OrderTypeLimitBuy, OrderSideSell, "", OrderUpdateChange})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(encoded) != `{"Type":"LIMIT_BUY","Side":"SELL","Condition":null,"Update":2}` {
+		t.Errorf("unexpected encoding %s", encoded)
+	}
+
+	if _, err := json.Marshal(FillType("SOMETIMES")); err == nil {
+		t.Errorf("expected an error marshalling an unknown fill type")
+	}
+
+	if OrderUpdateRemove.String() != "REMOVE" || ConditionLessThan.String() != "LESS_THAN" {
+		t.Errorf("unexpected String()")
+	}
+}
@@ -0,0 +1,652 @@
+package bittrex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+//DefaultExecutionPeriod how often an Execution checks its child order when ExecutionOrder.Period is 0.
+const DefaultExecutionPeriod = 5 * time.Second
+
+//ExecutionBroker a Broker that can also report on its orders and the market, as execution algorithms need.
+//Client.ExecutionBroker trades live, a PaperBroker on paper for dry runs.
+type ExecutionBroker interface {
+	Broker
+	Order(uuid string) (AccountOrderDescription, error)
+	Ticker(market string) (Ticker, error)
+}
+
+//ExecutionBroker live ExecutionBroker with a session of its own, so it can be used from an Execution's goroutine.
+//Orders are checked by the client's OrderValidator, if one was set before the call.
+func (c *Client) ExecutionBroker() ExecutionBroker {
+	return clientBroker{c.session()}
+}
+
+func (b clientBroker) Order(uuid string) (AccountOrderDescription, error) {
+	return b.client.AccountGetOrder(uuid)
+}
+
+func (b clientBroker) Ticker(market string) (Ticker, error) {
+	return b.client.PublicGetTicker(market)
+}
+
+//ExecutionOrder what an execution algorithm buys or sells, as a series of limit (child) orders.
+type ExecutionOrder struct {
+	Market   string
+	Side     OrderSide
+	Quantity *big.Float
+
+	//Limit worst price any child order may use: the most a buy pays, the least a sell accepts. Child orders of TWAP
+	//and POV otherwise go at the touch (the ask for buys, the bid for sells). Required by Iceberg, which rests there.
+	Limit *big.Float
+
+	//Period how often the child order and the schedule are checked. 0 uses DefaultExecutionPeriod.
+	Period time.Duration
+}
+
+//ExecutionState where an Execution is in its life.
+type ExecutionState int
+
+const (
+	//ExecutionRunning placing and working child orders
+	ExecutionRunning ExecutionState = iota
+
+	//ExecutionPaused no child order is working until Resume
+	ExecutionPaused
+
+	//ExecutionCompleted the whole quantity filled. Terminal.
+	ExecutionCompleted
+
+	//ExecutionExpired a TWAP window ended before the whole quantity filled. Terminal.
+	ExecutionExpired
+
+	//ExecutionCancelled stopped by Cancel. Terminal.
+	ExecutionCancelled
+)
+
+func (s ExecutionState) String() string {
+	switch s {
+	case ExecutionRunning:
+		return "ExecutionRunning"
+	case ExecutionPaused:
+		return "ExecutionPaused"
+	case ExecutionCompleted:
+		return "ExecutionCompleted"
+	case ExecutionExpired:
+		return "ExecutionExpired"
+	case ExecutionCancelled:
+		return "ExecutionCancelled"
+	}
+	return "ExecutionState(?)"
+}
+
+//Terminal whether an Execution in state s is finished.
+func (s ExecutionState) Terminal() bool {
+	return s == ExecutionCompleted || s == ExecutionExpired || s == ExecutionCancelled
+}
+
+//ExecutionProgress snapshot of an Execution. AveragePrice is nil until something filled. Working is the UUID of
+//the child order on the book, if any; one left there by a failed cancel stays in Working after the end.
+//Err is the last broker error, nil once a later step succeeds.
+type ExecutionProgress struct {
+	Algorithm    string
+	Market       string
+	Side         OrderSide
+	State        ExecutionState
+	Quantity     *big.Float
+	Filled       *big.Float
+	Remaining    *big.Float
+	AveragePrice *big.Float
+	Orders       int
+	Working      string
+	Err          error
+}
+
+//executionSchedule how much of the order an algorithm wants filled by now.
+type executionSchedule interface {
+	name() string
+	desired(now time.Time, quantity *big.Rat, volume *big.Rat) *big.Rat
+	expired(now time.Time) bool
+}
+
+type twapSchedule struct {
+	start  time.Time
+	window time.Duration
+	slices int
+}
+
+func (s twapSchedule) name() string {
+	return "TWAP"
+}
+
+//desired an equal share of quantity for every slice started so far.
+func (s twapSchedule) desired(now time.Time, quantity *big.Rat, volume *big.Rat) *big.Rat {
+	slice := int64(now.Sub(s.start)/(s.window/time.Duration(s.slices))) + 1
+	if slice >= int64(s.slices) {
+		return new(big.Rat).Set(quantity)
+	}
+
+	share := new(big.Rat).Mul(quantity, big.NewRat(slice, int64(s.slices)))
+	return floorDecimals(share, BittrexDecimals)
+}
+
+func (s twapSchedule) expired(now time.Time) bool {
+	return !now.Before(s.start.Add(s.window))
+}
+
+type icebergSchedule struct{}
+
+func (icebergSchedule) name() string {
+	return "ICEBERG"
+}
+
+func (icebergSchedule) desired(now time.Time, quantity *big.Rat, volume *big.Rat) *big.Rat {
+	return new(big.Rat).Set(quantity)
+}
+
+func (icebergSchedule) expired(now time.Time) bool {
+	return false
+}
+
+type povSchedule struct {
+	percent *big.Rat
+}
+
+func (povSchedule) name() string {
+	return "POV"
+}
+
+//desired percent of the volume traded since the start.
+func (s povSchedule) desired(now time.Time, quantity *big.Rat, volume *big.Rat) *big.Rat {
+	share := floorDecimals(new(big.Rat).Mul(volume, s.percent), BittrexDecimals)
+	if share.Cmp(quantity) > 0 {
+		return new(big.Rat).Set(quantity)
+	}
+	return share
+}
+
+func (povSchedule) expired(now time.Time) bool {
+	return false
+}
+
+//floorDecimals r rounded down to decimals places.
+func floorDecimals(r *big.Rat, decimals int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+
+	scaled := new(big.Int).Mul(r.Num(), scale)
+	scaled.Quo(scaled, r.Denom())
+
+	return new(big.Rat).SetFrac(scaled, scale)
+}
+
+//childOrder the child order on the book: how much of it filled at what cost, and the cumulative quantity the
+//execution meant to have filled once it fills.
+type childOrder struct {
+	uuid   string
+	rate   *big.Float
+	target *big.Rat
+	filled *big.Rat
+	cost   *big.Rat
+}
+
+//Execution an execution algorithm working one order as a series of limit child orders, one on the book at a
+//time, from a goroutine of its own. Start one with NewTWAP, NewIceberg or NewPOV. Safe for concurrent use.
+type Execution struct {
+	order    ExecutionOrder
+	quantity *big.Rat
+	clip     *big.Rat
+	broker   ExecutionBroker
+	schedule executionSchedule
+	now      func() time.Time
+
+	mutex       sync.Mutex
+	state       ExecutionState
+	cancelling  bool
+	filled      *big.Rat
+	cost        *big.Rat
+	volume      *big.Rat
+	child       *childOrder
+	orders      int
+	err         error
+	lastEmitted string
+
+	watchMutex sync.Mutex
+	watchers   map[int]func(ExecutionProgress)
+	nextWatch  int
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+//NewTWAP fill order evenly over window: it is split into slices equal parts, one more of which is wanted at the
+//start of each slice. What has not filled by the end of window is left unfilled (ExecutionExpired).
+func NewTWAP(broker ExecutionBroker, order ExecutionOrder, window time.Duration, slices int) (*Execution, error) {
+	if window <= 0 || slices < 1 || window/time.Duration(slices) <= 0 {
+		return nil, fmt.Errorf("twap - window must be positive with at least one slice")
+	}
+
+	return startExecution(broker, order, nil, twapSchedule{time.Now(), window, slices}, time.Now)
+}
+
+//NewIceberg fill order resting at order.Limit, showing no more than clip on the book at a time. The next clip is
+//placed once the previous one fills.
+func NewIceberg(broker ExecutionBroker, order ExecutionOrder, clip *big.Float) (*Execution, error) {
+	if order.Limit == nil || order.Limit.Sign() <= 0 {
+		return nil, fmt.Errorf("iceberg - a positive limit price is required")
+	}
+
+	if clip == nil || clip.Sign() <= 0 {
+		return nil, fmt.Errorf("iceberg - clip must be positive")
+	}
+
+	return startExecution(broker, order, clip, icebergSchedule{}, time.Now)
+}
+
+//NewPOV fill order as percent (ex: 0.1 for 10%) of the market's traded volume from now on. Feed it the market's
+//trades with OnTrade or Follow (ex: from a TradeStream).
+func NewPOV(broker ExecutionBroker, order ExecutionOrder, percent *big.Float) (*Execution, error) {
+	if percent == nil || percent.Sign() <= 0 || percent.Cmp(big.NewFloat(1)) > 0 {
+		return nil, fmt.Errorf("pov - percent must be above 0 and at most 1")
+	}
+
+	return startExecution(broker, order, nil, povSchedule{decimal(percent)}, time.Now)
+}
+
+func startExecution(broker ExecutionBroker, order ExecutionOrder, clip *big.Float, schedule executionSchedule, now func() time.Time) (*Execution, error) {
+	e, err := newExecution(broker, order, clip, schedule, now)
+	if err != nil {
+		return nil, err
+	}
+
+	go e.run()
+
+	return e, nil
+}
+
+func newExecution(broker ExecutionBroker, order ExecutionOrder, clip *big.Float, schedule executionSchedule, now func() time.Time) (*Execution, error) {
+	if order.Market == "" {
+		return nil, MarketError{order.Market, "market is required"}
+	}
+
+	if !order.Side.Valid() {
+		return nil, EnumError{"order side", string(order.Side)}
+	}
+
+	if order.Quantity == nil || order.Quantity.Sign() <= 0 {
+		return nil, OrderValueError{"quantity", order.Quantity}
+	}
+
+	if order.Period <= 0 {
+		order.Period = DefaultExecutionPeriod
+	}
+
+	e := &Execution{
+		order:    order,
+		quantity: decimal(order.Quantity),
+		broker:   broker,
+		schedule: schedule,
+		now:      now,
+		filled:   new(big.Rat),
+		cost:     new(big.Rat),
+		volume:   new(big.Rat),
+		watchers: make(map[int]func(ExecutionProgress)),
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	if clip != nil {
+		e.clip = decimal(clip)
+	}
+
+	return e, nil
+}
+
+//Progress current snapshot.
+func (e *Execution) Progress() ExecutionProgress {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.progress()
+}
+
+//progress callers hold mutex.
+func (e *Execution) progress() ExecutionProgress {
+	filled, cost := new(big.Rat).Set(e.filled), new(big.Rat).Set(e.cost)
+
+	p := ExecutionProgress{
+		Algorithm: e.schedule.name(),
+		Market:    e.order.Market,
+		Side:      e.order.Side,
+		State:     e.state,
+		Quantity:  new(big.Float).Set(e.order.Quantity),
+		Orders:    e.orders,
+		Err:       e.err,
+	}
+
+	if e.child != nil {
+		p.Working = e.child.uuid
+		filled.Add(filled, e.child.filled)
+		cost.Add(cost, e.child.cost)
+	}
+
+	p.Filled = ratAmount(filled)
+	p.Remaining = ratAmount(new(big.Rat).Sub(e.quantity, filled))
+
+	if filled.Sign() > 0 {
+		p.AveragePrice = ratAmount(new(big.Rat).Quo(cost, filled))
+	}
+
+	return p
+}
+
+//Watch call handler with the progress every time it changes, from the execution's goroutine.
+//Call the returned func to stop.
+func (e *Execution) Watch(handler func(ExecutionProgress)) (unwatch func()) {
+	e.watchMutex.Lock()
+	defer e.watchMutex.Unlock()
+
+	id := e.nextWatch
+	e.nextWatch++
+	e.watchers[id] = handler
+
+	return func() {
+		e.watchMutex.Lock()
+		defer e.watchMutex.Unlock()
+
+		delete(e.watchers, id)
+	}
+}
+
+func (e *Execution) emit(progress ExecutionProgress) {
+	e.watchMutex.Lock()
+	handlers := make([]func(ExecutionProgress), 0, len(e.watchers))
+	for _, handler := range e.watchers {
+		handlers = append(handlers, handler)
+	}
+	e.watchMutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(progress)
+	}
+}
+
+//Pause cancel the working child order and place no more until Resume.
+func (e *Execution) Pause() {
+	e.setState(ExecutionRunning, ExecutionPaused)
+}
+
+//Resume continue a paused execution.
+func (e *Execution) Resume() {
+	e.setState(ExecutionPaused, ExecutionRunning)
+}
+
+func (e *Execution) setState(from, to ExecutionState) {
+	e.mutex.Lock()
+	if e.state == from {
+		e.state = to
+	}
+	e.mutex.Unlock()
+
+	e.poke()
+}
+
+func (e *Execution) poke() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+//Cancel stop the execution, cancelling its working child order. Returns the final progress once stopped,
+//or ctx.Err() if ctx expires first (the execution still stops in the background).
+func (e *Execution) Cancel(ctx context.Context) (ExecutionProgress, error) {
+	e.mutex.Lock()
+	e.cancelling = true
+	e.mutex.Unlock()
+
+	e.poke()
+
+	return e.Wait(ctx)
+}
+
+//Wait block until the execution ends and return its final progress.
+func (e *Execution) Wait(ctx context.Context) (ExecutionProgress, error) {
+	select {
+	case <-e.done:
+		return e.Progress(), nil
+	case <-ctx.Done():
+		return e.Progress(), ctx.Err()
+	}
+}
+
+//Done closed when the execution ends.
+func (e *Execution) Done() <-chan struct{} {
+	return e.done
+}
+
+//OnTrade count a trade of the execution's market towards the volume POV follows.
+func (e *Execution) OnTrade(trade Trade) {
+	if trade.Market != e.order.Market || trade.Quantity == nil {
+		return
+	}
+
+	e.mutex.Lock()
+	e.volume.Add(e.volume, decimal(trade.Quantity))
+	e.mutex.Unlock()
+}
+
+//Follow pass every trade from trades to OnTrade in the background, until trades is closed or the execution ends.
+func (e *Execution) Follow(trades <-chan Trade) {
+	go func() {
+		for {
+			select {
+			case <-e.done:
+				return
+			case trade, ok := <-trades:
+				if !ok {
+					return
+				}
+				e.OnTrade(trade)
+			}
+		}
+	}()
+}
+
+func (e *Execution) run() {
+	ticker := time.NewTicker(e.order.Period)
+	defer ticker.Stop()
+
+	for !e.step() {
+		select {
+		case <-ticker.C:
+		case <-e.wake:
+		}
+	}
+}
+
+//step bring the child order in line with the state and the schedule. Returns true once the execution has ended.
+func (e *Execution) step() bool {
+	e.setErr(nil)
+	now := e.now()
+
+	e.refresh()
+
+	e.mutex.Lock()
+	cancelling, state := e.cancelling, e.state
+	filled := new(big.Rat).Set(e.filled)
+	if e.child != nil {
+		filled.Add(filled, e.child.filled)
+	}
+	e.mutex.Unlock()
+
+	switch {
+	case cancelling:
+		e.cancelChild()
+		return e.finish(ExecutionCancelled)
+	case filled.Cmp(e.quantity) >= 0:
+		return e.finish(ExecutionCompleted)
+	case e.schedule.expired(now):
+		e.cancelChild()
+		return e.finish(ExecutionExpired)
+	case state == ExecutionPaused:
+		e.cancelChild()
+		e.report()
+		return false
+	}
+
+	e.mutex.Lock()
+	volume := new(big.Rat).Set(e.volume)
+	e.mutex.Unlock()
+
+	//the cumulative quantity wanted filled once the child order fills, a clip at a time for icebergs.
+	target := e.schedule.desired(now, e.quantity, volume)
+	if e.clip != nil {
+		if clipped := new(big.Rat).Add(e.filled, e.clip); clipped.Cmp(target) < 0 {
+			target = clipped
+		}
+	}
+
+	rate, err := e.rate()
+	if err != nil {
+		e.setErr(err)
+		e.report()
+		return false
+	}
+
+	//a child at a stale price or for less than the schedule now wants is replaced.
+	if e.child != nil && (e.child.rate.Cmp(rate) != 0 || e.child.target.Cmp(target) < 0) {
+		e.cancelChild()
+	}
+
+	if e.child == nil {
+		if want := new(big.Rat).Sub(target, e.filled); want.Sign() > 0 {
+			e.place(want, target, rate)
+		}
+	}
+
+	e.report()
+	return false
+}
+
+//rate price for a child order: the limit for icebergs, else the touch no worse than the limit.
+func (e *Execution) rate() (*big.Float, error) {
+	if e.clip != nil {
+		return e.order.Limit, nil
+	}
+
+	ticker, err := e.broker.Ticker(e.order.Market)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, buy := ticker.Bid, e.order.Side == OrderSideBuy
+	if buy {
+		rate = ticker.Ask
+	}
+
+	if rate == nil || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%s - no price in ticker for %s", e.schedule.name(), e.order.Market)
+	}
+
+	if e.order.Limit != nil && (buy && rate.Cmp(e.order.Limit) > 0 || !buy && rate.Cmp(e.order.Limit) < 0) {
+		rate = e.order.Limit
+	}
+
+	return new(big.Float).Set(rate), nil
+}
+
+func (e *Execution) place(quantity, target *big.Rat, rate *big.Float) {
+	var uuid string
+	var err error
+
+	if e.order.Side == OrderSideBuy {
+		uuid, err = e.broker.BuyLimit(e.order.Market, ratAmount(quantity), rate)
+	} else {
+		uuid, err = e.broker.SellLimit(e.order.Market, ratAmount(quantity), rate)
+	}
+
+	if err != nil {
+		e.setErr(err)
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.orders++
+	e.child = &childOrder{uuid: uuid, rate: rate, target: target, filled: new(big.Rat), cost: new(big.Rat)}
+}
+
+//refresh read the child order's fills, retiring it once it is closed.
+func (e *Execution) refresh() {
+	if e.child == nil {
+		return
+	}
+
+	state, err := e.broker.Order(e.child.uuid)
+	if err != nil {
+		e.setErr(err)
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.child.filled = new(big.Rat).Sub(decimal(state.Quantity), decimal(state.QuantityRemaining))
+	e.child.cost = decimal(state.Price)
+
+	if !state.IsOpen {
+		e.filled.Add(e.filled, e.child.filled)
+		e.cost.Add(e.cost, e.child.cost)
+		e.child = nil
+	}
+}
+
+//cancelChild take the child order off the book, keeping what filled. A child that can't be cancelled stays.
+func (e *Execution) cancelChild() {
+	if e.child == nil {
+		return
+	}
+
+	if err := e.broker.Cancel(e.child.uuid); err != nil {
+		e.setErr(err)
+	}
+
+	e.refresh()
+}
+
+func (e *Execution) setErr(err error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.err = err
+}
+
+func (e *Execution) finish(state ExecutionState) bool {
+	e.mutex.Lock()
+	e.state = state
+	e.mutex.Unlock()
+
+	e.report()
+	close(e.done)
+
+	return true
+}
+
+//report emit the progress if it changed since the last report.
+func (e *Execution) report() {
+	e.mutex.Lock()
+	progress := e.progress()
+
+	key := fmt.Sprintf("%s %s %s %d %s %v", progress.State, progress.Filled.Text('g', -1), progress.Working, progress.Orders, progress.Remaining.Text('g', -1), progress.Err)
+	changed := key != e.lastEmitted
+	e.lastEmitted = key
+	e.mutex.Unlock()
+
+	if changed {
+		e.emit(progress)
+	}
+}
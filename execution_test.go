@@ -0,0 +1,186 @@
+package bittrex
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func paperTrade(price, quantity string) Trade {
+	return Trade{Market: "BTC-LTC", Price: dec(price), Quantity: dec(quantity)}
+}
+
+func TestIcebergExecution(t *testing.T) {
+	paper := NewPaperBroker(map[string]*big.Float{"BTC": dec("10")})
+
+	e, err := newExecution(paper, ExecutionOrder{Market: "BTC-LTC", Side: OrderSideBuy, Quantity: dec("5"), Limit: dec("0.01")}, dec("2"), icebergSchedule{}, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reports []ExecutionProgress
+	e.Watch(func(progress ExecutionProgress) {
+		reports = append(reports, progress)
+	})
+
+	e.step()
+	open, _ := paper.OpenOrders("BTC-LTC")
+	if len(open) != 1 || open[0].Quantity.Text('f', -1) != "2" {
+		t.Fatalf("expected a clip of 2 on the book, got %+v", open)
+	}
+
+	//a trade above the limit does not fill a buy
+	paper.OnTrade(paperTrade("0.011", "5"))
+	paper.OnTrade(paperTrade("0.01", "1.5"))
+	e.step()
+	expectDecimal(t, "partly filled clip", e.Progress().Filled, "1.5")
+
+	for _, trade := range []Trade{paperTrade("0.009", "3"), paperTrade("0.01", "10"), paperTrade("0.01", "10")} {
+		paper.OnTrade(trade)
+		if e.step() {
+			break
+		}
+		e.step()
+	}
+
+	progress := e.Progress()
+	if progress.State != ExecutionCompleted || progress.Orders != 3 || progress.Working != "" {
+		t.Fatalf("unexpected final progress %+v", progress)
+	}
+	expectDecimal(t, "filled", progress.Filled, "5")
+	expectDecimal(t, "average", progress.AveragePrice, "0.01")
+
+	btc, _ := paper.Balance("BTC")
+	expectDecimal(t, "paper BTC", btc.Balance, "9.949875")
+	ltc, _ := paper.Balance("LTC")
+	expectDecimal(t, "paper LTC", ltc.Balance, "5")
+
+	if len(reports) == 0 || reports[len(reports)-1].State != ExecutionCompleted {
+		t.Errorf("watchers did not see the end: %+v", reports)
+	}
+}
+
+func TestTWAPExecution(t *testing.T) {
+	paper := NewPaperBroker(map[string]*big.Float{"LTC": dec("4")})
+	paper.OnTrade(paperTrade("0.02", "0"))
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+
+	e, err := newExecution(paper, ExecutionOrder{Market: "BTC-LTC", Side: OrderSideSell, Quantity: dec("4")}, nil, twapSchedule{start, 10 * time.Minute, 2}, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.step()
+	progress := e.Progress()
+	if progress.Orders != 1 || progress.Working == "" {
+		t.Fatalf("expected the first slice working, got %+v", progress)
+	}
+
+	paper.OnTrade(paperTrade("0.021", "1"))
+
+	//the second slice replaces the first child at the new price
+	now = start.Add(5 * time.Minute)
+	e.step()
+
+	child, _ := paper.Order(e.Progress().Working)
+	expectDecimal(t, "second child quantity", child.Quantity, "3")
+	expectDecimal(t, "second child rate", child.Limit, "0.021")
+
+	paper.OnTrade(paperTrade("0.022", "1"))
+
+	now = start.Add(10 * time.Minute)
+	if !e.step() {
+		t.Fatal("expected the window to end the execution")
+	}
+
+	progress = e.Progress()
+	if progress.State != ExecutionExpired || progress.Orders != 2 || progress.Working != "" {
+		t.Fatalf("unexpected final progress %+v", progress)
+	}
+	expectDecimal(t, "filled", progress.Filled, "2")
+	expectDecimal(t, "remaining", progress.Remaining, "2")
+	expectDecimal(t, "average", progress.AveragePrice, "0.0205")
+
+	if open, _ := paper.OpenOrders(""); len(open) != 0 {
+		t.Errorf("expected nothing left on the book, got %+v", open)
+	}
+}
+
+func TestPOVExecutionPause(t *testing.T) {
+	paper := NewPaperBroker(map[string]*big.Float{"BTC": dec("1")})
+	paper.OnTrade(paperTrade("0.011", "0"))
+
+	e, err := newExecution(paper, ExecutionOrder{Market: "BTC-LTC", Side: OrderSideBuy, Quantity: dec("10"), Limit: dec("0.01")}, nil, povSchedule{decimal(dec("0.5"))}, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//nothing traded yet, nothing wanted
+	e.step()
+	if e.Progress().Orders != 0 {
+		t.Fatal("placed an order before any volume")
+	}
+
+	e.OnTrade(paperTrade("0.011", "4"))
+	e.OnTrade(Trade{Market: "BTC-ETH", Price: dec("0.05"), Quantity: dec("100")})
+	e.step()
+
+	child, _ := paper.Order(e.Progress().Working)
+	expectDecimal(t, "pov child", child.Quantity, "2")
+	expectDecimal(t, "limit caps the ask", child.Limit, "0.01")
+
+	e.Pause()
+	e.step()
+	if progress := e.Progress(); progress.State != ExecutionPaused || progress.Working != "" {
+		t.Fatalf("expected a paused execution with no child, got %+v", progress)
+	}
+
+	e.Resume()
+	e.step()
+	if progress := e.Progress(); progress.State != ExecutionRunning || progress.Orders != 2 {
+		t.Fatalf("expected a new child after Resume, got %+v", progress)
+	}
+}
+
+func TestExecutionCancel(t *testing.T) {
+	paper := NewPaperBroker(map[string]*big.Float{"BTC": dec("1")})
+
+	e, err := NewIceberg(paper, ExecutionOrder{Market: "BTC-LTC", Side: OrderSideBuy, Quantity: dec("10"), Limit: dec("0.01"), Period: time.Millisecond}, dec("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	for e.Progress().Orders == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	paper.OnTrade(paperTrade("0.01", "0.25"))
+
+	progress, err := e.Cancel(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if progress.State != ExecutionCancelled || progress.Working != "" {
+		t.Errorf("unexpected progress after Cancel %+v", progress)
+	}
+	expectDecimal(t, "kept fill", progress.Filled, "0.25")
+
+	if open, _ := paper.OpenOrders(""); len(open) != 0 {
+		t.Errorf("expected the child cancelled, got %+v", open)
+	}
+
+	if _, err := NewIceberg(paper, ExecutionOrder{Market: "BTC-LTC", Side: OrderSideBuy, Quantity: dec("1")}, dec("1")); err == nil {
+		t.Error("expected an iceberg without a limit to fail")
+	}
+
+	if _, err := NewTWAP(paper, ExecutionOrder{Market: "BTC-LTC", Side: OrderSideBuy, Quantity: dec("1")}, time.Minute, 0); err == nil {
+		t.Error("expected a TWAP without slices to fail")
+	}
+}
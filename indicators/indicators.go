@@ -0,0 +1,121 @@
+//Package indicators technical indicators over bittrex.Candle series.
+//
+//Every indicator has an incremental form, a struct whose Update method takes the next candle (for example
+//each Final update of a bittrex.CandleBuilder) and returns the new value once enough candles have been seen,
+//and a batch form over a []bittrex.Candle returning one value per candle, nil until the indicator is warmed up.
+//Both return a PeriodError for periods below 1.
+//Math is done on big.Float at Precision bits; values are computed from the candles' Close unless stated otherwise.
+package indicators
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/technicalviking/bittrex"
+)
+
+//Precision mantissa bits used for every computed value.
+const Precision = 128
+
+//PeriodError a period an indicator can't be computed over.
+type PeriodError struct {
+	Indicator string
+	Period    int
+}
+
+func (e PeriodError) Error() string {
+	return fmt.Sprintf("%s period must be positive, got %d", e.Indicator, e.Period)
+}
+
+//checkPeriods a PeriodError for the first of periods below 1.
+func checkPeriods(indicator string, periods ...int) error {
+	for _, period := range periods {
+		if period < 1 {
+			return PeriodError{indicator, period}
+		}
+	}
+	return nil
+}
+
+func newFloat() *big.Float {
+	return new(big.Float).SetPrec(Precision)
+}
+
+func fromInt(i int) *big.Float {
+	return newFloat().SetInt64(int64(i))
+}
+
+func copyFloat(f *big.Float) *big.Float {
+	if f == nil {
+		return newFloat()
+	}
+	return newFloat().Set(f)
+}
+
+func add(a, b *big.Float) *big.Float {
+	return newFloat().Add(a, b)
+}
+
+func sub(a, b *big.Float) *big.Float {
+	return newFloat().Sub(a, b)
+}
+
+func mul(a, b *big.Float) *big.Float {
+	return newFloat().Mul(a, b)
+}
+
+func quo(a, b *big.Float) *big.Float {
+	return newFloat().Quo(a, b)
+}
+
+func maxFloat(a, b *big.Float) *big.Float {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func minFloat(a, b *big.Float) *big.Float {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+//window the last size values pushed.
+type window struct {
+	size   int
+	values []*big.Float
+}
+
+func (w *window) push(value *big.Float) {
+	w.values = append(w.values, value)
+	if len(w.values) > w.size {
+		w.values = w.values[1:]
+	}
+}
+
+func (w *window) full() bool {
+	return len(w.values) == w.size
+}
+
+func (w *window) sum() *big.Float {
+	total := newFloat()
+	for _, value := range w.values {
+		total.Add(total, value)
+	}
+	return total
+}
+
+//batch run update over every candle, collecting one (possibly nil) result per candle.
+func batch(candles []bittrex.Candle, update func(bittrex.Candle) (*big.Float, bool)) []*big.Float {
+	results := make([]*big.Float, len(candles))
+
+	for i, candle := range candles {
+		if value, ok := update(candle); ok {
+			results[i] = value
+		}
+	}
+
+	return results
+}
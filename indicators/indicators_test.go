@@ -0,0 +1,218 @@
+package indicators
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/technicalviking/bittrex"
+)
+
+//testCloses closes from Wilder's RSI example, extended.
+var testCloses = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08, 45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41,
+	46.22, 45.64, 46.21, 46.25, 45.71, 46.45, 45.78, 45.35, 44.03, 44.18, 44.22, 44.57, 43.42, 42.66, 43.13, 43.50, 44.20,
+}
+
+//testCandles candles around testCloses. Reference values below were computed independently in float64.
+func testCandles() []bittrex.Candle {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := make([]bittrex.Candle, len(testCloses))
+
+	for i, close := range testCloses {
+		volume := float64(1000 + 37*i - (i%5)*90)
+		candles[i] = bittrex.Candle{
+			TimeStamp:  bittrex.BittrexTimestamp(start.Add(time.Duration(i) * time.Hour)),
+			Open:       big.NewFloat(close),
+			High:       big.NewFloat(close + 0.3 + 0.1*float64(i%3)),
+			Low:        big.NewFloat(close - 0.25 - 0.05*float64(i%4)),
+			Close:      big.NewFloat(close),
+			Volume:     big.NewFloat(volume),
+			BaseVolume: big.NewFloat(volume * close),
+		}
+	}
+
+	return candles
+}
+
+func checkValue(t *testing.T, label string, got *big.Float, want float64) {
+	if got == nil {
+		t.Errorf("%s: no value, wanted %v", label, want)
+		return
+	}
+
+	value, _ := got.Float64()
+	if math.Abs(value-want) > 1e-9*math.Max(1, math.Abs(want)) {
+		t.Errorf("%s: got %v, wanted %v", label, value, want)
+	}
+}
+
+func firstValue(values []*big.Float) int {
+	for i, value := range values {
+		if value != nil {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestMovingAverages(t *testing.T) {
+	candles := testCandles()
+
+	sma, err := SMA(candles, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := firstValue(sma); first != 9 {
+		t.Errorf("SMA: first value at %d", first)
+	}
+	checkValue(t, "SMA 30", sma[30], 44.996)
+	checkValue(t, "SMA 34", sma[34], 43.925999999999995)
+
+	ema, err := EMA(candles, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, "EMA 9", ema[9], 44.779)
+	checkValue(t, "EMA 30", ema[30], 44.7122861832326)
+	checkValue(t, "EMA 34", ema[34], 44.041844795313786)
+
+	wma, err := WMA(candles, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, "WMA 30", wma[30], 44.53490909090909)
+	checkValue(t, "WMA 34", wma[34], 43.69745454545454)
+}
+
+func TestOscillators(t *testing.T) {
+	candles := testCandles()
+
+	rsi, err := RSI(candles, 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := firstValue(rsi); first != 14 {
+		t.Errorf("RSI: first value at %d", first)
+	}
+	checkValue(t, "RSI 14", rsi[14], 70.46413502109705)
+	checkValue(t, "RSI 30", rsi[30], 37.322778313379956)
+	checkValue(t, "RSI 34", rsi[34], 47.31521490268803)
+
+	macd, err := MACD(candles, 12, 26, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if macd[32].MACD != nil || macd[33].MACD == nil {
+		t.Errorf("MACD: expected the first value at 33")
+	}
+	checkValue(t, "MACD 34", macd[34].MACD, -0.4619845951317103)
+	checkValue(t, "MACD signal 34", macd[34].Signal, -0.2111493673810505)
+	checkValue(t, "MACD histogram 34", macd[34].Histogram, -0.2508352277506598)
+
+	stochastic, err := Stochastic(candles, 14, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stochastic[14].K == nil || stochastic[14].D != nil {
+		t.Errorf("Stochastic: expected %%K but no %%D at 14")
+	}
+	checkValue(t, "%K 30", stochastic[30].K, 9.020618556701061)
+	checkValue(t, "%D 30", stochastic[30].D, 17.58383513561797)
+	checkValue(t, "%K 34", stochastic[34].K, 41.364605543710084)
+	checkValue(t, "%D 34", stochastic[34].D, 28.784648187633312)
+}
+
+func TestVolatilityAndVolume(t *testing.T) {
+	candles := testCandles()
+
+	bollinger, err := Bollinger(candles, 20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, "Bollinger upper 30", bollinger[30].Upper, 47.3352466525569)
+	checkValue(t, "Bollinger middle 30", bollinger[30].Middle, 45.5335)
+	checkValue(t, "Bollinger lower 30", bollinger[30].Lower, 43.731753347443096)
+	checkValue(t, "Bollinger upper 34", bollinger[34].Upper, 47.409494142642686)
+
+	atr, err := ATR(candles, 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := firstValue(atr); first != 13 {
+		t.Errorf("ATR: first value at %d", first)
+	}
+	checkValue(t, "ATR 30", atr[30], 0.9156204985747189)
+	checkValue(t, "ATR 34", atr[34], 0.9298244757338755)
+
+	obv := OBV(candles)
+	checkValue(t, "OBV 0", obv[0], 0)
+	checkValue(t, "OBV 30", obv[30], 5077)
+	checkValue(t, "OBV 34", obv[34], 8873)
+
+	vwap := VWAP(candles)
+	checkValue(t, "VWAP 30", vwap[30], 45.33198123272203)
+	checkValue(t, "VWAP 34", vwap[34], 45.027670906043575)
+}
+
+func TestIncremental(t *testing.T) {
+	candles := testCandles()
+
+	//incremental updates from a live builder match the batch form
+	batch, err := RSI(candles, 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := NewRSI(14)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, candle := range candles {
+		value, ok := live.Update(candle)
+		if ok != (batch[i] != nil) {
+			t.Fatalf("RSI %d: incremental ready %v, batch %v", i, ok, batch[i])
+		}
+		if ok && value.Cmp(batch[i]) != 0 {
+			t.Errorf("RSI %d: incremental %s, batch %s", i, value.String(), batch[i].String())
+		}
+	}
+
+	vwap := NewVWAP()
+	vwap.Update(candles[0])
+	vwap.Reset()
+	value, _ := vwap.Update(candles[1])
+	checkValue(t, "VWAP after reset", value, (44.49+43.790000000000006+44.09)/3)
+
+	//returned values are not modified by later updates
+	sma, err := NewSMA(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sma.Update(candles[0])
+	first, _ := sma.Update(candles[1])
+	sma.Update(candles[2])
+	checkValue(t, "SMA copy", first, (44.34+44.09)/2)
+}
+
+func TestPeriodValidation(t *testing.T) {
+	for name, err := range map[string]error{
+		"SMA":        func() error { _, err := NewSMA(0); return err }(),
+		"EMA":        func() error { _, err := NewEMA(-1); return err }(),
+		"WMA":        func() error { _, err := NewWMA(0); return err }(),
+		"RSI":        func() error { _, err := NewRSI(0); return err }(),
+		"MACD":       func() error { _, err := NewMACD(12, 26, 0); return err }(),
+		"Stochastic": func() error { _, err := NewStochastic(14, 0); return err }(),
+		"Bollinger":  func() error { _, err := NewBollinger(0, 2); return err }(),
+		"ATR":        func() error { _, err := NewATR(0); return err }(),
+	} {
+		if _, ok := err.(PeriodError); !ok {
+			t.Errorf("%s: expected a PeriodError, got %v", name, err)
+		}
+	}
+
+	if values, err := SMA(testCandles(), 0); err == nil || values != nil {
+		t.Errorf("expected the batch form to reject period 0, got %v %v", values, err)
+	}
+}
@@ -0,0 +1,145 @@
+package indicators
+
+import (
+	"math/big"
+
+	"github.com/technicalviking/bittrex"
+)
+
+//SMAState incremental simple moving average.
+type SMAState struct {
+	window window
+}
+
+//NewSMA simple moving average over period candles.
+func NewSMA(period int) (*SMAState, error) {
+	if err := checkPeriods("SMA", period); err != nil {
+		return nil, err
+	}
+
+	return &SMAState{window{size: period}}, nil
+}
+
+//Update add a candle.
+func (s *SMAState) Update(candle bittrex.Candle) (*big.Float, bool) {
+	return s.UpdateValue(candle.Close)
+}
+
+//UpdateValue add a raw value, for averaging something other than Close.
+func (s *SMAState) UpdateValue(value *big.Float) (*big.Float, bool) {
+	s.window.push(copyFloat(value))
+
+	if !s.window.full() {
+		return nil, false
+	}
+
+	return quo(s.window.sum(), fromInt(s.window.size)), true
+}
+
+//SMA simple moving average of Close over period candles.
+func SMA(candles []bittrex.Candle, period int) ([]*big.Float, error) {
+	state, err := NewSMA(period)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch(candles, state.Update), nil
+}
+
+//EMAState incremental exponential moving average. The smoothing factor is 2/(period+1) and the first value
+//is the simple average of the first period values.
+type EMAState struct {
+	period int
+	alpha  *big.Float
+	seed   *SMAState
+	value  *big.Float
+}
+
+//NewEMA exponential moving average over period candles.
+func NewEMA(period int) (*EMAState, error) {
+	if err := checkPeriods("EMA", period); err != nil {
+		return nil, err
+	}
+
+	seed, _ := NewSMA(period)
+
+	return &EMAState{
+		period: period,
+		alpha:  quo(fromInt(2), fromInt(period+1)),
+		seed:   seed,
+	}, nil
+}
+
+//Update add a candle.
+func (e *EMAState) Update(candle bittrex.Candle) (*big.Float, bool) {
+	return e.UpdateValue(candle.Close)
+}
+
+//UpdateValue add a raw value, for smoothing something other than Close.
+func (e *EMAState) UpdateValue(value *big.Float) (*big.Float, bool) {
+	if e.value == nil {
+		seed, ok := e.seed.UpdateValue(value)
+		if !ok {
+			return nil, false
+		}
+		e.value = seed
+		return copyFloat(e.value), true
+	}
+
+	//value = previous + alpha * (value - previous)
+	e.value = add(e.value, mul(e.alpha, sub(value, e.value)))
+
+	return copyFloat(e.value), true
+}
+
+//EMA exponential moving average of Close over period candles.
+func EMA(candles []bittrex.Candle, period int) ([]*big.Float, error) {
+	state, err := NewEMA(period)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch(candles, state.Update), nil
+}
+
+//WMAState incremental linearly weighted moving average, the newest value weighing period and the oldest 1.
+type WMAState struct {
+	window window
+}
+
+//NewWMA weighted moving average over period candles.
+func NewWMA(period int) (*WMAState, error) {
+	if err := checkPeriods("WMA", period); err != nil {
+		return nil, err
+	}
+
+	return &WMAState{window{size: period}}, nil
+}
+
+//Update add a candle.
+func (w *WMAState) Update(candle bittrex.Candle) (*big.Float, bool) {
+	w.window.push(copyFloat(candle.Close))
+
+	if !w.window.full() {
+		return nil, false
+	}
+
+	total := newFloat()
+	for i, value := range w.window.values {
+		total.Add(total, mul(value, fromInt(i+1)))
+	}
+
+	weights := fromInt(w.window.size * (w.window.size + 1) / 2)
+
+	return quo(total, weights), true
+}
+
+//WMA weighted moving average of Close over period candles.
+func WMA(candles []bittrex.Candle, period int) ([]*big.Float, error) {
+	state, err := NewWMA(period)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch(candles, state.Update), nil
+}
@@ -0,0 +1,220 @@
+package indicators
+
+import (
+	"math/big"
+
+	"github.com/technicalviking/bittrex"
+)
+
+//RSIState incremental relative strength index with Wilder's smoothing.
+type RSIState struct {
+	period   int
+	previous *big.Float
+	gains    window
+	losses   window
+	avgGain  *big.Float
+	avgLoss  *big.Float
+}
+
+//NewRSI relative strength index over period candles. The first value needs period+1 candles.
+func NewRSI(period int) (*RSIState, error) {
+	if err := checkPeriods("RSI", period); err != nil {
+		return nil, err
+	}
+
+	return &RSIState{
+		period: period,
+		gains:  window{size: period},
+		losses: window{size: period},
+	}, nil
+}
+
+//Update add a candle. Returns a value between 0 and 100.
+func (r *RSIState) Update(candle bittrex.Candle) (*big.Float, bool) {
+	price := copyFloat(candle.Close)
+
+	if r.previous == nil {
+		r.previous = price
+		return nil, false
+	}
+
+	change := sub(price, r.previous)
+	r.previous = price
+
+	gain, loss := newFloat(), newFloat()
+	if change.Sign() > 0 {
+		gain = change
+	} else {
+		loss = newFloat().Neg(change)
+	}
+
+	period := fromInt(r.period)
+
+	if r.avgGain == nil {
+		r.gains.push(gain)
+		r.losses.push(loss)
+
+		if !r.gains.full() {
+			return nil, false
+		}
+
+		r.avgGain = quo(r.gains.sum(), period)
+		r.avgLoss = quo(r.losses.sum(), period)
+	} else {
+		//avg = (avg * (period - 1) + current) / period
+		periodLess := fromInt(r.period - 1)
+		r.avgGain = quo(add(mul(r.avgGain, periodLess), gain), period)
+		r.avgLoss = quo(add(mul(r.avgLoss, periodLess), loss), period)
+	}
+
+	hundred := fromInt(100)
+
+	if r.avgLoss.Sign() == 0 {
+		return hundred, true
+	}
+
+	//100 - 100 / (1 + avgGain / avgLoss)
+	rs := quo(r.avgGain, r.avgLoss)
+	return sub(hundred, quo(hundred, add(fromInt(1), rs))), true
+}
+
+//RSI relative strength index of Close over period candles.
+func RSI(candles []bittrex.Candle, period int) ([]*big.Float, error) {
+	state, err := NewRSI(period)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch(candles, state.Update), nil
+}
+
+//MACDValue one MACD result.
+type MACDValue struct {
+	MACD      *big.Float
+	Signal    *big.Float
+	Histogram *big.Float
+}
+
+//MACDState incremental moving average convergence divergence.
+type MACDState struct {
+	fast   *EMAState
+	slow   *EMAState
+	signal *EMAState
+}
+
+//NewMACD MACD with the given EMA periods, classically 12, 26 and 9.
+func NewMACD(fast, slow, signal int) (*MACDState, error) {
+	if err := checkPeriods("MACD", fast, slow, signal); err != nil {
+		return nil, err
+	}
+
+	fastEMA, _ := NewEMA(fast)
+	slowEMA, _ := NewEMA(slow)
+	signalEMA, _ := NewEMA(signal)
+
+	return &MACDState{fastEMA, slowEMA, signalEMA}, nil
+}
+
+//Update add a candle. The first value needs slow+signal-1 candles.
+func (m *MACDState) Update(candle bittrex.Candle) (MACDValue, bool) {
+	fast, fastOK := m.fast.Update(candle)
+	slow, slowOK := m.slow.Update(candle)
+
+	if !fastOK || !slowOK {
+		return MACDValue{}, false
+	}
+
+	macd := sub(fast, slow)
+
+	signal, ok := m.signal.UpdateValue(macd)
+	if !ok {
+		return MACDValue{}, false
+	}
+
+	return MACDValue{macd, signal, sub(macd, signal)}, true
+}
+
+//MACD moving average convergence divergence of Close, one value per candle, zero value until warmed up.
+func MACD(candles []bittrex.Candle, fast, slow, signal int) ([]MACDValue, error) {
+	state, err := NewMACD(fast, slow, signal)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]MACDValue, len(candles))
+
+	for i, candle := range candles {
+		results[i], _ = state.Update(candle)
+	}
+
+	return results, nil
+}
+
+//StochasticValue one stochastic oscillator result. D is nil until dPeriod values of K are known.
+type StochasticValue struct {
+	K *big.Float
+	D *big.Float
+}
+
+//StochasticState incremental stochastic oscillator.
+type StochasticState struct {
+	highs window
+	lows  window
+	d     *SMAState
+}
+
+//NewStochastic %K over kPeriod candles, %D the simple average of dPeriod %K values. Classically 14 and 3.
+func NewStochastic(kPeriod, dPeriod int) (*StochasticState, error) {
+	if err := checkPeriods("Stochastic", kPeriod, dPeriod); err != nil {
+		return nil, err
+	}
+
+	d, _ := NewSMA(dPeriod)
+
+	return &StochasticState{
+		highs: window{size: kPeriod},
+		lows:  window{size: kPeriod},
+		d:     d,
+	}, nil
+}
+
+//Update add a candle. %K is 100 * (close - lowest low) / (highest high - lowest low), or 50 when the range is empty.
+func (s *StochasticState) Update(candle bittrex.Candle) (StochasticValue, bool) {
+	s.highs.push(copyFloat(candle.High))
+	s.lows.push(copyFloat(candle.Low))
+
+	if !s.highs.full() {
+		return StochasticValue{}, false
+	}
+
+	highest, lowest := s.highs.values[0], s.lows.values[0]
+	for i := range s.highs.values {
+		highest = maxFloat(highest, s.highs.values[i])
+		lowest = minFloat(lowest, s.lows.values[i])
+	}
+
+	k := fromInt(50)
+	if spread := sub(highest, lowest); spread.Sign() != 0 {
+		k = quo(mul(fromInt(100), sub(candle.Close, lowest)), spread)
+	}
+
+	d, _ := s.d.UpdateValue(k)
+
+	return StochasticValue{k, d}, true
+}
+
+//Stochastic stochastic oscillator, one value per candle, zero value until warmed up.
+func Stochastic(candles []bittrex.Candle, kPeriod, dPeriod int) ([]StochasticValue, error) {
+	state, err := NewStochastic(kPeriod, dPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]StochasticValue, len(candles))
+
+	for i, candle := range candles {
+		results[i], _ = state.Update(candle)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,127 @@
+package indicators
+
+import (
+	"math/big"
+
+	"github.com/technicalviking/bittrex"
+)
+
+//BollingerValue one Bollinger Bands result.
+type BollingerValue struct {
+	Upper  *big.Float
+	Middle *big.Float
+	Lower  *big.Float
+}
+
+//BollingerState incremental Bollinger Bands.
+type BollingerState struct {
+	window window
+	width  *big.Float
+}
+
+//NewBollinger bands width standard deviations around the period simple average, classically 20 and 2.
+//The standard deviation is the population one, as in Bollinger's definition.
+func NewBollinger(period int, width float64) (*BollingerState, error) {
+	if err := checkPeriods("Bollinger", period); err != nil {
+		return nil, err
+	}
+
+	return &BollingerState{
+		window: window{size: period},
+		width:  newFloat().SetFloat64(width),
+	}, nil
+}
+
+//Update add a candle.
+func (b *BollingerState) Update(candle bittrex.Candle) (BollingerValue, bool) {
+	b.window.push(copyFloat(candle.Close))
+
+	if !b.window.full() {
+		return BollingerValue{}, false
+	}
+
+	count := fromInt(b.window.size)
+	mean := quo(b.window.sum(), count)
+
+	variance := newFloat()
+	for _, value := range b.window.values {
+		deviation := sub(value, mean)
+		variance.Add(variance, mul(deviation, deviation))
+	}
+	variance.Quo(variance, count)
+
+	offset := mul(b.width, newFloat().Sqrt(variance))
+
+	return BollingerValue{add(mean, offset), mean, sub(mean, offset)}, true
+}
+
+//Bollinger Bollinger Bands of Close, one value per candle, zero value until warmed up.
+func Bollinger(candles []bittrex.Candle, period int, width float64) ([]BollingerValue, error) {
+	state, err := NewBollinger(period, width)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BollingerValue, len(candles))
+
+	for i, candle := range candles {
+		results[i], _ = state.Update(candle)
+	}
+
+	return results, nil
+}
+
+//ATRState incremental average true range with Wilder's smoothing.
+type ATRState struct {
+	period   int
+	previous *big.Float
+	ranges   window
+	value    *big.Float
+}
+
+//NewATR average true range over period candles.
+func NewATR(period int) (*ATRState, error) {
+	if err := checkPeriods("ATR", period); err != nil {
+		return nil, err
+	}
+
+	return &ATRState{period: period, ranges: window{size: period}}, nil
+}
+
+//Update add a candle. The true range of the first candle is its high - low.
+func (a *ATRState) Update(candle bittrex.Candle) (*big.Float, bool) {
+	trueRange := sub(candle.High, candle.Low)
+
+	if a.previous != nil {
+		trueRange = maxFloat(trueRange, newFloat().Abs(sub(candle.High, a.previous)))
+		trueRange = maxFloat(trueRange, newFloat().Abs(sub(candle.Low, a.previous)))
+	}
+
+	a.previous = copyFloat(candle.Close)
+
+	period := fromInt(a.period)
+
+	if a.value == nil {
+		a.ranges.push(trueRange)
+
+		if !a.ranges.full() {
+			return nil, false
+		}
+
+		a.value = quo(a.ranges.sum(), period)
+	} else {
+		a.value = quo(add(mul(a.value, fromInt(a.period-1)), trueRange), period)
+	}
+
+	return copyFloat(a.value), true
+}
+
+//ATR average true range over period candles.
+func ATR(candles []bittrex.Candle, period int) ([]*big.Float, error) {
+	state, err := NewATR(period)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch(candles, state.Update), nil
+}
@@ -0,0 +1,76 @@
+package indicators
+
+import (
+	"math/big"
+
+	"github.com/technicalviking/bittrex"
+)
+
+//OBVState incremental on balance volume.
+type OBVState struct {
+	previous *big.Float
+	value    *big.Float
+}
+
+//NewOBV on balance volume, starting at 0 on the first candle.
+func NewOBV() *OBVState {
+	return &OBVState{}
+}
+
+//Update add a candle. Volume is added when the close rises and subtracted when it falls.
+func (o *OBVState) Update(candle bittrex.Candle) (*big.Float, bool) {
+	if o.previous == nil {
+		o.value = newFloat()
+	} else if change := candle.Close.Cmp(o.previous); change > 0 {
+		o.value = add(o.value, candle.Volume)
+	} else if change < 0 {
+		o.value = sub(o.value, candle.Volume)
+	}
+
+	o.previous = copyFloat(candle.Close)
+
+	return copyFloat(o.value), true
+}
+
+//OBV on balance volume.
+func OBV(candles []bittrex.Candle) []*big.Float {
+	return batch(candles, NewOBV().Update)
+}
+
+//VWAPState incremental volume weighted average price, using the typical price (high + low + close) / 3.
+type VWAPState struct {
+	priceVolume *big.Float
+	volume      *big.Float
+}
+
+//NewVWAP volume weighted average price from the first candle on. Use Reset to start a new session.
+func NewVWAP() *VWAPState {
+	v := &VWAPState{}
+	v.Reset()
+	return v
+}
+
+//Reset start accumulating again, ex: at the start of each day.
+func (v *VWAPState) Reset() {
+	v.priceVolume = newFloat()
+	v.volume = newFloat()
+}
+
+//Update add a candle. No value until some volume has traded.
+func (v *VWAPState) Update(candle bittrex.Candle) (*big.Float, bool) {
+	typical := quo(add(add(candle.High, candle.Low), candle.Close), fromInt(3))
+
+	v.priceVolume = add(v.priceVolume, mul(typical, candle.Volume))
+	v.volume = add(v.volume, candle.Volume)
+
+	if v.volume.Sign() == 0 {
+		return nil, false
+	}
+
+	return quo(v.priceVolume, v.volume), true
+}
+
+//VWAP volume weighted average price accumulated over all candles.
+func VWAP(candles []bittrex.Candle) []*big.Float {
+	return batch(candles, NewVWAP().Update)
+}
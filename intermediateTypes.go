@@ -150,13 +150,13 @@ func (m *OrderElement) UnmarshalJSON(raw []byte) error {
 
 func (m *Trade) UnmarshalJSON(raw []byte) error {
 	temp := struct {
-		ID        string           `json:"Id"`        // : 319435,
+		ID        json.Number      `json:"Id"`        // : 319435,
 		TimeStamp BittrexTimestamp `json:"TimeStamp"` // : "2014-07-09T03:21:20.08",
 		Quantity  json.Number      `json:"Quantity"`  // : 0.30802438,
 		Price     json.Number      `json:"Price"`     // : 0.01263400,
 		Total     json.Number      `json:"Total"`     // : 0.00389158,
-		FillType  string           `json:"FillType"`  // : "FILL",
-		OrderType string           `json:"OrderType"` // : "BUY" or "SELL"
+		FillType  FillType         `json:"FillType"`  // : "FILL",
+		OrderType OrderSide        `json:"OrderType"` // : "BUY" or "SELL"
 	}{}
 
 	if err := json.Unmarshal(raw, &temp); err != nil {
@@ -164,7 +164,7 @@ func (m *Trade) UnmarshalJSON(raw []byte) error {
 	}
 
 	*m = Trade{
-		ID:        temp.ID,
+		ID:        temp.ID.String(),
 		TimeStamp: temp.TimeStamp,
 		Quantity:  castToBigFloat(temp.Quantity),
 		Price:     castToBigFloat(temp.Price),
@@ -181,7 +181,7 @@ func (m *OrderDescription) UnmarshalJSON(raw []byte) error {
 		UUID              string           `json:"Uuid"`              // : null,
 		OrderUUID         string           `json:"OrderUuid"`         // : "09aa5bb6-8232-41aa-9b78-a5a1093e0211",
 		Exchange          string           `json:"Exchange"`          // : "BTC-LTC",
-		OrderType         string           `json:"OrderType"`         // : "LIMIT_SELL",
+		OrderType         OrderType        `json:"OrderType"`         // : "LIMIT_SELL",
 		Quantity          json.Number      `json:"Quantity"`          // : 5.00000000,
 		QuantityRemaining json.Number      `json:"QuantityRemaining"` // : 5.00000000,
 		Limit             json.Number      `json:"Limit"`             // : 2.00000000,
@@ -193,7 +193,7 @@ func (m *OrderDescription) UnmarshalJSON(raw []byte) error {
 		CancelInitiated   bool             `json:"CancelInitiated"`   // : false,
 		ImmediateOrCancel bool             `json:"ImmediateOrCancel"` // : false,
 		IsConditional     bool             `json:"IsConditional"`     // : false,
-		Condition         string           `json:"Condition"`         // : null,
+		Condition         OrderCondition   `json:"Condition"`         // : null,
 		ConditionTarget   string           `json:"ConditionTarget"`   // : null
 	}{}
 
@@ -229,7 +229,7 @@ func (m *AccountOrderDescription) UnmarshalJSON(raw []byte) error {
 		AccountID                  string           `json:"AccountId"`                  // : null,
 		OrderUUID                  string           `json:"OrderUuid"`                  // : "0cb4c4e4-bdc7-4e13-8c13-430e587d2cc1",
 		Exchange                   string           `json:"Exchange"`                   // : "BTC-SHLD",
-		Type                       string           `json:"Type"`                       // : "LIMIT_BUY",
+		Type                       OrderType        `json:"Type"`                       // : "LIMIT_BUY",
 		Quantity                   json.Number      `json:"Quantity"`                   // : 1000.00000000,
 		QuantityRemaining          json.Number      `json:"QuantityRemaining"`          // : 1000.00000000,
 		Limit                      json.Number      `json:"Limit"`                      // : 0.00000001,
@@ -247,7 +247,7 @@ func (m *AccountOrderDescription) UnmarshalJSON(raw []byte) error {
 		CancelInitiated            bool             `json:"CancelInitiated"`            // : false,
 		ImmediateOrCancel          bool             `json:"ImmediateOrCancel"`          // : false,
 		IsConditional              bool             `json:"IsConditional"`              // : false,
-		Condition                  string           `json:"Condition"`                  // : "NONE",
+		Condition                  OrderCondition   `json:"Condition"`                  // : "NONE",
 		ConditionTarget            string           `json:"ConditionTarget"`            // : null
 	}{}
 
@@ -289,7 +289,7 @@ func (m *AccountOrderHistoryDescription) UnmarshalJSON(raw []byte) error {
 		OrderUUID         string           `json:"OrderUuid"`         // : "fd97d393-e9b9-4dd1-9dbf-f288fc72a185",
 		Exchange          string           `json:"Exchange"`          // : "BTC-LTC",
 		TimeStamp         BittrexTimestamp `json:"TimeStamp"`         // : "2014-07-09T04:01:00.667",
-		OrderType         string           `json:"OrderType"`         // : "LIMIT_BUY",
+		OrderType         OrderType        `json:"OrderType"`         // : "LIMIT_BUY",
 		Limit             json.Number      `json:"Limit"`             // : 0.00000001,
 		Quantity          json.Number      `json:"Quantity"`          // : 100000.00000000,
 		QuantityRemaining json.Number      `json:"QuantityRemaining"` // : 100000.00000000,
@@ -297,7 +297,7 @@ func (m *AccountOrderHistoryDescription) UnmarshalJSON(raw []byte) error {
 		Price             json.Number      `json:"Price"`             // : 0.00000000,
 		PricePerUnit      json.Number      `json:"PricePerUnit"`      // : null,
 		IsConditional     bool             `json:"IsConditional"`     // : false,
-		Condition         string           `json:"Condition"`         // : null,
+		Condition         OrderCondition   `json:"Condition"`         // : null,
 		ConditionTarget   string           `json:"ConditionTarget"`   // : null,
 		ImmediateOrCancel bool             `json:"ImmediateOrCancel"` // : false
 	}{}
@@ -419,3 +419,51 @@ func (m *Candle) UnmarshalJSON(raw []byte) error {
 
 	return nil
 }
+
+//OrderUpdate and Fill need their own unmarshallers, otherwise the one promoted from the embedded OrderElement
+//is used and every other field is dropped.
+func (m *OrderUpdate) UnmarshalJSON(raw []byte) error {
+	temp := struct {
+		Quantity json.Number     `json:"Quantity"`
+		Rate     json.Number     `json:"Rate"`
+		Type     OrderUpdateType `json:"Type"`
+	}{}
+
+	if err := json.Unmarshal(raw, &temp); err != nil {
+		return err
+	}
+
+	*m = OrderUpdate{
+		OrderElement: OrderElement{
+			Quantity: castToBigFloat(temp.Quantity),
+			Rate:     castToBigFloat(temp.Rate),
+		},
+		Type: temp.Type,
+	}
+
+	return nil
+}
+
+func (m *Fill) UnmarshalJSON(raw []byte) error {
+	temp := struct {
+		Quantity  json.Number      `json:"Quantity"`
+		Rate      json.Number      `json:"Rate"`
+		OrderType OrderSide        `json:"OrderType"`
+		TimeStamp BittrexTimestamp `json:"TimeStamp"`
+	}{}
+
+	if err := json.Unmarshal(raw, &temp); err != nil {
+		return err
+	}
+
+	*m = Fill{
+		OrderElement: OrderElement{
+			Quantity: castToBigFloat(temp.Quantity),
+			Rate:     castToBigFloat(temp.Rate),
+		},
+		OrderType: temp.OrderType,
+		Timestamp: temp.TimeStamp,
+	}
+
+	return nil
+}
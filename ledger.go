@@ -0,0 +1,608 @@
+package bittrex
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+//LotMethod which tax lots a disposal takes from.
+type LotMethod string
+
+const (
+	//LotFIFO first in, first out: the oldest lots first.
+	LotFIFO LotMethod = "FIFO"
+
+	//LotLIFO last in, first out: the newest lots first.
+	LotLIFO LotMethod = "LIFO"
+
+	//LotAverageCost every holding of a currency is one pool at its average cost.
+	LotAverageCost LotMethod = "AVERAGE_COST"
+)
+
+//Valid whether m is one of the LotMethod consts.
+func (m LotMethod) Valid() bool {
+	return m == LotFIFO || m == LotLIFO || m == LotAverageCost
+}
+
+func (m LotMethod) String() string {
+	return string(m)
+}
+
+func (m LotMethod) MarshalJSON() ([]byte, error) {
+	return marshalEnum(string(m), "lot method", m.Valid())
+}
+
+func (m *LotMethod) UnmarshalJSON(raw []byte) error {
+	value, err := unmarshalEnum(raw, "lot method", func(v string) bool { return LotMethod(v).Valid() })
+	*m = LotMethod(value)
+	return err
+}
+
+//PriceFunc value of one unit of currency in a Ledger's currency at a time, ex: from archived candles.
+type PriceFunc func(currency string, at time.Time) (*big.Float, error)
+
+//LedgerPriceError a Ledger needed the value of Currency at At and has no PriceFunc to ask.
+type LedgerPriceError struct {
+	Currency string
+	At       time.Time
+}
+
+func (e LedgerPriceError) Error() string {
+	return fmt.Sprintf("ledger - no price for %s at %s", e.Currency, e.At.UTC().Format(time.RFC3339))
+}
+
+//Disposal the part of a sale (or of a buy's base currency spent) taken from one tax lot. Proceeds are net of the
+//disposal's share of commission, Fee; Cost includes the commission paid acquiring the lot. Acquired is zero with
+//LotAverageCost, and for quantity no lot covered (Unmatched, ex: bought before the history starts), whose Cost is 0.
+type Disposal struct {
+	Currency  string
+	Quantity  *big.Float
+	Acquired  time.Time
+	Disposed  time.Time
+	Proceeds  *big.Float
+	Cost      *big.Float
+	Gain      *big.Float
+	Fee       *big.Float
+	Source    string
+	Unmatched bool
+}
+
+//LedgerFee commission paid on an order, or TxCost on a withdrawal: Amount of Currency, worth Value in the
+//ledger's currency.
+type LedgerFee struct {
+	Time     time.Time
+	Currency string
+	Amount   *big.Float
+	Value    *big.Float
+	Source   string
+}
+
+//Position what the ledger's lots hold of Currency, and what they cost. Price, Value and Unrealized (Value - Cost)
+//are nil when no current price was given.
+type Position struct {
+	Currency   string
+	Quantity   *big.Float
+	Cost       *big.Float
+	Price      *big.Float
+	Value      *big.Float
+	Unrealized *big.Float
+}
+
+type taxLot struct {
+	quantity *big.Rat
+	cost     *big.Rat
+	acquired time.Time
+}
+
+type ledgerEvent struct {
+	time     time.Time
+	rank     int
+	order    *AccountOrderHistoryDescription
+	transfer *TransactionHistoryDescription
+}
+
+const (
+	ledgerDeposit = iota
+	ledgerOrder
+	ledgerWithdrawal
+)
+
+//Ledger cost basis accounting in Currency (ex: "USDT") over an account's order, deposit and withdrawal histories.
+//
+//Every filled order exchanges one currency for another: a buy disposes of the base currency spent (commission
+//included) and acquires the market currency at that cost; a sell disposes of the market currency and acquires the
+//base currency received. Both sides are valued in Currency with Price at the time of the order, except Currency
+//itself, which is not tracked as lots. Deposits enter as lots at their value when deposited; withdrawals take lots
+//out without a gain, with TxCost (part of Amount) recorded as a fee.
+//
+//Histories may be added in any order and overlap; entries are deduplicated by UUID and replayed chronologically
+//whenever the ledger is read. TaxYearStart is the month tax years begin (January when zero); a year is named after
+//the calendar year it begins in. Safe for concurrent use.
+type Ledger struct {
+	Currency     string
+	Method       LotMethod
+	Price        PriceFunc
+	TaxYearStart time.Month
+
+	mutex     sync.Mutex
+	events    map[string]ledgerEvent
+	built     bool
+	lots      map[string][]*taxLot
+	disposals []Disposal
+	fees      []LedgerFee
+}
+
+//NewLedger empty ledger in currency using method. price may be nil when every order trades against currency and
+//nothing else is deposited or withdrawn.
+func NewLedger(currency string, method LotMethod, price PriceFunc) (*Ledger, error) {
+	if !method.Valid() {
+		return nil, EnumError{"lot method", string(method)}
+	}
+
+	return &Ledger{
+		Currency: currency,
+		Method:   method,
+		Price:    price,
+		events:   make(map[string]ledgerEvent),
+	}, nil
+}
+
+//NewLedger ledger over the account's whole order, deposit and withdrawal histories.
+func (c *Client) NewLedger(currency string, method LotMethod, price PriceFunc) (*Ledger, error) {
+	ledger, err := NewLedger(currency, method, price)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := c.AccountGetOrderHistory("")
+	if err != nil {
+		return nil, err
+	}
+
+	deposits, err := c.AccountGetDepositHistory("")
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawals, err := c.AccountGetWithdrawalHistory("")
+	if err != nil {
+		return nil, err
+	}
+
+	ledger.AddOrders(orders)
+	ledger.AddDeposits(deposits)
+	ledger.AddWithdrawals(withdrawals)
+
+	return ledger, nil
+}
+
+//AddOrders add orders from AccountGetOrderHistory. Orders that filled nothing are ignored.
+func (l *Ledger) AddOrders(orders []AccountOrderHistoryDescription) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i := range orders {
+		order := orders[i]
+		l.events["order "+order.OrderUUID] = ledgerEvent{time: time.Time(order.TimeStamp), rank: ledgerOrder, order: &order}
+	}
+	l.built = false
+}
+
+//AddDeposits add deposits from AccountGetDepositHistory.
+func (l *Ledger) AddDeposits(deposits []TransactionHistoryDescription) {
+	l.addTransfers(deposits, ledgerDeposit)
+}
+
+//AddWithdrawals add withdrawals from AccountGetWithdrawalHistory. Canceled ones are ignored.
+func (l *Ledger) AddWithdrawals(withdrawals []TransactionHistoryDescription) {
+	l.addTransfers(withdrawals, ledgerWithdrawal)
+}
+
+func (l *Ledger) addTransfers(transfers []TransactionHistoryDescription, rank int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i := range transfers {
+		transfer := transfers[i]
+		l.events[transferKey(transfer, rank)] = ledgerEvent{time: time.Time(transfer.Opened), rank: rank, transfer: &transfer}
+	}
+	l.built = false
+}
+
+//transferKey identify a transfer by its PaymentUUID, else its TxID, else its currency, amount and time, so
+//transfers added twice are counted once and those missing a PaymentUUID are not merged.
+func transferKey(transfer TransactionHistoryDescription, rank int) string {
+	switch {
+	case transfer.PaymentUUID != "":
+		return fmt.Sprintf("transfer %d %s", rank, transfer.PaymentUUID)
+	case transfer.TxID != "":
+		return fmt.Sprintf("transfer %d tx %s", rank, transfer.TxID)
+	}
+
+	amount := ""
+	if transfer.Amount != nil {
+		amount = transfer.Amount.Text('g', -1)
+	}
+
+	return fmt.Sprintf("transfer %d %s %s %s", rank, transfer.Currency, amount, time.Time(transfer.Opened).Format(time.RFC3339Nano))
+}
+
+//build replay every event from scratch. Callers hold mutex.
+func (l *Ledger) build() error {
+	if l.built {
+		return nil
+	}
+
+	keys := make([]string, 0, len(l.events))
+	for key := range l.events {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := l.events[keys[i]], l.events[keys[j]]
+		if !a.time.Equal(b.time) {
+			return a.time.Before(b.time)
+		}
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		return keys[i] < keys[j]
+	})
+
+	l.lots = make(map[string][]*taxLot)
+	l.disposals, l.fees = nil, nil
+
+	for _, key := range keys {
+		event := l.events[key]
+
+		var err error
+		switch {
+		case event.order != nil:
+			err = l.replayOrder(*event.order)
+		case event.rank == ledgerDeposit:
+			err = l.replayDeposit(*event.transfer)
+		default:
+			err = l.replayWithdrawal(*event.transfer)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	l.built = true
+	return nil
+}
+
+//value of one unit of currency at at, in the ledger's currency.
+func (l *Ledger) value(currency string, at time.Time) (*big.Rat, error) {
+	if currency == l.Currency {
+		return big.NewRat(1, 1), nil
+	}
+
+	if l.Price == nil {
+		return nil, LedgerPriceError{currency, at}
+	}
+
+	price, err := l.Price(currency, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return decimal(price), nil
+}
+
+func (l *Ledger) replayOrder(order AccountOrderHistoryDescription) error {
+	quantity := new(big.Rat).Sub(decimal(order.Quantity), decimal(order.QuantityRemaining))
+	if quantity.Sign() <= 0 {
+		return nil
+	}
+
+	market, err := ParseMarket(order.Exchange)
+	if err != nil {
+		return err
+	}
+
+	at := time.Time(order.TimeStamp)
+	price, err := l.value(market.Base(), at)
+	if err != nil {
+		return err
+	}
+
+	total, commission := decimal(order.Price), decimal(order.Commission)
+	fee := new(big.Rat).Mul(commission, price)
+
+	if commission.Sign() > 0 {
+		l.fees = append(l.fees, LedgerFee{at, market.Base(), ratAmount(commission), ratAmount(fee), order.OrderUUID})
+	}
+
+	if order.OrderType.Side() == OrderSideBuy {
+		spent := new(big.Rat).Add(total, commission)
+		cost := new(big.Rat).Mul(spent, price)
+
+		l.dispose(market.Base(), spent, cost, new(big.Rat), at, order.OrderUUID)
+		l.acquire(market.Quote(), quantity, cost, at)
+		return nil
+	}
+
+	received := new(big.Rat).Sub(total, commission)
+	proceeds := new(big.Rat).Mul(received, price)
+
+	l.dispose(market.Quote(), quantity, proceeds, fee, at, order.OrderUUID)
+	l.acquire(market.Base(), received, proceeds, at)
+	return nil
+}
+
+func (l *Ledger) replayDeposit(deposit TransactionHistoryDescription) error {
+	if deposit.Canceled || deposit.Currency == l.Currency {
+		return nil
+	}
+
+	at := time.Time(deposit.Opened)
+	price, err := l.value(deposit.Currency, at)
+	if err != nil {
+		return err
+	}
+
+	amount := decimal(deposit.Amount)
+	l.acquire(deposit.Currency, amount, new(big.Rat).Mul(amount, price), at)
+	return nil
+}
+
+func (l *Ledger) replayWithdrawal(withdrawal TransactionHistoryDescription) error {
+	if withdrawal.Canceled || withdrawal.InvalidAddress {
+		return nil
+	}
+
+	at := time.Time(withdrawal.Opened)
+
+	if txCost := decimal(withdrawal.TxCost); txCost.Sign() > 0 {
+		price, err := l.value(withdrawal.Currency, at)
+		if err != nil {
+			return err
+		}
+
+		value := new(big.Rat).Mul(txCost, price)
+		l.fees = append(l.fees, LedgerFee{at, withdrawal.Currency, ratAmount(txCost), ratAmount(value), withdrawal.PaymentUUID})
+	}
+
+	if withdrawal.Currency != l.Currency {
+		l.take(withdrawal.Currency, decimal(withdrawal.Amount))
+	}
+	return nil
+}
+
+//acquire add a lot. Callers hold mutex.
+func (l *Ledger) acquire(currency string, quantity, cost *big.Rat, at time.Time) {
+	if currency == l.Currency || quantity.Sign() <= 0 {
+		return
+	}
+
+	lots := l.lots[currency]
+
+	if l.Method == LotAverageCost && len(lots) > 0 {
+		lots[0].quantity.Add(lots[0].quantity, quantity)
+		lots[0].cost.Add(lots[0].cost, cost)
+		return
+	}
+
+	l.lots[currency] = append(lots, &taxLot{new(big.Rat).Set(quantity), new(big.Rat).Set(cost), at})
+}
+
+//take remove quantity of currency from its lots in Method's order, returning the parts taken and what no lot
+//covered. Callers hold mutex.
+func (l *Ledger) take(currency string, quantity *big.Rat) ([]taxLot, *big.Rat) {
+	left := new(big.Rat).Set(quantity)
+	lots := l.lots[currency]
+
+	var taken []taxLot
+	for left.Sign() > 0 && len(lots) > 0 {
+		i := 0
+		if l.Method == LotLIFO {
+			i = len(lots) - 1
+		}
+		lot := lots[i]
+
+		part := taxLot{quantity: new(big.Rat).Set(lot.quantity), cost: new(big.Rat).Set(lot.cost), acquired: lot.acquired}
+		if l.Method == LotAverageCost {
+			part.acquired = time.Time{}
+		}
+
+		if lot.quantity.Cmp(left) > 0 {
+			part.quantity.Set(left)
+			part.cost.Mul(lot.cost, new(big.Rat).Quo(left, lot.quantity))
+
+			lot.quantity.Sub(lot.quantity, left)
+			lot.cost.Sub(lot.cost, part.cost)
+		} else {
+			lots = append(lots[:i], lots[i+1:]...)
+		}
+
+		left.Sub(left, part.quantity)
+		taken = append(taken, part)
+	}
+
+	l.lots[currency] = lots
+	return taken, left
+}
+
+//dispose record quantity of currency leaving for proceeds, net of fee. Callers hold mutex.
+func (l *Ledger) dispose(currency string, quantity, proceeds, fee *big.Rat, at time.Time, source string) {
+	if currency == l.Currency {
+		return
+	}
+
+	taken, unmatched := l.take(currency, quantity)
+	if unmatched.Sign() > 0 {
+		taken = append(taken, taxLot{quantity: unmatched, cost: new(big.Rat)})
+	}
+
+	for i, part := range taken {
+		share := new(big.Rat).Quo(part.quantity, quantity)
+		partProceeds := new(big.Rat).Mul(proceeds, share)
+
+		l.disposals = append(l.disposals, Disposal{
+			Currency:  currency,
+			Quantity:  ratAmount(part.quantity),
+			Acquired:  part.acquired,
+			Disposed:  at,
+			Proceeds:  ratAmount(partProceeds),
+			Cost:      ratAmount(part.cost),
+			Gain:      ratAmount(new(big.Rat).Sub(partProceeds, part.cost)),
+			Fee:       ratAmount(new(big.Rat).Mul(fee, share)),
+			Source:    source,
+			Unmatched: unmatched.Sign() > 0 && i == len(taken)-1,
+		})
+	}
+}
+
+//TaxYear the tax year t falls in.
+func (l *Ledger) TaxYear(t time.Time) int {
+	start := l.TaxYearStart
+	if start == 0 {
+		start = time.January
+	}
+
+	t = t.UTC()
+	if t.Month() < start {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+//Disposals every disposal, oldest first. year > 0 keeps those of that tax year only.
+func (l *Ledger) Disposals(year int) ([]Disposal, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.build(); err != nil {
+		return nil, err
+	}
+
+	var disposals []Disposal
+	for _, disposal := range l.disposals {
+		if year <= 0 || l.TaxYear(disposal.Disposed) == year {
+			disposals = append(disposals, disposal)
+		}
+	}
+
+	return disposals, nil
+}
+
+//Realized total gain of the disposals of a tax year (every year when year <= 0).
+func (l *Ledger) Realized(year int) (*big.Float, error) {
+	disposals, err := l.Disposals(year)
+	if err != nil {
+		return nil, err
+	}
+
+	total := new(big.Rat)
+	for _, disposal := range disposals {
+		total.Add(total, decimal(disposal.Gain))
+	}
+
+	return ratAmount(total), nil
+}
+
+//Fees every commission and withdrawal fee, oldest first. year > 0 keeps those of that tax year only.
+func (l *Ledger) Fees(year int) ([]LedgerFee, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.build(); err != nil {
+		return nil, err
+	}
+
+	var fees []LedgerFee
+	for _, fee := range l.fees {
+		if year <= 0 || l.TaxYear(fee.Time) == year {
+			fees = append(fees, fee)
+		}
+	}
+
+	return fees, nil
+}
+
+//Positions what the lots hold now, by currency, with unrealized P&L against prices (value of one unit in the
+//ledger's currency, ex: AssetValuation.Price) where given.
+func (l *Ledger) Positions(prices map[string]*big.Float) ([]Position, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.build(); err != nil {
+		return nil, err
+	}
+
+	currencies := make([]string, 0, len(l.lots))
+	for currency, lots := range l.lots {
+		if len(lots) > 0 {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+
+	positions := make([]Position, 0, len(currencies))
+	for _, currency := range currencies {
+		quantity, cost := new(big.Rat), new(big.Rat)
+		for _, lot := range l.lots[currency] {
+			quantity.Add(quantity, lot.quantity)
+			cost.Add(cost, lot.cost)
+		}
+
+		position := Position{Currency: currency, Quantity: ratAmount(quantity), Cost: ratAmount(cost)}
+
+		if price, ok := prices[currency]; ok && price != nil {
+			value := new(big.Rat).Mul(quantity, decimal(price))
+
+			position.Price = new(big.Float).Set(price)
+			position.Value = ratAmount(value)
+			position.Unrealized = ratAmount(value.Sub(value, cost))
+		}
+
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+//WriteCSV write the disposals of a tax year to w, one row per lot with a header row. Amounts are in the ledger's
+//currency; Acquired is VARIOUS with LotAverageCost and UNKNOWN for unmatched quantity.
+func (l *Ledger) WriteCSV(w io.Writer, year int) error {
+	disposals, err := l.Disposals(year)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"Currency", "Quantity", "Acquired", "Disposed", "Proceeds", "Cost", "Gain", "Fee", "Source"})
+
+	for _, disposal := range disposals {
+		acquired := disposal.Acquired.UTC().Format(time.RFC3339)
+		switch {
+		case disposal.Unmatched:
+			acquired = "UNKNOWN"
+		case disposal.Acquired.IsZero():
+			acquired = "VARIOUS"
+		}
+
+		writer.Write([]string{
+			disposal.Currency,
+			disposal.Quantity.Text('f', -1),
+			acquired,
+			disposal.Disposed.UTC().Format(time.RFC3339),
+			disposal.Proceeds.Text('f', -1),
+			disposal.Cost.Text('f', -1),
+			disposal.Gain.Text('f', -1),
+			disposal.Fee.Text('f', -1),
+			disposal.Source,
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,228 @@
+package bittrex
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ledgerDate(year int, month time.Month, day int) BittrexTimestamp {
+	return BittrexTimestamp(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+func historyOrder(uuid, market string, orderType OrderType, at BittrexTimestamp, quantity, total, commission string) AccountOrderHistoryDescription {
+	return AccountOrderHistoryDescription{
+		OrderUUID:         uuid,
+		Exchange:          market,
+		TimeStamp:         at,
+		OrderType:         orderType,
+		Quantity:          dec(quantity),
+		QuantityRemaining: dec("0"),
+		Price:             dec(total),
+		Commission:        dec(commission),
+	}
+}
+
+//testLedger BTC deposited at 10000, LTC bought twice and partly sold, ETH bought with BTC at 20000, BTC withdrawn.
+func testLedger(t *testing.T, method LotMethod) *Ledger {
+	price := func(currency string, at time.Time) (*big.Float, error) {
+		if at.Year() < 2019 {
+			return dec("10000"), nil
+		}
+		return dec("20000"), nil
+	}
+
+	ledger, err := NewLedger("USDT", method, price)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//added out of order and overlapping
+	ledger.AddOrders([]AccountOrderHistoryDescription{
+		historyOrder("sell-ltc", "USDT-LTC", OrderTypeLimitSell, ledgerDate(2019, 2, 1), "15", "2250", "5.625"),
+		historyOrder("buy-eth", "BTC-ETH", OrderTypeLimitBuy, ledgerDate(2019, 6, 1), "2", "0.1", "0.00025"),
+	})
+	ledger.AddOrders([]AccountOrderHistoryDescription{
+		historyOrder("buy-ltc-1", "USDT-LTC", OrderTypeLimitBuy, ledgerDate(2018, 2, 1), "10", "1000", "2.5"),
+		historyOrder("buy-ltc-2", "USDT-LTC", OrderTypeLimitBuy, ledgerDate(2018, 3, 1), "10", "2000", "5"),
+		historyOrder("sell-ltc", "USDT-LTC", OrderTypeLimitSell, ledgerDate(2019, 2, 1), "15", "2250", "5.625"),
+	})
+	ledger.AddDeposits([]TransactionHistoryDescription{
+		{PaymentUUID: "deposit", Currency: "BTC", Amount: dec("1"), Opened: ledgerDate(2018, 1, 5)},
+	})
+	ledger.AddWithdrawals([]TransactionHistoryDescription{
+		{PaymentUUID: "withdrawal", Currency: "BTC", Amount: dec("0.5"), TxCost: dec("0.0005"), Opened: ledgerDate(2019, 7, 1)},
+		{PaymentUUID: "canceled", Currency: "BTC", Amount: dec("0.3"), Opened: ledgerDate(2019, 7, 2), Canceled: true},
+	})
+
+	return ledger
+}
+
+func TestLedgerLotMethods(t *testing.T) {
+	for method, want := range map[LotMethod]struct {
+		gains    []string
+		realized string
+		ltcCost  string
+	}{
+		LotFIFO:        {[]string{"493.75", "-254.375", "1002.5"}, "1241.875", "1002.5"},
+		LotLIFO:        {[]string{"-508.75", "246.875", "1002.5"}, "740.625", "501.25"},
+		LotAverageCost: {[]string{"-11.25", "1002.5"}, "991.25", "751.875"},
+	} {
+		ledger := testLedger(t, method)
+
+		disposals, err := ledger.Disposals(2019)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(disposals) != len(want.gains) {
+			t.Fatalf("%s: expected %d disposals, got %+v", method, len(want.gains), disposals)
+		}
+
+		for i, gain := range want.gains {
+			expectDecimal(t, string(method)+" gain", disposals[i].Gain, gain)
+		}
+
+		realized, err := ledger.Realized(2019)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectDecimal(t, string(method)+" realized", realized, want.realized)
+
+		positions, err := ledger.Positions(map[string]*big.Float{"BTC": dec("30000"), "LTC": dec("200")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(positions) != 3 || positions[0].Currency != "BTC" || positions[1].Currency != "ETH" || positions[2].Currency != "LTC" {
+			t.Fatalf("%s: unexpected positions %+v", method, positions)
+		}
+
+		//1 BTC less 0.10025 spent on ETH and 0.5 withdrawn
+		expectDecimal(t, string(method)+" BTC held", positions[0].Quantity, "0.39975")
+		expectDecimal(t, string(method)+" BTC unrealized", positions[0].Unrealized, "7995")
+		expectDecimal(t, string(method)+" ETH cost", positions[1].Cost, "2005")
+		expectDecimal(t, string(method)+" LTC cost", positions[2].Cost, want.ltcCost)
+
+		if positions[1].Unrealized != nil {
+			t.Errorf("%s: expected no unrealized P&L without an ETH price", method)
+		}
+	}
+}
+
+func TestLedgerFees(t *testing.T) {
+	ledger := testLedger(t, LotFIFO)
+
+	fees, err := ledger.Fees(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ source, value string }{
+		{"buy-ltc-1", "2.5"},
+		{"buy-ltc-2", "5"},
+		{"sell-ltc", "5.625"},
+		{"buy-eth", "5"},
+		{"withdrawal", "10"},
+	}
+
+	if len(fees) != len(want) {
+		t.Fatalf("expected %d fees, got %+v", len(want), fees)
+	}
+
+	for i, w := range want {
+		if fees[i].Source != w.source {
+			t.Errorf("fee %d: expected %s, got %s", i, w.source, fees[i].Source)
+		}
+		expectDecimal(t, w.source+" fee", fees[i].Value, w.value)
+	}
+}
+
+func TestLedgerCSV(t *testing.T) {
+	ledger := testLedger(t, LotFIFO)
+
+	var out bytes.Buffer
+	if err := ledger.WriteCSV(&out, 2019); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"Currency,Quantity,Acquired,Disposed,Proceeds,Cost,Gain,Fee,Source",
+		"LTC,10,2018-02-01T00:00:00Z,2019-02-01T00:00:00Z,1496.25,1002.5,493.75,3.75,sell-ltc",
+		"LTC,5,2018-03-01T00:00:00Z,2019-02-01T00:00:00Z,748.125,1002.5,-254.375,1.875,sell-ltc",
+		"BTC,0.10025,2018-01-05T00:00:00Z,2019-06-01T00:00:00Z,2005,1002.5,1002.5,0,buy-eth",
+		"",
+	}, "\n")
+
+	if out.String() != want {
+		t.Errorf("unexpected CSV:\n%s\nwanted:\n%s", out.String(), want)
+	}
+
+	//a tax year starting in April puts February 2019 in 2018
+	ledger.TaxYearStart = time.April
+	if disposals, _ := ledger.Disposals(2018); len(disposals) != 2 {
+		t.Errorf("expected the LTC sale in tax year 2018, got %+v", disposals)
+	}
+}
+
+func TestLedgerUnmatchedAndMissingPrice(t *testing.T) {
+	ledger, err := NewLedger("USDT", LotFIFO, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ledger.AddOrders([]AccountOrderHistoryDescription{
+		historyOrder("sell", "USDT-LTC", OrderTypeLimitSell, ledgerDate(2019, 1, 1), "2", "200", "0"),
+	})
+
+	var out bytes.Buffer
+	if err := ledger.WriteCSV(&out, 2019); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "LTC,2,UNKNOWN,2019-01-01T00:00:00Z,200,0,200,0,sell") {
+		t.Errorf("expected an unmatched disposal at zero cost, got:\n%s", out.String())
+	}
+
+	ledger.AddDeposits([]TransactionHistoryDescription{{PaymentUUID: "deposit", Currency: "BTC", Amount: dec("1")}})
+	if _, err := ledger.Disposals(0); err == nil {
+		t.Error("expected a LedgerPriceError valuing BTC without a PriceFunc")
+	} else if _, ok := err.(LedgerPriceError); !ok {
+		t.Errorf("expected a LedgerPriceError, got %v", err)
+	}
+
+	if _, err := NewLedger("USDT", LotMethod("HIFO"), nil); err == nil {
+		t.Error("expected an unknown lot method to be rejected")
+	}
+}
+
+func TestLedgerTransfersWithoutUUID(t *testing.T) {
+	ledger, err := NewLedger("USDT", LotFIFO, func(currency string, at time.Time) (*big.Float, error) {
+		return dec("10000"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deposits := []TransactionHistoryDescription{
+		{Currency: "BTC", Amount: dec("1"), Opened: ledgerDate(2018, 1, 5)},
+		{Currency: "BTC", Amount: dec("2"), Opened: ledgerDate(2018, 1, 6)},
+		{Currency: "BTC", Amount: dec("0.5"), Opened: ledgerDate(2018, 1, 6), TxID: "tx"},
+	}
+
+	//added twice: still counted once each
+	ledger.AddDeposits(deposits)
+	ledger.AddDeposits(deposits)
+
+	positions, err := ledger.Positions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(positions) != 1 {
+		t.Fatalf("unexpected positions %+v", positions)
+	}
+	expectDecimal(t, "BTC held", positions[0].Quantity, "3.5")
+}
@@ -0,0 +1,216 @@
+package bittrex
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+//MarketError a market name that could not be parsed or is not listed.
+type MarketError struct {
+	Name   string
+	Reason string
+}
+
+func (e MarketError) Error() string {
+	return fmt.Sprintf("market %q: %s", e.Name, e.Reason)
+}
+
+//Market a market as the v1.1 api names it, "BASE-QUOTE": Base is the currency prices are quoted in (BTC in
+//BTC-LTC, MarketDescription.BaseCurrency) and Quote the currency traded (LTC, MarketDescription.MarketCurrency).
+//The zero value is no market.
+type Market struct {
+	base  string
+	quote string
+}
+
+//NewMarket market trading quote for base, ex: NewMarket("BTC", "LTC") for BTC-LTC.
+func NewMarket(base, quote string) (Market, error) {
+	m := Market{strings.ToUpper(strings.TrimSpace(base)), strings.ToUpper(strings.TrimSpace(quote))}
+	name := m.String()
+
+	if !validCurrencyCode(m.base) || !validCurrencyCode(m.quote) {
+		return Market{}, MarketError{name, "currency codes must be 1 to 10 letters or digits"}
+	}
+
+	if m.base == m.quote {
+		return Market{}, MarketError{name, "base and quote are the same currency"}
+	}
+
+	return m, nil
+}
+
+//ParseMarket parse the v1.1 "BASE-QUOTE" form, ex: "BTC-LTC". Case and surrounding spaces are ignored.
+func ParseMarket(name string) (Market, error) {
+	parts := strings.Split(name, "-")
+	if len(parts) != 2 {
+		return Market{}, MarketError{name, "expected BASE-QUOTE"}
+	}
+
+	return NewMarket(parts[0], parts[1])
+}
+
+//ParseMarketV3 parse the v3 api's reversed "QUOTE-BASE" form, ex: "LTC-BTC" for BTC-LTC.
+func ParseMarketV3(symbol string) (Market, error) {
+	parts := strings.Split(symbol, "-")
+	if len(parts) != 2 {
+		return Market{}, MarketError{symbol, "expected QUOTE-BASE"}
+	}
+
+	return NewMarket(parts[1], parts[0])
+}
+
+func validCurrencyCode(code string) bool {
+	if len(code) == 0 || len(code) > 10 {
+		return false
+	}
+
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+//Base currency prices are quoted in, ex: BTC in BTC-LTC.
+func (m Market) Base() string {
+	return m.base
+}
+
+//Quote currency being traded, ex: LTC in BTC-LTC.
+func (m Market) Quote() string {
+	return m.quote
+}
+
+//IsZero whether m is the zero Market.
+func (m Market) IsZero() bool {
+	return m.base == "" && m.quote == ""
+}
+
+//String the v1.1 name, ex: "BTC-LTC".
+func (m Market) String() string {
+	return m.base + "-" + m.quote
+}
+
+//V3 the v3 api symbol, ex: "LTC-BTC".
+func (m Market) V3() string {
+	return m.quote + "-" + m.base
+}
+
+//Market the Market described.
+func (d MarketDescription) Market() Market {
+	return Market{d.BaseCurrency, d.MarketCurrency}
+}
+
+//ValidateMarket check that m is listed in the client's Registry. Returns its description, or a MarketError
+//for markets Bittrex does not list.
+func (c *Client) ValidateMarket(m Market) (MarketDescription, error) {
+	if m.IsZero() {
+		return MarketDescription{}, MarketError{"", "no market"}
+	}
+
+	description, ok, err := c.Registry().Market(m)
+	if err != nil {
+		return MarketDescription{}, err
+	}
+
+	if !ok {
+		return MarketDescription{}, MarketError{m.String(), "not listed"}
+	}
+
+	return description, nil
+}
+
+//MarketClient the market methods of a Client bound to one Market. Get one from Client.Market.
+type MarketClient struct {
+	Market Market
+	client *Client
+}
+
+//Market the client's methods taking a market name, for m.
+func (c *Client) Market(m Market) MarketClient {
+	return MarketClient{m, c}
+}
+
+//Validate see Client.ValidateMarket.
+func (m MarketClient) Validate() (MarketDescription, error) {
+	return m.client.ValidateMarket(m.Market)
+}
+
+//PublicGetTicker see Client.PublicGetTicker.
+func (m MarketClient) PublicGetTicker() (Ticker, error) {
+	return m.client.PublicGetTicker(m.Market.String())
+}
+
+//PublicGetMarketSummary see Client.PublicGetMarketSummary.
+func (m MarketClient) PublicGetMarketSummary() (MarketSummary, error) {
+	return m.client.PublicGetMarketSummary(m.Market.String())
+}
+
+//PublicGetOrderBook see Client.PublicGetOrderBook.
+func (m MarketClient) PublicGetOrderBook(orderType OrderBookSide) (OrderBook, error) {
+	return m.client.PublicGetOrderBook(m.Market.String(), orderType)
+}
+
+//PublicGetOrderBookDepth see Client.PublicGetOrderBookDepth.
+func (m MarketClient) PublicGetOrderBookDepth(orderType OrderBookSide, depth int) (OrderBook, error) {
+	return m.client.PublicGetOrderBookDepth(m.Market.String(), orderType, depth)
+}
+
+//PublicGetMarketHistory see Client.PublicGetMarketHistory.
+func (m MarketClient) PublicGetMarketHistory() ([]Trade, error) {
+	return m.client.PublicGetMarketHistory(m.Market.String())
+}
+
+//PubMarketGetTicks see Client.PubMarketGetTicks.
+func (m MarketClient) PubMarketGetTicks(interval string) ([]Candle, error) {
+	return m.client.PubMarketGetTicks(m.Market.String(), interval)
+}
+
+//PubMarketGetLatestTick see Client.PubMarketGetLatestTick.
+func (m MarketClient) PubMarketGetLatestTick(interval string) (Candle, error) {
+	return m.client.PubMarketGetLatestTick(m.Market.String(), interval)
+}
+
+//MarketBuyLimit see Client.MarketBuyLimit.
+func (m MarketClient) MarketBuyLimit(quantity *big.Float, rate *big.Float) (TransactionID, error) {
+	return m.client.MarketBuyLimit(m.Market.String(), quantity, rate)
+}
+
+//MarketSellLimit see Client.MarketSellLimit.
+func (m MarketClient) MarketSellLimit(quantity *big.Float, rate *big.Float) (TransactionID, error) {
+	return m.client.MarketSellLimit(m.Market.String(), quantity, rate)
+}
+
+//MarketOrder see Client.MarketOrder.
+func (m MarketClient) MarketOrder(side OrderSide, quantity *big.Float, options MarketOrderOptions) (MarketOrderReport, error) {
+	return m.client.MarketOrder(m.Market.String(), side, quantity, options)
+}
+
+//MarketGetOpenOrders see Client.MarketGetOpenOrders.
+func (m MarketClient) MarketGetOpenOrders() ([]OrderDescription, error) {
+	return m.client.MarketGetOpenOrders(m.Market.String())
+}
+
+//AccountGetOrderHistory see Client.AccountGetOrderHistory.
+func (m MarketClient) AccountGetOrderHistory() ([]AccountOrderHistoryDescription, error) {
+	return m.client.AccountGetOrderHistory(m.Market.String())
+}
+
+//WsSubExchangeUpdates see Client.WsSubExchangeUpdates.
+func (m MarketClient) WsSubExchangeUpdates() *BittrexSubscription {
+	return m.client.WsSubExchangeUpdates(m.Market.String())
+}
+
+//WsTradeStream see Client.WsTradeStream.
+func (m MarketClient) WsTradeStream() *TradeStream {
+	return m.client.WsTradeStream(m.Market.String())
+}
+
+//WsCandles see Client.WsCandles.
+func (m MarketClient) WsCandles(interval time.Duration) *CandleStream {
+	return m.client.WsCandles(m.Market.String(), interval)
+}
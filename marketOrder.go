@@ -0,0 +1,270 @@
+package bittrex
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+var (
+	//DefaultMaxSlippage how far past the best price MarketOrder may go when no MaxSlippage is given: 1%.
+	DefaultMaxSlippage = big.NewFloat(0.01)
+)
+
+const (
+	//DefaultMarketOrderSettle how long MarketOrder waits for its order to close when no Settle is given.
+	DefaultMarketOrderSettle = 5 * time.Second
+
+	marketOrderPoll = 500 * time.Millisecond
+)
+
+//MarketOrderOptions how MarketOrder prices and places its limit order.
+type MarketOrderOptions struct {
+	//MaxSlippage largest fraction the limit price may be past the best price (ex: 0.01 for 1%).
+	//Nil uses DefaultMaxSlippage.
+	MaxSlippage *big.Float
+
+	//AllowPartial place whatever the book holds within MaxSlippage when it can't fill the whole quantity,
+	//instead of returning a SlippageError.
+	AllowPartial bool
+
+	//ImmediateOrCancel place through the v2 api with TimeInEffectImmediateOrCancel, so nothing rests on the book.
+	ImmediateOrCancel bool
+
+	//Settle how long to wait for the order to close before reporting. 0 uses DefaultMarketOrderSettle.
+	Settle time.Duration
+
+	//CancelRemainder cancel whatever is still open after Settle, emulating immediate-or-cancel on the v1.1 api.
+	CancelRemainder bool
+}
+
+//SlippageError the book can't fill Requested on Side within Limit, MaxSlippage past the best price Best;
+//only Available can be.
+type SlippageError struct {
+	Side      OrderSide
+	Requested *big.Float
+	Available *big.Float
+	Best      *big.Float
+	Limit     *big.Float
+}
+
+func (e SlippageError) Error() string {
+	return fmt.Sprintf(
+		"%s of %s: only %s available between %s and %s",
+		e.Side,
+		e.Requested.Text('f', -1),
+		e.Available.Text('f', -1),
+		e.Best.Text('f', -1),
+		e.Limit.Text('f', -1),
+	)
+}
+
+//MarketOrderReport what a MarketOrder placed and how much of it filled. Best is the best price on the book and
+//Expected what the book promised when the order was priced. AveragePrice and Slippage (how much worse AveragePrice
+//is than Best, as a fraction of Best) are nil when nothing filled. Open is true when the order still rests on the book.
+type MarketOrderReport struct {
+	UUID      string
+	Side      OrderSide
+	Requested *big.Float
+	Best      *big.Float
+	Limit     *big.Float
+	Expected  BookFill
+
+	Filled       *big.Float
+	Remaining    *big.Float
+	AveragePrice *big.Float
+	Slippage     *big.Float
+	Open         bool
+	Order        AccountOrderDescription
+}
+
+//planMarketOrder the best price of book and the limit price that sweeps quantity within maxSlippage of it,
+//with the fill the book promises at that limit.
+func planMarketOrder(book OrderBook, side OrderSide, quantity *big.Float, maxSlippage *big.Float, allowPartial bool) (best *big.Float, limit *big.Float, fill BookFill, err error) {
+	bookSide, levels := OrderBookSell, book.asks()
+	if side == OrderSideSell {
+		bookSide, levels = OrderBookBuy, book.bids()
+	}
+
+	if len(levels) == 0 {
+		return nil, nil, BookFill{}, ErrOrderBookEmpty
+	}
+
+	bestRate := decimal(levels[0].Rate)
+
+	//buying may pay up to best * (1 + slippage), selling accepts down to best * (1 - slippage).
+	bound := new(big.Rat).Mul(bestRate, decimal(maxSlippage))
+	if side == OrderSideBuy {
+		bound.Add(bestRate, bound)
+	} else {
+		bound.Sub(bestRate, bound)
+	}
+
+	var within []OrderElement
+	for _, level := range levels {
+		rate := decimal(level.Rate)
+		if side == OrderSideBuy && rate.Cmp(bound) > 0 || side == OrderSideSell && rate.Cmp(bound) < 0 {
+			break
+		}
+		within = append(within, level)
+	}
+
+	best = ratAmount(bestRate)
+
+	fill, err = walkBook(bookSide, within, decimal(quantity), nil)
+	if depthErr, ok := err.(OrderBookDepthError); ok {
+		if !allowPartial {
+			return nil, nil, BookFill{}, SlippageError{side, depthErr.Requested, depthErr.Available, best, ratAmount(bound)}
+		}
+
+		fill, err = walkBook(bookSide, within, decimal(depthErr.Available), nil)
+	}
+
+	if err != nil {
+		return nil, nil, BookFill{}, err
+	}
+
+	return best, fill.WorstPrice, fill, nil
+}
+
+//marketOrderer the requests behind MarketOrder, client methods outside tests.
+type marketOrderer struct {
+	book   func(market string, side OrderBookSide) (OrderBook, error)
+	place  func(market string, side OrderSide, quantity, rate *big.Float, immediate bool) (string, error)
+	order  func(uuid string) (AccountOrderDescription, error)
+	cancel func(uuid string) error
+	sleep  func(time.Duration)
+}
+
+//MarketOrder buy or sell quantity on market now. Bittrex has no working market orders, so this prices a limit
+//order from the current order book to sweep quantity within options.MaxSlippage, places it, and waits up to
+//options.Settle for it to close. The report says how much filled and at what price; a placed order whose state
+//could not be read back is reported with its UUID alongside the error.
+func (c *Client) MarketOrder(market string, side OrderSide, quantity *big.Float, options MarketOrderOptions) (MarketOrderReport, error) {
+	return marketOrderer{
+		book: func(market string, side OrderBookSide) (OrderBook, error) {
+			return c.PublicGetOrderBook(market, side)
+		},
+		place: func(market string, side OrderSide, quantity, rate *big.Float, immediate bool) (string, error) {
+			var id TransactionID
+			var err error
+
+			switch {
+			case immediate && side == OrderSideBuy:
+				id, err = c.KeyMarketTradeBuy(market, quantity, rate, TimeInEffectImmediateOrCancel)
+			case immediate:
+				id, err = c.KeyMarketTradeSell(market, quantity, rate, TimeInEffectImmediateOrCancel)
+			case side == OrderSideBuy:
+				id, err = c.MarketBuyLimit(market, quantity, rate)
+			default:
+				id, err = c.MarketSellLimit(market, quantity, rate)
+			}
+
+			return id.UUID, err
+		},
+		order: c.AccountGetOrder,
+		cancel: func(uuid string) error {
+			_, err := c.MarketCancel(uuid)
+			return err
+		},
+		sleep: time.Sleep,
+	}.run(market, side, quantity, options)
+}
+
+func (m marketOrderer) run(market string, side OrderSide, quantity *big.Float, options MarketOrderOptions) (MarketOrderReport, error) {
+	if !side.Valid() {
+		return MarketOrderReport{}, EnumError{"order side", string(side)}
+	}
+
+	if quantity == nil || quantity.Sign() <= 0 {
+		return MarketOrderReport{}, OrderValueError{"quantity", quantity}
+	}
+
+	maxSlippage := options.MaxSlippage
+	if maxSlippage == nil {
+		maxSlippage = DefaultMaxSlippage
+	}
+
+	settle := options.Settle
+	if settle <= 0 {
+		settle = DefaultMarketOrderSettle
+	}
+
+	//buys take from the sell side of the book, sells from the buy side.
+	bookSide := OrderBookSell
+	if side == OrderSideSell {
+		bookSide = OrderBookBuy
+	}
+
+	book, err := m.book(market, bookSide)
+	if err != nil {
+		return MarketOrderReport{}, err
+	}
+
+	best, limit, expected, err := planMarketOrder(book, side, quantity, maxSlippage, options.AllowPartial)
+	if err != nil {
+		return MarketOrderReport{}, err
+	}
+
+	report := MarketOrderReport{
+		Side:      side,
+		Requested: new(big.Float).Set(quantity),
+		Best:      best,
+		Limit:     limit,
+		Expected:  expected,
+	}
+
+	if report.UUID, err = m.place(market, side, expected.Quantity, limit, options.ImmediateOrCancel); err != nil {
+		return report, err
+	}
+
+	state, err := m.settle(report.UUID, settle)
+	if err == nil && state.IsOpen && options.CancelRemainder {
+		if err = m.cancel(report.UUID); err == nil {
+			state, err = m.settle(report.UUID, settle)
+		}
+	}
+
+	if err != nil {
+		return report, err
+	}
+
+	report.fill(state)
+	return report, nil
+}
+
+//settle poll uuid until it closes or wait has passed, returning its last state.
+func (m marketOrderer) settle(uuid string, wait time.Duration) (AccountOrderDescription, error) {
+	for waited := time.Duration(0); ; waited += marketOrderPoll {
+		state, err := m.order(uuid)
+		if err == nil && !state.IsOpen || waited >= wait {
+			return state, err
+		}
+
+		m.sleep(marketOrderPoll)
+	}
+}
+
+func (r *MarketOrderReport) fill(state AccountOrderDescription) {
+	filled := new(big.Rat).Sub(decimal(state.Quantity), decimal(state.QuantityRemaining))
+
+	r.Order = state
+	r.Open = state.IsOpen
+	r.Filled = ratAmount(filled)
+	r.Remaining = ratAmount(new(big.Rat).Sub(decimal(r.Requested), filled))
+	r.AveragePrice = averagePrice(state, filled)
+
+	if r.AveragePrice == nil {
+		return
+	}
+
+	best := decimal(r.Best)
+
+	//buys slip as the price rises, sells as it falls.
+	slippage := new(big.Rat).Sub(decimal(r.AveragePrice), best)
+	if r.Side == OrderSideSell {
+		slippage.Neg(slippage)
+	}
+
+	r.Slippage = ratAmount(slippage.Quo(slippage, best))
+}
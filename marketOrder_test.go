@@ -0,0 +1,111 @@
+package bittrex
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestPlanMarketOrder(t *testing.T) {
+	book := testOrderBook()
+
+	best, limit, fill, err := planMarketOrder(book, OrderSideBuy, dec("2"), dec("0.01"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDecimal(t, "best ask", best, "0.0101")
+	expectDecimal(t, "buy limit", limit, "0.0102")
+	expectDecimal(t, "buy average", fill.AveragePrice, "0.01015")
+
+	//0.0105 is more than 1% past 0.0101
+	_, _, _, err = planMarketOrder(book, OrderSideBuy, dec("4"), dec("0.01"), false)
+	slippageErr, ok := err.(SlippageError)
+	if !ok {
+		t.Fatalf("expected SlippageError, got %v", err)
+	}
+	expectDecimal(t, "available", slippageErr.Available, "3")
+	expectDecimal(t, "bound", slippageErr.Limit, "0.010201")
+
+	_, limit, fill, err = planMarketOrder(book, OrderSideBuy, dec("4"), dec("0.01"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDecimal(t, "partial quantity", fill.Quantity, "3")
+	expectDecimal(t, "partial limit", limit, "0.0102")
+
+	//a wider bound reaches the last ask
+	if _, limit, _, err = planMarketOrder(book, OrderSideBuy, dec("4"), dec("0.05"), false); err != nil {
+		t.Fatal(err)
+	}
+	expectDecimal(t, "wide limit", limit, "0.0105")
+
+	best, limit, _, err = planMarketOrder(book, OrderSideSell, dec("4"), dec("0.01"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDecimal(t, "best bid", best, "0.01")
+	expectDecimal(t, "sell limit", limit, "0.0099")
+
+	if _, _, _, err = planMarketOrder(OrderBook{}, OrderSideSell, dec("1"), dec("0.01"), false); err != ErrOrderBookEmpty {
+		t.Errorf("expected ErrOrderBookEmpty, got %v", err)
+	}
+}
+
+func TestMarketOrderCancelsRemainder(t *testing.T) {
+	var placedQuantity, placedRate string
+	var immediate, cancelled bool
+	polls := 0
+
+	orderer := marketOrderer{
+		book: func(market string, side OrderBookSide) (OrderBook, error) {
+			if side != OrderBookSell {
+				t.Errorf("a buy should read the sell side, read %s", side)
+			}
+			return OrderBook{Sell: testOrderBook().Sell}, nil
+		},
+		place: func(market string, side OrderSide, quantity, rate *big.Float, ioc bool) (string, error) {
+			placedQuantity, placedRate, immediate = quantity.Text('f', -1), rate.Text('f', -1), ioc
+			return "order", nil
+		},
+		order: func(uuid string) (AccountOrderDescription, error) {
+			polls++
+			state := testOrderState("3", "2", "0.0101", !cancelled, cancelled)
+			return state, nil
+		},
+		cancel: func(uuid string) error {
+			cancelled = true
+			return nil
+		},
+		sleep: func(time.Duration) {},
+	}
+
+	report, err := orderer.run("BTC-LTC", OrderSideBuy, dec("4"), MarketOrderOptions{AllowPartial: true, Settle: time.Second, CancelRemainder: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if placedQuantity != "3" || placedRate != "0.0102" || immediate {
+		t.Errorf("placed %s at %s (immediate %v)", placedQuantity, placedRate, immediate)
+	}
+
+	if !cancelled || report.Open || polls != 4 {
+		t.Errorf("expected the open remainder cancelled after 3 polls, cancelled %v open %v polls %d", cancelled, report.Open, polls)
+	}
+
+	expectDecimal(t, "filled", report.Filled, "1")
+	expectDecimal(t, "remaining", report.Remaining, "3")
+	expectDecimal(t, "average", report.AveragePrice, "0.0101")
+	expectDecimal(t, "slippage", report.Slippage, "0")
+
+	if report.UUID != "order" || report.Order.OrderUUID != "order" {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if _, err := orderer.run("BTC-LTC", OrderSideBuy, dec("4"), MarketOrderOptions{}); err == nil {
+		t.Error("expected a SlippageError without AllowPartial")
+	}
+
+	if _, err := orderer.run("BTC-LTC", OrderSide("HOLD"), dec("1"), MarketOrderOptions{}); err == nil {
+		t.Error("expected an invalid side to fail")
+	}
+}
@@ -0,0 +1,75 @@
+package bittrex
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMarket(t *testing.T) {
+	m, err := ParseMarket(" btc-ltc ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Base() != "BTC" || m.Quote() != "LTC" || m.String() != "BTC-LTC" || m.V3() != "LTC-BTC" {
+		t.Errorf("unexpected market %s (%s)", m, m.V3())
+	}
+
+	v3, err := ParseMarketV3("LTC-BTC")
+	if err != nil || v3 != m {
+		t.Errorf("expected the v3 symbol to parse to %s, got %s (%v)", m, v3, err)
+	}
+
+	for _, bad := range []string{"BTCLTC", "BTC-LTC-ETH", "BTC-", "BTC-BTC", "BTC-L.TC", "BTC-ABCDEFGHIJK"} {
+		if _, err := ParseMarket(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		} else if _, ok := err.(MarketError); !ok {
+			t.Errorf("unexpected error type %T for %q", err, bad)
+		}
+	}
+
+	if description := (MarketDescription{BaseCurrency: "BTC", MarketCurrency: "LTC"}); description.Market() != m {
+		t.Errorf("unexpected market from description %s", description.Market())
+	}
+}
+
+func TestValidateMarket(t *testing.T) {
+	c := New("", "")
+
+	fetches := 0
+	c.Registry().fetchMarkets = func() ([]MarketDescription, error) {
+		fetches++
+		return []MarketDescription{{BaseCurrency: "BTC", MarketCurrency: "LTC", MarketName: "BTC-LTC", IsActive: true}}, nil
+	}
+	c.Registry().fetchCurrencies = func() ([]Currency, error) {
+		return nil, nil
+	}
+
+	ltc, _ := NewMarket("BTC", "LTC")
+	eth, _ := NewMarket("BTC", "ETH")
+
+	if description, err := c.Market(ltc).Validate(); err != nil || !description.IsActive {
+		t.Errorf("unexpected validation %+v, %v", description, err)
+	}
+
+	if _, err := c.ValidateMarket(eth); err == nil {
+		t.Errorf("expected BTC-ETH to be unlisted")
+	}
+
+	if _, err := c.ValidateMarket(Market{}); err == nil {
+		t.Errorf("expected the zero market to be rejected")
+	}
+
+	if fetches != 1 {
+		t.Errorf("expected markets to be fetched once, got %d", fetches)
+	}
+
+	failing := New("", "")
+	failing.Registry().fetchMarkets = func() ([]MarketDescription, error) {
+		return nil, errors.New("down")
+	}
+
+	if _, err := failing.ValidateMarket(ltc); err == nil || err.Error() != "down" {
+		t.Errorf("expected the fetch error, got %v", err)
+	}
+}
@@ -0,0 +1,412 @@
+package bittrex
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+//OrderBookSide type parameter of /public/getorderbook, also the side of the book an analytic walks.
+type OrderBookSide string
+
+const (
+	//OrderBookBuy buy orders (bids)
+	OrderBookBuy OrderBookSide = "buy"
+
+	//OrderBookSell sell orders (asks)
+	OrderBookSell OrderBookSide = "sell"
+
+	//OrderBookBoth both sides
+	OrderBookBoth OrderBookSide = "both"
+)
+
+//Valid whether s is one of the OrderBookSide consts.
+func (s OrderBookSide) Valid() bool {
+	return s == OrderBookBuy || s == OrderBookSell || s == OrderBookBoth
+}
+
+//ErrOrderBookEmpty a side of the order book has no orders.
+var ErrOrderBookEmpty = errors.New("order book side is empty")
+
+//OrderBookDepthError the book does not hold enough orders on Side (OrderBookBuy or OrderBookSell) to fill Requested;
+//only Available can be filled. Both are in the unit that was requested (quantity or base currency total).
+type OrderBookDepthError struct {
+	Side      OrderBookSide
+	Requested *big.Float
+	Available *big.Float
+}
+
+func (e OrderBookDepthError) Error() string {
+	return fmt.Sprintf("order book %s side can only fill %s of %s", e.Side, e.Available.Text('f', -1), e.Requested.Text('f', -1))
+}
+
+//DepthLevel one price level of the book with the quantity and base currency total of it and every better level.
+type DepthLevel struct {
+	Rate               *big.Float
+	Quantity           *big.Float
+	CumulativeQuantity *big.Float
+	CumulativeTotal    *big.Float
+}
+
+//BookFill what taking liquidity from the book would do: Quantity of the market currency for Total base currency
+//(before commission), at AveragePrice, reaching down to WorstPrice. Impact is how much worse AveragePrice is than
+//the best price, as a fraction of the best price.
+type BookFill struct {
+	Quantity     *big.Float
+	Total        *big.Float
+	AveragePrice *big.Float
+	WorstPrice   *big.Float
+	Impact       *big.Float
+}
+
+//decimal exact value of f's shortest decimal representation, so 0.1 read from the api is exactly 1/10.
+func decimal(f *big.Float) *big.Rat {
+	if f == nil {
+		return new(big.Rat)
+	}
+
+	r, _ := new(big.Rat).SetString(f.Text('g', -1))
+	return r
+}
+
+func ratAmount(r *big.Rat) *big.Float {
+	return newAmount().SetRat(r)
+}
+
+//bids buy orders, highest rate first.
+func (b OrderBook) bids() []OrderElement {
+	bids := append([]OrderElement(nil), b.Buy...)
+	sort.SliceStable(bids, func(i, j int) bool {
+		return bids[i].Rate.Cmp(bids[j].Rate) > 0
+	})
+	return bids
+}
+
+//asks sell orders, lowest rate first.
+func (b OrderBook) asks() []OrderElement {
+	asks := append([]OrderElement(nil), b.Sell...)
+	sort.SliceStable(asks, func(i, j int) bool {
+		return asks[i].Rate.Cmp(asks[j].Rate) < 0
+	})
+	return asks
+}
+
+//BestBid highest buy order.
+func (b OrderBook) BestBid() (OrderElement, bool) {
+	if bids := b.bids(); len(bids) > 0 {
+		return bids[0], true
+	}
+	return OrderElement{}, false
+}
+
+//BestAsk lowest sell order.
+func (b OrderBook) BestAsk() (OrderElement, bool) {
+	if asks := b.asks(); len(asks) > 0 {
+		return asks[0], true
+	}
+	return OrderElement{}, false
+}
+
+//Spread best ask - best bid.
+func (b OrderBook) Spread() (*big.Float, error) {
+	bid, bidOK := b.BestBid()
+	ask, askOK := b.BestAsk()
+
+	if !bidOK || !askOK {
+		return nil, ErrOrderBookEmpty
+	}
+
+	return ratAmount(new(big.Rat).Sub(decimal(ask.Rate), decimal(bid.Rate))), nil
+}
+
+//MidPrice halfway between the best bid and the best ask.
+func (b OrderBook) MidPrice() (*big.Float, error) {
+	bid, bidOK := b.BestBid()
+	ask, askOK := b.BestAsk()
+
+	if !bidOK || !askOK {
+		return nil, ErrOrderBookEmpty
+	}
+
+	mid := new(big.Rat).Add(decimal(ask.Rate), decimal(bid.Rate))
+	return ratAmount(mid.Quo(mid, big.NewRat(2, 1))), nil
+}
+
+//BuyDepth cumulative depth of the buy side, best (highest) rate first.
+func (b OrderBook) BuyDepth() []DepthLevel {
+	return depth(b.bids())
+}
+
+//SellDepth cumulative depth of the sell side, best (lowest) rate first.
+func (b OrderBook) SellDepth() []DepthLevel {
+	return depth(b.asks())
+}
+
+func depth(levels []OrderElement) []DepthLevel {
+	quantity, total := new(big.Rat), new(big.Rat)
+	result := make([]DepthLevel, len(levels))
+
+	for i, level := range levels {
+		rate, levelQuantity := decimal(level.Rate), decimal(level.Quantity)
+
+		quantity.Add(quantity, levelQuantity)
+		total.Add(total, new(big.Rat).Mul(rate, levelQuantity))
+
+		result[i] = DepthLevel{
+			Rate:               ratAmount(rate),
+			Quantity:           ratAmount(levelQuantity),
+			CumulativeQuantity: ratAmount(quantity),
+			CumulativeTotal:    ratAmount(total),
+		}
+	}
+
+	return result
+}
+
+//BuyImpact buying quantity at market against the sell side.
+func (b OrderBook) BuyImpact(quantity *big.Float) (BookFill, error) {
+	return walkBook(OrderBookSell, b.asks(), decimal(quantity), nil)
+}
+
+//SellImpact selling quantity at market against the buy side.
+func (b OrderBook) SellImpact(quantity *big.Float) (BookFill, error) {
+	return walkBook(OrderBookBuy, b.bids(), decimal(quantity), nil)
+}
+
+//BuyForTotal spending total base currency at market against the sell side.
+func (b OrderBook) BuyForTotal(total *big.Float) (BookFill, error) {
+	return walkBook(OrderBookSell, b.asks(), nil, decimal(total))
+}
+
+//SellForTotal selling at market against the buy side until total base currency is received.
+func (b OrderBook) SellForTotal(total *big.Float) (BookFill, error) {
+	return walkBook(OrderBookBuy, b.bids(), nil, decimal(total))
+}
+
+//walkBook take levels (best first) until wantQuantity or wantTotal, whichever is set, is reached.
+func walkBook(side OrderBookSide, levels []OrderElement, wantQuantity *big.Rat, wantTotal *big.Rat) (BookFill, error) {
+	if len(levels) == 0 {
+		return BookFill{}, ErrOrderBookEmpty
+	}
+
+	quantity, total := new(big.Rat), new(big.Rat)
+	var worst *big.Rat
+
+	for _, level := range levels {
+		if wantQuantity != nil && quantity.Cmp(wantQuantity) >= 0 || wantTotal != nil && total.Cmp(wantTotal) >= 0 {
+			break
+		}
+
+		rate, take := decimal(level.Rate), decimal(level.Quantity)
+
+		if wantQuantity != nil {
+			if remaining := new(big.Rat).Sub(wantQuantity, quantity); take.Cmp(remaining) > 0 {
+				take = remaining
+			}
+		} else {
+			remaining := new(big.Rat).Sub(wantTotal, total)
+			if levelTotal := new(big.Rat).Mul(rate, take); levelTotal.Cmp(remaining) > 0 {
+				take = remaining.Quo(remaining, rate)
+			}
+		}
+
+		quantity.Add(quantity, take)
+		total.Add(total, new(big.Rat).Mul(rate, take))
+		worst = rate
+	}
+
+	if wantQuantity != nil && quantity.Cmp(wantQuantity) < 0 {
+		return BookFill{}, OrderBookDepthError{side, ratAmount(wantQuantity), ratAmount(quantity)}
+	}
+
+	if wantTotal != nil && total.Cmp(wantTotal) < 0 {
+		return BookFill{}, OrderBookDepthError{side, ratAmount(wantTotal), ratAmount(total)}
+	}
+
+	if quantity.Sign() == 0 {
+		return BookFill{Quantity: newAmount(), Total: newAmount()}, nil
+	}
+
+	best := decimal(levels[0].Rate)
+	average := new(big.Rat).Quo(total, quantity)
+
+	//buying against asks gets worse as the price rises, selling against bids as it falls.
+	impact := new(big.Rat).Sub(average, best)
+	if side == OrderBookBuy {
+		impact.Neg(impact)
+	}
+	impact.Quo(impact, best)
+
+	return BookFill{
+		Quantity:     ratAmount(quantity),
+		Total:        ratAmount(total),
+		AveragePrice: ratAmount(average),
+		WorstPrice:   ratAmount(worst),
+		Impact:       ratAmount(impact),
+	}, nil
+}
+
+//OrderBookGapError an ExchangeState was missed: the LocalOrderBook expected Nounce Expected but got Got. The book
+//stays out of sync until the next Initial state, ex: from resubscribing or SubscriptionConfig's OverflowDropAndResync.
+type OrderBookGapError struct {
+	Market   string
+	Expected int
+	Got      int
+}
+
+func (e OrderBookGapError) Error() string {
+	return fmt.Sprintf("order book %s missed updates: expected nounce %d, got %d", e.Market, e.Expected, e.Got)
+}
+
+//LocalOrderBook order book for one market kept up to date from the ExchangeStates of a WsSubExchangeUpdates
+//subscription. It has the same analytics as OrderBook, computed on the current book. Safe for concurrent use.
+type LocalOrderBook struct {
+	Market string
+
+	mutex  sync.RWMutex
+	synced bool
+	nounce int
+	buys   map[string]OrderElement
+	sells  map[string]OrderElement
+}
+
+//NewLocalOrderBook empty book for market, waiting for an Initial state.
+func NewLocalOrderBook(market string) *LocalOrderBook {
+	return &LocalOrderBook{
+		Market: market,
+		buys:   make(map[string]OrderElement),
+		sells:  make(map[string]OrderElement),
+	}
+}
+
+//Apply an ExchangeState for the book's market. An Initial state replaces the book, a delta must carry the next
+//Nounce: older deltas are ignored and a gap returns an OrderBookGapError. States for other markets are ignored.
+func (b *LocalOrderBook) Apply(state ExchangeState) error {
+	if state.MarketName != b.Market {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if state.Initial {
+		b.buys = make(map[string]OrderElement)
+		b.sells = make(map[string]OrderElement)
+	} else if !b.synced || state.Nounce <= b.nounce {
+		return nil
+	} else if state.Nounce != b.nounce+1 {
+		b.synced = false
+		return OrderBookGapError{b.Market, b.nounce + 1, state.Nounce}
+	}
+
+	applyOrderUpdates(b.buys, state.Buys)
+	applyOrderUpdates(b.sells, state.Sells)
+
+	b.nounce = state.Nounce
+	b.synced = true
+
+	return nil
+}
+
+//applyOrderUpdates OrderUpdateRemove removes the rate, OrderUpdateAdd and OrderUpdateChange set its quantity.
+func applyOrderUpdates(levels map[string]OrderElement, updates []OrderUpdate) {
+	for _, update := range updates {
+		if update.Rate == nil {
+			continue
+		}
+
+		key := update.Rate.Text('g', -1)
+
+		if update.Type == OrderUpdateRemove || update.Quantity == nil || update.Quantity.Sign() == 0 {
+			delete(levels, key)
+			continue
+		}
+
+		levels[key] = OrderElement{
+			Quantity: new(big.Float).Set(update.Quantity),
+			Rate:     new(big.Float).Set(update.Rate),
+		}
+	}
+}
+
+//Synced whether the book has an Initial state and no gap since.
+func (b *LocalOrderBook) Synced() bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.synced
+}
+
+//Nounce of the last applied state.
+func (b *LocalOrderBook) Nounce() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.nounce
+}
+
+//OrderBook copy of the current book, buys highest rate first and sells lowest rate first.
+func (b *LocalOrderBook) OrderBook() OrderBook {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	book := OrderBook{
+		Buy:  make([]OrderElement, 0, len(b.buys)),
+		Sell: make([]OrderElement, 0, len(b.sells)),
+	}
+
+	for _, level := range b.buys {
+		book.Buy = append(book.Buy, OrderElement{new(big.Float).Set(level.Quantity), new(big.Float).Set(level.Rate)})
+	}
+
+	for _, level := range b.sells {
+		book.Sell = append(book.Sell, OrderElement{new(big.Float).Set(level.Quantity), new(big.Float).Set(level.Rate)})
+	}
+
+	book.Buy = book.bids()
+	book.Sell = book.asks()
+
+	return book
+}
+
+//Spread see OrderBook.Spread.
+func (b *LocalOrderBook) Spread() (*big.Float, error) {
+	return b.OrderBook().Spread()
+}
+
+//MidPrice see OrderBook.MidPrice.
+func (b *LocalOrderBook) MidPrice() (*big.Float, error) {
+	return b.OrderBook().MidPrice()
+}
+
+//BuyDepth see OrderBook.BuyDepth.
+func (b *LocalOrderBook) BuyDepth() []DepthLevel {
+	return b.OrderBook().BuyDepth()
+}
+
+//SellDepth see OrderBook.SellDepth.
+func (b *LocalOrderBook) SellDepth() []DepthLevel {
+	return b.OrderBook().SellDepth()
+}
+
+//BuyImpact see OrderBook.BuyImpact.
+func (b *LocalOrderBook) BuyImpact(quantity *big.Float) (BookFill, error) {
+	return b.OrderBook().BuyImpact(quantity)
+}
+
+//SellImpact see OrderBook.SellImpact.
+func (b *LocalOrderBook) SellImpact(quantity *big.Float) (BookFill, error) {
+	return b.OrderBook().SellImpact(quantity)
+}
+
+//BuyForTotal see OrderBook.BuyForTotal.
+func (b *LocalOrderBook) BuyForTotal(total *big.Float) (BookFill, error) {
+	return b.OrderBook().BuyForTotal(total)
+}
+
+//SellForTotal see OrderBook.SellForTotal.
+func (b *LocalOrderBook) SellForTotal(total *big.Float) (BookFill, error) {
+	return b.OrderBook().SellForTotal(total)
+}
@@ -0,0 +1,122 @@
+package bittrex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+//LadderLevel orders grouped into one price level of an OrderBookLadder.
+type LadderLevel struct {
+	Rate               *big.Float
+	Quantity           *big.Float
+	Total              *big.Float //base currency, Σ rate * quantity of the grouped orders
+	CumulativeQuantity *big.Float
+	Orders             int
+}
+
+//OrderBookLadder compact view of an order book with rates grouped by Tick, best level first on each side.
+type OrderBookLadder struct {
+	Tick *big.Float
+	Bids []LadderLevel
+	Asks []LadderLevel
+}
+
+//Ladder group the book into price levels tick apart (ex: 0.000001 BTC), keeping at most levels per side
+//(0 for all). Bids round down and asks round up to a multiple of tick, so a level never looks better than
+//the orders in it.
+func (b OrderBook) Ladder(tick *big.Float, levels int) (OrderBookLadder, error) {
+	if tick == nil || tick.Sign() <= 0 {
+		return OrderBookLadder{}, errors.New("ladder tick must be positive")
+	}
+
+	step := decimal(tick)
+
+	return OrderBookLadder{
+		Tick: new(big.Float).Set(tick),
+		Bids: ladderSide(b.bids(), step, false, levels),
+		Asks: ladderSide(b.asks(), step, true, levels),
+	}, nil
+}
+
+//Ladder see OrderBook.Ladder.
+func (b *LocalOrderBook) Ladder(tick *big.Float, levels int) (OrderBookLadder, error) {
+	return b.OrderBook().Ladder(tick, levels)
+}
+
+//ladderSide group orders (best first) into levels of step, rounding rates up or down.
+func ladderSide(orders []OrderElement, step *big.Rat, roundUp bool, limit int) []LadderLevel {
+	var ladder []LadderLevel
+	var rate, quantity, total, cumulative *big.Rat
+	count := 0
+
+	flush := func() {
+		if rate == nil {
+			return
+		}
+		ladder = append(ladder, LadderLevel{
+			Rate:               ratAmount(rate),
+			Quantity:           ratAmount(quantity),
+			Total:              ratAmount(total),
+			CumulativeQuantity: ratAmount(cumulative),
+			Orders:             count,
+		})
+	}
+
+	cumulative = new(big.Rat)
+
+	for _, order := range orders {
+		orderRate, orderQuantity := decimal(order.Rate), decimal(order.Quantity)
+		grouped := roundToStep(orderRate, step, roundUp)
+
+		if rate == nil || grouped.Cmp(rate) != 0 {
+			flush()
+			if limit > 0 && len(ladder) == limit {
+				return ladder
+			}
+			rate, quantity, total, count = grouped, new(big.Rat), new(big.Rat), 0
+		}
+
+		quantity.Add(quantity, orderQuantity)
+		total.Add(total, new(big.Rat).Mul(orderRate, orderQuantity))
+		cumulative.Add(cumulative, orderQuantity)
+		count++
+	}
+
+	flush()
+
+	return ladder
+}
+
+//roundToStep the multiple of step at or below (or above, with up) value. Rates are positive.
+func roundToStep(value, step *big.Rat, up bool) *big.Rat {
+	ratio := new(big.Rat).Quo(value, step)
+	steps, remainder := new(big.Int).QuoRem(ratio.Num(), ratio.Denom(), new(big.Int))
+
+	if up && remainder.Sign() != 0 {
+		steps.Add(steps, big.NewInt(1))
+	}
+
+	return new(big.Rat).Mul(new(big.Rat).SetInt(steps), step)
+}
+
+//String the ladder as text, asks from worst to best above bids from best to worst:
+//rate, quantity and cumulative quantity per line.
+func (l OrderBookLadder) String() string {
+	var out bytes.Buffer
+
+	line := func(side string, level LadderLevel) {
+		fmt.Fprintf(&out, "%-4s %18s %18s %18s\n", side, level.Rate.Text('f', -1), level.Quantity.Text('f', 8), level.CumulativeQuantity.Text('f', 8))
+	}
+
+	for i := len(l.Asks) - 1; i >= 0; i-- {
+		line("ask", l.Asks[i])
+	}
+
+	for _, level := range l.Bids {
+		line("bid", level)
+	}
+
+	return out.String()
+}
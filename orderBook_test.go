@@ -0,0 +1,186 @@
+package bittrex
+
+import (
+	"math/big"
+	"testing"
+)
+
+//dec parse like the api does.
+func dec(s string) *big.Float {
+	f, _ := new(big.Float).SetString(s)
+	return f
+}
+
+func level(quantity, rate string) OrderElement {
+	return OrderElement{Quantity: dec(quantity), Rate: dec(rate)}
+}
+
+func expectDecimal(t *testing.T, label string, got *big.Float, want string) {
+	if got == nil || got.Text('f', -1) != want {
+		t.Errorf("%s: got %v, wanted %s", label, got, want)
+	}
+}
+
+func testOrderBook() OrderBook {
+	return OrderBook{
+		Buy:  []OrderElement{level("1", "0.0099"), level("3", "0.0100")},
+		Sell: []OrderElement{level("2", "0.0102"), level("1", "0.0101"), level("5", "0.0105")},
+	}
+}
+
+func TestOrderBookAnalytics(t *testing.T) {
+	book := testOrderBook()
+
+	spread, err := book.Spread()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDecimal(t, "spread", spread, "0.0001")
+
+	mid, _ := book.MidPrice()
+	expectDecimal(t, "mid", mid, "0.01005")
+
+	depth := book.SellDepth()
+	if len(depth) != 3 {
+		t.Fatalf("expected 3 levels, got %d", len(depth))
+	}
+	expectDecimal(t, "best ask", depth[0].Rate, "0.0101")
+	expectDecimal(t, "cumulative quantity", depth[2].CumulativeQuantity, "8")
+	expectDecimal(t, "cumulative total", depth[2].CumulativeTotal, "0.083")
+	expectDecimal(t, "best bid", book.BuyDepth()[0].Rate, "0.01")
+
+	buy, err := book.BuyImpact(dec("2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDecimal(t, "buy total", buy.Total, "0.0203")
+	expectDecimal(t, "buy average", buy.AveragePrice, "0.01015")
+	expectDecimal(t, "buy worst", buy.WorstPrice, "0.0102")
+	if impact, _ := buy.Impact.Float64(); impact < 0.00495 || impact > 0.00496 {
+		t.Errorf("unexpected buy impact %v", impact)
+	}
+
+	spend, err := book.BuyForTotal(dec("0.0305"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDecimal(t, "spend quantity", spend.Quantity, "3")
+	expectDecimal(t, "spend worst", spend.WorstPrice, "0.0102")
+
+	sell, err := book.SellImpact(dec("3.5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDecimal(t, "sell total", sell.Total, "0.03495")
+	if impact := sell.Impact.Sign(); impact <= 0 {
+		t.Errorf("selling into lower bids should have a positive impact, got %v", sell.Impact)
+	}
+
+	receive, err := book.SellForTotal(dec("0.015"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDecimal(t, "receive quantity", receive.Quantity, "1.5")
+
+	if _, err := book.SellImpact(dec("10")); err == nil {
+		t.Errorf("expected a depth error")
+	} else if depthErr, ok := err.(OrderBookDepthError); !ok || depthErr.Side != OrderBookBuy {
+		t.Errorf("unexpected error %v", err)
+	} else {
+		expectDecimal(t, "available", depthErr.Available, "4")
+	}
+
+	if _, err := (OrderBook{Buy: book.Buy}).Spread(); err != ErrOrderBookEmpty {
+		t.Errorf("expected ErrOrderBookEmpty, got %v", err)
+	}
+}
+
+func TestLocalOrderBook(t *testing.T) {
+	book := NewLocalOrderBook("BTC-LTC")
+
+	update := func(updateType OrderUpdateType, quantity, rate string) OrderUpdate {
+		return OrderUpdate{OrderElement: level(quantity, rate), Type: updateType}
+	}
+
+	if err := book.Apply(ExchangeState{MarketName: "BTC-LTC", Nounce: 5, Initial: true,
+		Buys:  []OrderUpdate{update(0, "1", "0.0099"), update(0, "3", "0.0100")},
+		Sells: []OrderUpdate{update(0, "2", "0.0102"), update(0, "1", "0.0101")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	//stale and foreign states are ignored
+	book.Apply(ExchangeState{MarketName: "BTC-LTC", Nounce: 4, Sells: []OrderUpdate{update(1, "0", "0.0102")}})
+	book.Apply(ExchangeState{MarketName: "BTC-ETH", Nounce: 6, Sells: []OrderUpdate{update(1, "0", "0.0102")}})
+
+	if err := book.Apply(ExchangeState{MarketName: "BTC-LTC", Nounce: 6,
+		Buys:  []OrderUpdate{update(2, "2", "0.0100"), update(0, "1", "0.01005")},
+		Sells: []OrderUpdate{update(1, "0", "0.0101")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	spread, _ := book.Spread()
+	expectDecimal(t, "live spread", spread, "0.00015")
+
+	snapshot := book.OrderBook()
+	if len(snapshot.Buy) != 3 || len(snapshot.Sell) != 1 {
+		t.Fatalf("unexpected book %+v", snapshot)
+	}
+	expectDecimal(t, "updated bid", snapshot.Buy[1].Quantity, "2")
+
+	if err := book.Apply(ExchangeState{MarketName: "BTC-LTC", Nounce: 8}); err == nil {
+		t.Errorf("expected a gap error")
+	} else if gap, ok := err.(OrderBookGapError); !ok || gap.Expected != 7 {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if book.Synced() {
+		t.Errorf("book should be out of sync after a gap")
+	}
+
+	book.Apply(ExchangeState{MarketName: "BTC-LTC", Nounce: 20, Initial: true, Sells: []OrderUpdate{update(0, "1", "0.02")}})
+
+	if !book.Synced() || book.Nounce() != 20 {
+		t.Errorf("expected a resynced book at nounce 20")
+	}
+
+	if _, err := book.Spread(); err != ErrOrderBookEmpty {
+		t.Errorf("expected an empty buy side, got %v", err)
+	}
+}
+
+func TestOrderBookLadder(t *testing.T) {
+	book := OrderBook{
+		Buy:  []OrderElement{level("1", "0.00001005"), level("2", "0.00001009"), level("4", "0.00000998"), level("1", "0.0000097")},
+		Sell: []OrderElement{level("1", "0.00001011"), level("3", "0.00001019"), level("2", "0.0000102")},
+	}
+
+	ladder, err := book.Ladder(dec("0.0000001"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ladder.Bids) != 2 || len(ladder.Asks) != 1 {
+		t.Fatalf("unexpected ladder\n%s", ladder)
+	}
+
+	expectDecimal(t, "best bid level", ladder.Bids[0].Rate, "0.00001")
+	expectDecimal(t, "best bid quantity", ladder.Bids[0].Quantity, "3")
+	expectDecimal(t, "best bid total", ladder.Bids[0].Total, "0.00003023")
+	expectDecimal(t, "second bid level", ladder.Bids[1].Rate, "0.0000099")
+	expectDecimal(t, "second bid cumulative", ladder.Bids[1].CumulativeQuantity, "7")
+	expectDecimal(t, "ask level", ladder.Asks[0].Rate, "0.0000102")
+
+	if ladder.Asks[0].Orders != 3 {
+		t.Errorf("expected 3 orders at the ask level, got %d", ladder.Asks[0].Orders)
+	}
+
+	if _, err := book.Ladder(dec("0"), 0); err == nil {
+		t.Errorf("expected an invalid tick error")
+	}
+
+	if OrderBookSide("all").Valid() || !OrderBookBoth.Valid() {
+		t.Errorf("unexpected side validation")
+	}
+}
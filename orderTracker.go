@@ -0,0 +1,349 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+)
+
+//ErrTrackerClosed returned by OrderTracker.Wait when the tracker is closed before the order is done.
+var ErrTrackerClosed = errors.New("order tracker closed")
+
+//ErrOrderForgotten returned by OrderTracker.Wait when the order is forgotten before it is done.
+var ErrOrderForgotten = errors.New("order forgotten")
+
+//OrderEventType what happened to a tracked order.
+type OrderEventType int
+
+const (
+	//OrderPartiallyFilled more of the order filled, some is still open
+	OrderPartiallyFilled OrderEventType = iota
+
+	//OrderFilled the order filled completely. Terminal.
+	OrderFilled
+
+	//OrderCancelInitiated a cancel was requested, the order is still open
+	OrderCancelInitiated
+
+	//OrderCancelled the order closed before filling completely, Filled may be non zero. Terminal.
+	OrderCancelled
+
+	//OrderPollFailed polling the order failed, see Err. It will be polled again.
+	OrderPollFailed
+)
+
+func (t OrderEventType) String() string {
+	switch t {
+	case OrderPartiallyFilled:
+		return "OrderPartiallyFilled"
+	case OrderFilled:
+		return "OrderFilled"
+	case OrderCancelInitiated:
+		return "OrderCancelInitiated"
+	case OrderCancelled:
+		return "OrderCancelled"
+	case OrderPollFailed:
+		return "OrderPollFailed"
+	}
+	return "OrderEventType(?)"
+}
+
+//OrderEvent a change to a tracked order. Filled is the total quantity filled so far (Quantity - QuantityRemaining)
+//and NewlyFilled the part filled since the previous event. AveragePrice is PricePerUnit, or Price / Filled
+//while the api has not set it, and nil before anything filled.
+type OrderEvent struct {
+	Type         OrderEventType
+	UUID         string
+	Order        AccountOrderDescription
+	Filled       *big.Float
+	NewlyFilled  *big.Float
+	AveragePrice *big.Float
+	Err          error
+}
+
+type trackedOrder struct {
+	last   AccountOrderDescription
+	polled bool
+	filled *big.Rat
+	//fullyFilled OrderFilled was emitted while the api still reported the order open.
+	fullyFilled bool
+	done        bool
+	forgotten   bool
+	doneCh      chan struct{}
+}
+
+//OrderTracker follows placed orders by polling AccountGetOrder every Period until each is filled or cancelled,
+//reporting changes to its watchers. The v1.1 api has no private socket, so polling is the only source.
+//Stop it with Close.
+type OrderTracker struct {
+	//getOrder source of order states, AccountGetOrder outside tests.
+	getOrder func(uuid string) (AccountOrderDescription, error)
+
+	mutex  sync.Mutex
+	orders map[string]*trackedOrder
+
+	watchMutex sync.Mutex
+	watchers   map[int]func(OrderEvent)
+	nextWatch  int
+
+	wake      chan struct{}
+	closeOnce sync.Once
+	closing   chan struct{}
+	closed    chan struct{}
+}
+
+//NewOrderTracker start a tracker polling every period. Each poll costs one request per open tracked order.
+func (c *Client) NewOrderTracker(period time.Duration) *OrderTracker {
+	//the tracker polls from its own goroutine, so it gets its own session.
+	t := newOrderTracker(c.session().AccountGetOrder)
+
+	go t.run(period)
+
+	return t
+}
+
+func newOrderTracker(getOrder func(string) (AccountOrderDescription, error)) *OrderTracker {
+	return &OrderTracker{
+		getOrder: getOrder,
+		orders:   make(map[string]*trackedOrder),
+		watchers: make(map[int]func(OrderEvent)),
+		wake:     make(chan struct{}, 1),
+		closing:  make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+}
+
+//Track start following uuid (ex: the TransactionID.UUID of MarketBuyLimit). Tracking it again does nothing.
+//Finished orders are kept so a later Wait returns their final state; Forget them when no longer needed.
+func (t *OrderTracker) Track(uuid string) {
+	t.track(uuid)
+}
+
+func (t *OrderTracker) track(uuid string) *trackedOrder {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if order, ok := t.orders[uuid]; ok {
+		return order
+	}
+
+	order := &trackedOrder{filled: new(big.Rat), doneCh: make(chan struct{})}
+	t.orders[uuid] = order
+
+	//poll soon rather than a full period from now.
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+
+	return order
+}
+
+//Forget stop following uuid. Waits on it that are still pending return ErrOrderForgotten.
+func (t *OrderTracker) Forget(uuid string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	order, ok := t.orders[uuid]
+	if !ok {
+		return
+	}
+
+	delete(t.orders, uuid)
+
+	//once done, the poll closes doneCh itself.
+	if !order.done {
+		order.done, order.forgotten = true, true
+		close(order.doneCh)
+	}
+}
+
+//Watch call handler with every OrderEvent, from the polling goroutine. Call the returned func to stop.
+func (t *OrderTracker) Watch(handler func(OrderEvent)) (unwatch func()) {
+	t.watchMutex.Lock()
+	defer t.watchMutex.Unlock()
+
+	id := t.nextWatch
+	t.nextWatch++
+	t.watchers[id] = handler
+
+	return func() {
+		t.watchMutex.Lock()
+		defer t.watchMutex.Unlock()
+
+		delete(t.watchers, id)
+	}
+}
+
+func (t *OrderTracker) emit(event OrderEvent) {
+	t.watchMutex.Lock()
+	handlers := make([]func(OrderEvent), 0, len(t.watchers))
+	for _, handler := range t.watchers {
+		handlers = append(handlers, handler)
+	}
+	t.watchMutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+//Wait block until uuid is filled or cancelled, tracking it if needed. Returns its final state.
+func (t *OrderTracker) Wait(ctx context.Context, uuid string) (AccountOrderDescription, error) {
+	order := t.track(uuid)
+
+	select {
+	case <-order.doneCh:
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+
+		if order.forgotten {
+			return AccountOrderDescription{}, ErrOrderForgotten
+		}
+		return order.last, nil
+	case <-t.closing:
+		return AccountOrderDescription{}, ErrTrackerClosed
+	case <-ctx.Done():
+		return AccountOrderDescription{}, ctx.Err()
+	}
+}
+
+//Close stop polling. Pending Waits return ErrTrackerClosed.
+func (t *OrderTracker) Close(ctx context.Context) error {
+	t.closeOnce.Do(func() {
+		close(t.closing)
+	})
+
+	select {
+	case <-t.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *OrderTracker) run(period time.Duration) {
+	defer close(t.closed)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.closing:
+			return
+		case <-ticker.C:
+		case <-t.wake:
+		}
+
+		t.poll()
+	}
+}
+
+//poll every open tracked order once.
+func (t *OrderTracker) poll() {
+	t.mutex.Lock()
+	var open []string
+	for uuid, order := range t.orders {
+		if !order.done {
+			open = append(open, uuid)
+		}
+	}
+	t.mutex.Unlock()
+
+	for _, uuid := range open {
+		select {
+		case <-t.closing:
+			return
+		default:
+		}
+
+		state, err := t.getOrder(uuid)
+		if err != nil {
+			t.emit(OrderEvent{Type: OrderPollFailed, UUID: uuid, Err: err})
+			continue
+		}
+
+		events, finished := t.update(uuid, state)
+		for _, event := range events {
+			t.emit(event)
+		}
+
+		//Wait returns only once watchers have seen the final events.
+		if finished != nil {
+			close(finished)
+		}
+	}
+}
+
+//update record a polled state, returning the events it causes and, once the order is done, its channel to close.
+func (t *OrderTracker) update(uuid string, state AccountOrderDescription) ([]OrderEvent, chan struct{}) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	order, ok := t.orders[uuid]
+	if !ok || order.done {
+		return nil, nil
+	}
+
+	previous, wasPolled := order.last, order.polled
+	order.last, order.polled = state, true
+
+	filled := new(big.Rat).Sub(decimal(state.Quantity), decimal(state.QuantityRemaining))
+	newlyFilled := new(big.Rat).Sub(filled, order.filled)
+	order.filled = filled
+
+	event := func(eventType OrderEventType) OrderEvent {
+		return OrderEvent{
+			Type:         eventType,
+			UUID:         uuid,
+			Order:        state,
+			Filled:       ratAmount(filled),
+			NewlyFilled:  ratAmount(newlyFilled),
+			AveragePrice: averagePrice(state, filled),
+		}
+	}
+
+	var events []OrderEvent
+
+	if newlyFilled.Sign() > 0 {
+		if state.QuantityRemaining == nil || state.QuantityRemaining.Sign() == 0 {
+			events = append(events, event(OrderFilled))
+			order.fullyFilled = true
+		} else {
+			events = append(events, event(OrderPartiallyFilled))
+		}
+	}
+
+	if state.IsOpen && state.CancelInitiated && (!wasPolled || !previous.CancelInitiated) {
+		events = append(events, event(OrderCancelInitiated))
+	}
+
+	if !state.IsOpen {
+		//the order can close filled without a poll having seen the last fill separately.
+		if state.QuantityRemaining != nil && state.QuantityRemaining.Sign() > 0 {
+			events = append(events, event(OrderCancelled))
+		} else if newlyFilled.Sign() <= 0 && !order.fullyFilled {
+			events = append(events, event(OrderFilled))
+		}
+
+		order.done = true
+		return events, order.doneCh
+	}
+
+	return events, nil
+}
+
+func averagePrice(state AccountOrderDescription, filled *big.Rat) *big.Float {
+	if filled.Sign() <= 0 {
+		return nil
+	}
+
+	if state.PricePerUnit != nil && state.PricePerUnit.Sign() > 0 {
+		return new(big.Float).Set(state.PricePerUnit)
+	}
+
+	return ratAmount(new(big.Rat).Quo(decimal(state.Price), filled))
+}
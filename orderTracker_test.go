@@ -0,0 +1,175 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testOrderState(quantity, remaining, price string, open, cancelling bool) AccountOrderDescription {
+	return AccountOrderDescription{
+		OrderUUID:         "order",
+		Quantity:          dec(quantity),
+		QuantityRemaining: dec(remaining),
+		Price:             dec(price),
+		PricePerUnit:      dec("0"),
+		IsOpen:            open,
+		CancelInitiated:   cancelling,
+	}
+}
+
+func TestOrderTrackerEvents(t *testing.T) {
+	tracker := newOrderTracker(nil)
+
+	var events []OrderEvent
+	tracker.Watch(func(event OrderEvent) {
+		events = append(events, event)
+	})
+
+	tracker.Track("order")
+
+	for _, state := range []AccountOrderDescription{
+		testOrderState("10", "10", "0", true, false),
+		testOrderState("10", "6", "0.08", true, false),
+		testOrderState("10", "6", "0.08", true, true),
+		testOrderState("10", "5", "0.1", false, true),
+	} {
+		updates, _ := tracker.update("order", state)
+		for _, event := range updates {
+			tracker.emit(event)
+		}
+	}
+
+	expected := []OrderEventType{OrderPartiallyFilled, OrderCancelInitiated, OrderPartiallyFilled, OrderCancelled}
+	if len(events) != len(expected) {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	for i, event := range events {
+		if event.Type != expected[i] {
+			t.Errorf("event %d: got %s, wanted %s", i, event.Type, expected[i])
+		}
+	}
+
+	expectDecimal(t, "first fill", events[0].NewlyFilled, "4")
+	expectDecimal(t, "first average", events[0].AveragePrice, "0.02")
+	expectDecimal(t, "total filled", events[3].Filled, "5")
+	expectDecimal(t, "last fill", events[2].NewlyFilled, "1")
+
+	//done orders are not updated again
+	if updates, _ := tracker.update("order", testOrderState("10", "0", "0.2", false, false)); updates != nil {
+		t.Errorf("expected no events after the order closed, got %+v", updates)
+	}
+}
+
+func TestOrderTrackerFilledOnce(t *testing.T) {
+	tracker := newOrderTracker(nil)
+	tracker.Track("order")
+
+	var events []OrderEvent
+
+	//the api can report nothing remaining a poll before it closes the order
+	for _, state := range []AccountOrderDescription{
+		testOrderState("10", "0", "0.1", true, false),
+		testOrderState("10", "0", "0.1", false, false),
+	} {
+		updates, _ := tracker.update("order", state)
+		events = append(events, updates...)
+	}
+
+	if len(events) != 1 || events[0].Type != OrderFilled {
+		t.Fatalf("expected a single OrderFilled, got %+v", events)
+	}
+	expectDecimal(t, "filled", events[0].NewlyFilled, "10")
+}
+
+func TestOrderTrackerWait(t *testing.T) {
+	var mutex sync.Mutex
+	polls := 0
+
+	tracker := newOrderTracker(func(uuid string) (AccountOrderDescription, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		polls++
+		switch polls {
+		case 1:
+			return AccountOrderDescription{}, errors.New("timeout")
+		case 2:
+			return testOrderState("2", "2", "0", true, false), nil
+		default:
+			state := testOrderState("2", "0", "0.5", false, false)
+			state.PricePerUnit = dec("0.25")
+			return state, nil
+		}
+	})
+	go tracker.run(5 * time.Millisecond)
+
+	var mutexEvents sync.Mutex
+	var types []OrderEventType
+	tracker.Watch(func(event OrderEvent) {
+		mutexEvents.Lock()
+		defer mutexEvents.Unlock()
+		types = append(types, event.Type)
+
+		if event.Type == OrderFilled {
+			expectDecimal(t, "average from PricePerUnit", event.AveragePrice, "0.25")
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	final, err := tracker.Wait(ctx, "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if final.IsOpen || final.QuantityRemaining.Sign() != 0 {
+		t.Errorf("unexpected final state %+v", final)
+	}
+
+	mutexEvents.Lock()
+	if len(types) != 2 || types[0] != OrderPollFailed || types[1] != OrderFilled {
+		t.Errorf("unexpected events %v", types)
+	}
+	mutexEvents.Unlock()
+
+	//a finished order waits no more
+	if _, err := tracker.Wait(ctx, "order"); err != nil {
+		t.Errorf("unexpected error waiting again: %v", err)
+	}
+
+	if err := tracker.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tracker.Wait(ctx, "other"); err != ErrTrackerClosed {
+		t.Errorf("expected ErrTrackerClosed, got %v", err)
+	}
+}
+
+func TestOrderTrackerForgetReleasesWait(t *testing.T) {
+	tracker := newOrderTracker(func(uuid string) (AccountOrderDescription, error) {
+		return testOrderState("2", "2", "0", true, false), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	waited := make(chan error, 1)
+	go func() {
+		_, err := tracker.Wait(ctx, "order")
+		waited <- err
+	}()
+
+	//let Wait start tracking the order
+	time.Sleep(20 * time.Millisecond)
+	tracker.Forget("order")
+
+	if err := <-waited; err != ErrOrderForgotten {
+		t.Errorf("expected ErrOrderForgotten, got %v", err)
+	}
+}
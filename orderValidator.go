@@ -0,0 +1,202 @@
+package bittrex
+
+import (
+	"fmt"
+	"math/big"
+)
+
+//BittrexDecimals decimal places Bittrex accepts in quantities and rates.
+const BittrexDecimals = 8
+
+//OrderValueError a quantity or rate that is missing or not positive.
+type OrderValueError struct {
+	Field string
+	Value *big.Float
+}
+
+func (e OrderValueError) Error() string {
+	return fmt.Sprintf("order %s must be positive, got %v", e.Field, e.Value)
+}
+
+//OrderPrecisionError a quantity or rate with more than Decimals decimal places.
+type OrderPrecisionError struct {
+	Field    string
+	Value    *big.Float
+	Decimals int
+}
+
+func (e OrderPrecisionError) Error() string {
+	return fmt.Sprintf("order %s %s has more than %d decimals", e.Field, e.Value.Text('g', -1), e.Decimals)
+}
+
+//OrderMarketInactiveError the market is listed but not trading.
+type OrderMarketInactiveError struct {
+	Market string
+}
+
+func (e OrderMarketInactiveError) Error() string {
+	return fmt.Sprintf("market %s is not active", e.Market)
+}
+
+//OrderMinTradeSizeError the quantity is below the market's MinTradeSize, MIN_TRADE_REQUIREMENT_NOT_MET from the api.
+type OrderMinTradeSizeError struct {
+	Market       string
+	Quantity     *big.Float
+	MinTradeSize *big.Float
+}
+
+func (e OrderMinTradeSizeError) Error() string {
+	return fmt.Sprintf("quantity %s is below the %s minimum trade size %s", e.Quantity.Text('g', -1), e.Market, e.MinTradeSize.Text('g', -1))
+}
+
+//OrderDustError the order's value (quantity * rate) in the base currency is below the minimum,
+//DUST_TRADE_DISALLOWED_MIN_VALUE_50K_SAT from the api.
+type OrderDustError struct {
+	Market  string
+	Total   *big.Float
+	Minimum *big.Float
+}
+
+func (e OrderDustError) Error() string {
+	return fmt.Sprintf("order value %s is below the %s minimum %s", e.Total.Text('f', -1), e.Market, e.Minimum.Text('f', -1))
+}
+
+//OrderBalanceError the available balance of Currency does not cover the order.
+type OrderBalanceError struct {
+	Currency  string
+	Needed    *big.Float
+	Available *big.Float
+}
+
+func (e OrderBalanceError) Error() string {
+	return fmt.Sprintf("order needs %s %s, only %s available", e.Needed.Text('f', -1), e.Currency, e.Available.Text('f', -1))
+}
+
+//OrderValidator checks limit orders against the market rules before they are sent. The market comes from the
+//client's Registry. Enable it for MarketBuyLimit and MarketSellLimit with Client.SetOrderValidator.
+type OrderValidator struct {
+	//MinOrderValue smallest quantity * rate accepted, per base currency. Bases without an entry are not checked.
+	MinOrderValue map[string]*big.Float
+
+	//Decimals most decimal places accepted in quantity and rate.
+	Decimals int
+
+	//CheckBalance also check the available balance with AccountGetBalance, one extra request per order.
+	CheckBalance bool
+
+	//Commission added to the cost of buys when checking the balance.
+	Commission *big.Float
+
+	client *Client
+
+	//balance source of balances, AccountGetBalance outside tests.
+	balance func(currency string) (AccountBalance, error)
+}
+
+//NewOrderValidator validator with Bittrex's rules: 8 decimals, 50k satoshi minimum in BTC markets, and balance checks.
+func (c *Client) NewOrderValidator() *OrderValidator {
+	//sessions share the validator, so each balance lookup gets a session of its own.
+	base := c.session()
+
+	return &OrderValidator{
+		MinOrderValue: map[string]*big.Float{"BTC": big.NewFloat(0.0005)},
+		Decimals:      BittrexDecimals,
+		CheckBalance:  true,
+		Commission:    new(big.Float).Set(BittrexCommission),
+		client:        c,
+		balance: func(currency string) (AccountBalance, error) {
+			return base.session().AccountGetBalance(currency)
+		},
+	}
+}
+
+//SetOrderValidator validate every MarketBuyLimit and MarketSellLimit with v first. nil turns validation off.
+func (c *Client) SetOrderValidator(v *OrderValidator) {
+	c.validator = v
+}
+
+//validateOrder run the validator set with SetOrderValidator, if any.
+func (c *Client) validateOrder(market string, side OrderSide, quantity *big.Float, rate *big.Float) error {
+	if c.validator == nil {
+		return nil
+	}
+
+	return c.validator.Validate(market, side, quantity, rate)
+}
+
+//Validate a limit order of quantity at rate on market. Returns the first rule broken as one of the Order*Error
+//types, a MarketError for unlisted markets, or the error of a failed lookup.
+func (v *OrderValidator) Validate(market string, side OrderSide, quantity *big.Float, rate *big.Float) error {
+	if quantity == nil || quantity.Sign() <= 0 {
+		return OrderValueError{"quantity", quantity}
+	}
+
+	if rate == nil || rate.Sign() <= 0 {
+		return OrderValueError{"rate", rate}
+	}
+
+	if !hasDecimals(quantity, v.Decimals) {
+		return OrderPrecisionError{"quantity", quantity, v.Decimals}
+	}
+
+	if !hasDecimals(rate, v.Decimals) {
+		return OrderPrecisionError{"rate", rate, v.Decimals}
+	}
+
+	description, ok, err := v.client.Registry().MarketByName(market)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return MarketError{market, "not listed"}
+	}
+
+	if !description.IsActive {
+		return OrderMarketInactiveError{description.MarketName}
+	}
+
+	if description.MinTradeSize != nil && quantity.Cmp(description.MinTradeSize) < 0 {
+		return OrderMinTradeSizeError{description.MarketName, quantity, description.MinTradeSize}
+	}
+
+	total := ratAmount(new(big.Rat).Mul(decimal(quantity), decimal(rate)))
+
+	if minimum, ok := v.MinOrderValue[description.BaseCurrency]; ok && total.Cmp(minimum) < 0 {
+		return OrderDustError{description.MarketName, total, minimum}
+	}
+
+	if !v.CheckBalance {
+		return nil
+	}
+
+	currency, needed := description.MarketCurrency, quantity
+	if side == OrderSideBuy {
+		currency, needed = description.BaseCurrency, total
+		if v.Commission != nil {
+			needed = newAmount().Add(total, newAmount().Mul(total, v.Commission))
+		}
+	}
+
+	balance, err := v.balance(currency)
+	if err != nil {
+		return err
+	}
+
+	available := balance.Available
+	if available == nil {
+		available = newAmount()
+	}
+
+	if available.Cmp(needed) < 0 {
+		return OrderBalanceError{currency, needed, available}
+	}
+
+	return nil
+}
+
+//hasDecimals whether f has at most decimals decimal places.
+func hasDecimals(f *big.Float, decimals int) bool {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	return new(big.Rat).Mul(decimal(f), new(big.Rat).SetInt(scale)).IsInt()
+}
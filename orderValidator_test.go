@@ -0,0 +1,122 @@
+package bittrex
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func testValidatorClient() *Client {
+	c := New("", "")
+
+	c.Registry().fetchMarkets = func() ([]MarketDescription, error) {
+		return []MarketDescription{
+			{MarketName: "BTC-LTC", BaseCurrency: "BTC", MarketCurrency: "LTC", MinTradeSize: big.NewFloat(0.01), IsActive: true},
+			{MarketName: "BTC-DOGE", BaseCurrency: "BTC", MarketCurrency: "DOGE", MinTradeSize: big.NewFloat(1), IsActive: false},
+		}, nil
+	}
+	c.Registry().fetchCurrencies = func() ([]Currency, error) {
+		return nil, nil
+	}
+
+	return c
+}
+
+func TestOrderValidator(t *testing.T) {
+	c := testValidatorClient()
+	v := c.NewOrderValidator()
+
+	balances := map[string]*big.Float{"BTC": big.NewFloat(0.01), "LTC": big.NewFloat(0.5)}
+	v.balance = func(currency string) (AccountBalance, error) {
+		return AccountBalance{Currency: currency, Available: balances[currency]}, nil
+	}
+
+	cases := []struct {
+		label    string
+		market   string
+		side     OrderSide
+		quantity *big.Float
+		rate     *big.Float
+		check    func(error) bool
+	}{
+		{"valid buy", "BTC-LTC", OrderSideBuy, dec("0.5"), dec("0.0195"), func(err error) bool { return err == nil }},
+		{"valid sell", "BTC-LTC", OrderSideSell, dec("0.5"), dec("0.02"), func(err error) bool { return err == nil }},
+		{"zero quantity", "BTC-LTC", OrderSideBuy, dec("0"), dec("0.02"), func(err error) bool {
+			_, ok := err.(OrderValueError)
+			return ok
+		}},
+		{"rate precision", "BTC-LTC", OrderSideBuy, dec("0.5"), dec("0.000000001"), func(err error) bool {
+			e, ok := err.(OrderPrecisionError)
+			return ok && e.Field == "rate"
+		}},
+		{"unlisted", "BTC-XYZ", OrderSideBuy, dec("1"), dec("0.02"), func(err error) bool {
+			_, ok := err.(MarketError)
+			return ok
+		}},
+		{"inactive", "BTC-DOGE", OrderSideBuy, dec("1000"), dec("0.0000005"), func(err error) bool {
+			_, ok := err.(OrderMarketInactiveError)
+			return ok
+		}},
+		{"min trade size", "BTC-LTC", OrderSideBuy, dec("0.001"), dec("0.02"), func(err error) bool {
+			_, ok := err.(OrderMinTradeSizeError)
+			return ok
+		}},
+		{"dust", "BTC-LTC", OrderSideBuy, dec("0.02"), dec("0.02"), func(err error) bool {
+			e, ok := err.(OrderDustError)
+			return ok && e.Total.Text('f', -1) == "0.0004"
+		}},
+		{"buy balance with commission", "BTC-LTC", OrderSideBuy, dec("0.5"), dec("0.02"), func(err error) bool {
+			e, ok := err.(OrderBalanceError)
+			return ok && e.Currency == "BTC"
+		}},
+		{"sell balance", "BTC-LTC", OrderSideSell, dec("0.6"), dec("0.02"), func(err error) bool {
+			e, ok := err.(OrderBalanceError)
+			return ok && e.Currency == "LTC"
+		}},
+	}
+
+	for _, tc := range cases {
+		if err := v.Validate(tc.market, tc.side, tc.quantity, tc.rate); !tc.check(err) {
+			t.Errorf("%s: unexpected result %v", tc.label, err)
+		}
+	}
+
+	v.balance = func(string) (AccountBalance, error) {
+		return AccountBalance{}, errors.New("balance unavailable")
+	}
+
+	v.CheckBalance = false
+	if err := v.Validate("BTC-LTC", OrderSideBuy, dec("0.5"), dec("0.02")); err != nil {
+		t.Errorf("expected no balance check, got %v", err)
+	}
+}
+
+func TestMarketBuyLimitValidation(t *testing.T) {
+	c := testValidatorClient()
+	c.SetOrderValidator(c.NewOrderValidator())
+
+	//rejected before any request is sent
+	if _, err := c.MarketBuyLimit("BTC-LTC", dec("0.001"), dec("0.02")); err == nil {
+		t.Errorf("expected a validation error")
+	} else if _, ok := err.(OrderMinTradeSizeError); !ok {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if _, err := c.MarketSellLimit("BTC-DOGE", dec("10"), dec("0.02")); err == nil {
+		t.Errorf("expected a validation error")
+	} else if _, ok := err.(OrderMarketInactiveError); !ok {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSessionValidation(t *testing.T) {
+	c := testValidatorClient()
+	c.SetOrderValidator(c.NewOrderValidator())
+
+	//sessions, used from background goroutines, keep the client's validator
+	if _, err := c.session().MarketBuyLimit("BTC-LTC", dec("0.001"), dec("0.02")); err == nil {
+		t.Errorf("expected a validation error")
+	} else if _, ok := err.(OrderMinTradeSizeError); !ok {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package bittrex
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+//PaperBroker an ExecutionBroker that fills limit orders against observed trades instead of sending them to
+//Bittrex, for dry runs. Feed it trades with OnTrade (ex: from a TradeStream). A buy fills at its limit from trades
+//at or below it, up to each trade's quantity; sells mirror this above. Balances are per currency, reserved by open
+//orders (buys including commission) and charged Commission on the base currency. Safe for concurrent use.
+type PaperBroker struct {
+	Commission *big.Float
+
+	mutex    sync.Mutex
+	balances map[string]*big.Float
+	orders   map[string]*AccountOrderDescription
+	open     []string
+	last     map[string]*big.Float
+	nextID   int
+	now      func() time.Time
+}
+
+//NewPaperBroker paper account with the given starting balances, charging BittrexCommission.
+func NewPaperBroker(balances map[string]*big.Float) *PaperBroker {
+	b := &PaperBroker{
+		Commission: new(big.Float).Set(BittrexCommission),
+		balances:   make(map[string]*big.Float),
+		orders:     make(map[string]*AccountOrderDescription),
+		last:       make(map[string]*big.Float),
+		now:        time.Now,
+	}
+
+	for currency, amount := range balances {
+		b.balances[currency] = newAmount().Set(amount)
+	}
+
+	return b
+}
+
+func (b *PaperBroker) balance(currency string) *big.Float {
+	if _, ok := b.balances[currency]; !ok {
+		b.balances[currency] = newAmount()
+	}
+
+	return b.balances[currency]
+}
+
+//reserved amount of currency held by open orders.
+func (b *PaperBroker) reserved(currency string) *big.Float {
+	total := newAmount()
+
+	for _, uuid := range b.open {
+		order := b.orders[uuid]
+		market, _ := ParseMarket(order.Exchange)
+
+		if order.Type == OrderTypeLimitBuy && market.Base() == currency {
+			total.Add(total, b.buyCost(order.QuantityRemaining, order.Limit))
+		} else if order.Type == OrderTypeLimitSell && market.Quote() == currency {
+			total.Add(total, order.QuantityRemaining)
+		}
+	}
+
+	return total
+}
+
+func (b *PaperBroker) buyCost(quantity, rate *big.Float) *big.Float {
+	total := newAmount().Mul(quantity, rate)
+	return total.Add(total, newAmount().Mul(total, b.Commission))
+}
+
+func (b *PaperBroker) place(orderType OrderType, market string, quantity *big.Float, rate *big.Float) (string, error) {
+	location := "paper - " + orderType.String()
+
+	parsed, err := ParseMarket(market)
+	if err != nil {
+		return "", &bittrexError{location, "INVALID_MARKET"}
+	}
+
+	if quantity == nil || rate == nil || quantity.Sign() <= 0 || rate.Sign() <= 0 {
+		return "", &bittrexError{location, "INVALID_QUANTITY_OR_RATE"}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	currency, needed := parsed.Base(), b.buyCost(quantity, rate)
+	if orderType == OrderTypeLimitSell {
+		currency, needed = parsed.Quote(), quantity
+	}
+
+	available := newAmount().Sub(b.balance(currency), b.reserved(currency))
+	if available.Cmp(needed) < 0 {
+		return "", &bittrexError{location, "INSUFFICIENT_FUNDS"}
+	}
+
+	b.nextID++
+	uuid := fmt.Sprintf("paper-%d", b.nextID)
+
+	b.orders[uuid] = &AccountOrderDescription{
+		OrderUUID:         uuid,
+		Exchange:          parsed.String(),
+		Type:              orderType,
+		Quantity:          new(big.Float).Set(quantity),
+		QuantityRemaining: new(big.Float).Set(quantity),
+		Limit:             new(big.Float).Set(rate),
+		CommissionPaid:    newAmount(),
+		Price:             newAmount(),
+		Opened:            BittrexTimestamp(b.now()),
+		IsOpen:            true,
+	}
+	b.open = append(b.open, uuid)
+
+	return uuid, nil
+}
+
+//BuyLimit place a paper limit buy.
+func (b *PaperBroker) BuyLimit(market string, quantity *big.Float, rate *big.Float) (string, error) {
+	return b.place(OrderTypeLimitBuy, market, quantity, rate)
+}
+
+//SellLimit place a paper limit sell.
+func (b *PaperBroker) SellLimit(market string, quantity *big.Float, rate *big.Float) (string, error) {
+	return b.place(OrderTypeLimitSell, market, quantity, rate)
+}
+
+//Cancel an open paper order. Whatever filled stays filled.
+func (b *PaperBroker) Cancel(uuid string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	order, ok := b.orders[uuid]
+	if !ok || !order.IsOpen {
+		return &bittrexError{"paper - cancel", "ORDER_NOT_OPEN"}
+	}
+
+	order.CancelInitiated = true
+	b.close(order)
+
+	return nil
+}
+
+//close take order off the book. Callers hold mutex.
+func (b *PaperBroker) close(order *AccountOrderDescription) {
+	order.IsOpen = false
+	order.Closed = BittrexTimestamp(b.now())
+
+	for i, uuid := range b.open {
+		if uuid == order.OrderUUID {
+			b.open = append(b.open[:i], b.open[i+1:]...)
+			break
+		}
+	}
+}
+
+//OpenOrders open paper orders for market (every market when empty), oldest first.
+func (b *PaperBroker) OpenOrders(market string) ([]OrderDescription, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var orders []OrderDescription
+	for _, uuid := range b.open {
+		order := b.orders[uuid]
+		if market != "" && order.Exchange != market {
+			continue
+		}
+
+		orders = append(orders, OrderDescription{
+			OrderUUID:         order.OrderUUID,
+			Exchange:          order.Exchange,
+			OrderType:         order.Type,
+			Quantity:          new(big.Float).Set(order.Quantity),
+			QuantityRemaining: new(big.Float).Set(order.QuantityRemaining),
+			Limit:             new(big.Float).Set(order.Limit),
+			CommissionPaid:    new(big.Float).Set(order.CommissionPaid),
+			Price:             new(big.Float).Set(order.Price),
+			Opened:            order.Opened,
+		})
+	}
+
+	return orders, nil
+}
+
+//Balance of currency. Available excludes what open orders reserve.
+func (b *PaperBroker) Balance(currency string) (AccountBalance, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	balance := newAmount().Set(b.balance(currency))
+
+	return AccountBalance{
+		Currency:  currency,
+		Balance:   balance,
+		Available: newAmount().Sub(balance, b.reserved(currency)),
+		Pending:   newAmount(),
+	}, nil
+}
+
+//Order state of a paper order, open or closed.
+func (b *PaperBroker) Order(uuid string) (AccountOrderDescription, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	order, ok := b.orders[uuid]
+	if !ok {
+		return AccountOrderDescription{}, &bittrexError{"paper - order", "INVALID_ORDER"}
+	}
+
+	state := *order
+	state.Quantity = new(big.Float).Set(order.Quantity)
+	state.QuantityRemaining = new(big.Float).Set(order.QuantityRemaining)
+	state.Limit = new(big.Float).Set(order.Limit)
+	state.CommissionPaid = new(big.Float).Set(order.CommissionPaid)
+	state.Price = new(big.Float).Set(order.Price)
+	if order.PricePerUnit != nil {
+		state.PricePerUnit = new(big.Float).Set(order.PricePerUnit)
+	}
+
+	return state, nil
+}
+
+//Ticker the last traded price of market as Bid, Ask and Last.
+func (b *PaperBroker) Ticker(market string) (Ticker, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	last, ok := b.last[market]
+	if !ok {
+		return Ticker{}, &bittrexError{"paper - ticker", "NO_TRADES_SEEN"}
+	}
+
+	return Ticker{Bid: new(big.Float).Set(last), Ask: new(big.Float).Set(last), Last: new(big.Float).Set(last)}, nil
+}
+
+//OnTrade fill the open orders of the trade's market that it reaches, oldest first, up to the trade's quantity.
+func (b *PaperBroker) OnTrade(trade Trade) {
+	if trade.Price == nil || trade.Quantity == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.last[trade.Market] = new(big.Float).Set(trade.Price)
+
+	left := decimal(trade.Quantity)
+
+	for _, uuid := range append([]string(nil), b.open...) {
+		if left.Sign() <= 0 {
+			return
+		}
+
+		order := b.orders[uuid]
+		if order.Exchange != trade.Market {
+			continue
+		}
+
+		if order.Type == OrderTypeLimitBuy && trade.Price.Cmp(order.Limit) > 0 ||
+			order.Type == OrderTypeLimitSell && trade.Price.Cmp(order.Limit) < 0 {
+			continue
+		}
+
+		take := decimal(order.QuantityRemaining)
+		if take.Cmp(left) > 0 {
+			take = new(big.Rat).Set(left)
+		}
+		left.Sub(left, take)
+
+		b.execute(order, take)
+	}
+}
+
+//execute fill quantity of order at its limit, exactly. Callers hold mutex.
+func (b *PaperBroker) execute(order *AccountOrderDescription, quantity *big.Rat) {
+	market, _ := ParseMarket(order.Exchange)
+
+	total := new(big.Rat).Mul(quantity, decimal(order.Limit))
+	commission := new(big.Rat).Mul(total, decimal(b.Commission))
+
+	base, held := decimal(b.balance(market.Base())), decimal(b.balance(market.Quote()))
+	if order.Type == OrderTypeLimitBuy {
+		base.Sub(base, new(big.Rat).Add(total, commission))
+		held.Add(held, quantity)
+	} else {
+		base.Add(base, new(big.Rat).Sub(total, commission))
+		held.Sub(held, quantity)
+	}
+	b.balances[market.Base()], b.balances[market.Quote()] = ratAmount(base), ratAmount(held)
+
+	remaining := new(big.Rat).Sub(decimal(order.QuantityRemaining), quantity)
+	price := new(big.Rat).Add(decimal(order.Price), total)
+
+	order.QuantityRemaining = ratAmount(remaining)
+	order.Price = ratAmount(price)
+	order.CommissionPaid = ratAmount(new(big.Rat).Add(decimal(order.CommissionPaid), commission))
+	order.PricePerUnit = ratAmount(price.Quo(price, new(big.Rat).Sub(decimal(order.Quantity), remaining)))
+
+	if remaining.Sign() == 0 {
+		b.close(order)
+	}
+}
@@ -0,0 +1,203 @@
+package bittrex
+
+import (
+	"math/big"
+	"sort"
+)
+
+//DefaultValuationVia currencies ValueBalances routes through when a balance has no direct market with the reference.
+var DefaultValuationVia = []string{"BTC", "ETH"}
+
+//ValuationOptions how ValueBalances prices balances.
+type ValuationOptions struct {
+	//Bid price at what trading into the reference currency would fetch now (the best bid when selling, the best ask
+	//when buying) instead of the last trade price.
+	Bid bool
+
+	//Via intermediate currencies tried when there is no direct market, or a better route through one.
+	//Nil uses DefaultValuationVia.
+	Via []string
+}
+
+//AssetValuation one balance priced in the reference currency. Route lists the markets it is priced through, empty
+//for the reference currency itself. Price is the value of one unit, Value that of the whole Balance (Pending
+//deposits excluded) and Allocation its share of the portfolio's total (0.25 for 25%). NoRoute flags a balance no
+//liquid market path could price; its Price, Value and Allocation are nil.
+type AssetValuation struct {
+	Currency   string
+	Balance    *big.Float
+	Available  *big.Float
+	Pending    *big.Float
+	Route      []string
+	Price      *big.Float
+	Value      *big.Float
+	Allocation *big.Float
+	NoRoute    bool
+}
+
+//PortfolioValuation an account's balances valued in Currency, largest first; those with NoRoute come last and are
+//left out of Total.
+type PortfolioValuation struct {
+	Currency string
+	Total    *big.Float
+	Assets   []AssetValuation
+}
+
+//Unpriced currencies of the assets with no liquid route.
+func (v PortfolioValuation) Unpriced() []string {
+	var currencies []string
+	for _, asset := range v.Assets {
+		if asset.NoRoute {
+			currencies = append(currencies, asset.Currency)
+		}
+	}
+	return currencies
+}
+
+//ValuePortfolio value the account's balances in each reference currency (ex: "BTC", "USDT"), from one fetch of
+//AccountGetBalances and PublicGetMarketSummaries.
+func (c *Client) ValuePortfolio(options ValuationOptions, references ...string) ([]PortfolioValuation, error) {
+	balances, err := c.AccountGetBalances()
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := c.PublicGetMarketSummaries()
+	if err != nil {
+		return nil, err
+	}
+
+	valuations := make([]PortfolioValuation, len(references))
+	for i, reference := range references {
+		valuations[i] = ValueBalances(balances, summaries, reference, options)
+	}
+
+	return valuations, nil
+}
+
+//ValueBalances value every non-zero balance in reference through the best route the summaries price: directly, or
+//through one of options.Via, whichever is worth most. Markets are only routed through while liquid, with a price
+//and some trading in the last 24 hours.
+func ValueBalances(balances []AccountBalance, summaries []MarketSummary, reference string, options ValuationOptions) PortfolioValuation {
+	via := options.Via
+	if via == nil {
+		via = DefaultValuationVia
+	}
+
+	markets := make(map[string]MarketSummary, len(summaries))
+	for _, summary := range summaries {
+		markets[summary.MarketName] = summary
+	}
+
+	valuation := PortfolioValuation{Currency: reference}
+	total := new(big.Rat)
+	values := make([]*big.Rat, 0, len(balances))
+
+	for _, balance := range balances {
+		if balance.Balance == nil || balance.Balance.Sign() == 0 {
+			continue
+		}
+
+		asset := AssetValuation{
+			Currency:  balance.Currency,
+			Balance:   balance.Balance,
+			Available: balance.Available,
+			Pending:   balance.Pending,
+		}
+
+		price, route := bestValuationRoute(markets, balance.Currency, reference, via, options.Bid)
+		if price == nil {
+			asset.NoRoute = true
+			values = append(values, nil)
+		} else {
+			value := new(big.Rat).Mul(decimal(balance.Balance), price)
+			total.Add(total, value)
+
+			asset.Route, asset.Price, asset.Value = route, ratAmount(price), ratAmount(value)
+			values = append(values, value)
+		}
+
+		valuation.Assets = append(valuation.Assets, asset)
+	}
+
+	for i, value := range values {
+		if value != nil && total.Sign() != 0 {
+			valuation.Assets[i].Allocation = ratAmount(new(big.Rat).Quo(value, total))
+		}
+	}
+
+	valuation.Total = ratAmount(total)
+
+	sort.SliceStable(valuation.Assets, func(i, j int) bool {
+		a, b := valuation.Assets[i], valuation.Assets[j]
+		if a.NoRoute != b.NoRoute {
+			return b.NoRoute
+		}
+		if a.NoRoute {
+			return a.Currency < b.Currency
+		}
+		return a.Value.Cmp(b.Value) > 0
+	})
+
+	return valuation
+}
+
+//bestValuationRoute the most one unit of currency is worth in reference, directly or through one of via, and the
+//markets that route takes. Nil when no route is liquid.
+func bestValuationRoute(markets map[string]MarketSummary, currency, reference string, via []string, bid bool) (*big.Rat, []string) {
+	if currency == reference {
+		return big.NewRat(1, 1), []string{}
+	}
+
+	best, route := valuationRate(markets, currency, reference, bid)
+
+	for _, middle := range via {
+		if middle == currency || middle == reference {
+			continue
+		}
+
+		first, firstMarket := valuationRate(markets, currency, middle, bid)
+		second, secondMarket := valuationRate(markets, middle, reference, bid)
+		if first == nil || second == nil {
+			continue
+		}
+
+		if rate := first.Mul(first, second); best == nil || rate.Cmp(best) > 0 {
+			best, route = rate, []string{firstMarket[0], secondMarket[0]}
+		}
+	}
+
+	return best, route
+}
+
+//valuationRate how much of to one unit of from is worth on the market between them: selling from on to-from, or
+//buying to with it on from-to. Nil when neither market is liquid.
+func valuationRate(markets map[string]MarketSummary, from, to string, bid bool) (*big.Rat, []string) {
+	if summary, ok := markets[to+"-"+from]; ok {
+		price := summary.Last
+		if bid {
+			price = summary.Bid
+		}
+
+		if liquidSummary(summary, price) {
+			return decimal(price), []string{summary.MarketName}
+		}
+	}
+
+	if summary, ok := markets[from+"-"+to]; ok {
+		price := summary.Last
+		if bid {
+			price = summary.Ask
+		}
+
+		if liquidSummary(summary, price) {
+			return new(big.Rat).Inv(decimal(price)), []string{summary.MarketName}
+		}
+	}
+
+	return nil, nil
+}
+
+func liquidSummary(summary MarketSummary, price *big.Float) bool {
+	return price != nil && price.Sign() > 0 && summary.Volume != nil && summary.Volume.Sign() > 0
+}
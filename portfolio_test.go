@@ -0,0 +1,110 @@
+package bittrex
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func valuationSummaries() []MarketSummary {
+	summary := func(market, last, bid, ask, volume string) MarketSummary {
+		return MarketSummary{MarketName: market, Last: dec(last), Bid: dec(bid), Ask: dec(ask), Volume: dec(volume)}
+	}
+
+	return []MarketSummary{
+		summary("USDT-BTC", "10000", "9990", "10010", "10"),
+		summary("BTC-LTC", "0.01", "0.0099", "0.0101", "100"),
+		summary("BTC-ETH", "0.05", "0.049", "0.051", "20"),
+		summary("USDT-ETH", "480", "479", "481", "1"),
+		summary("ETH-XYZ", "0.5", "0.4", "0.6", "5"),
+		//no trading in the last 24 hours
+		summary("BTC-DEAD", "0.001", "0.0009", "0.0011", "0"),
+	}
+}
+
+func valuationBalances() []AccountBalance {
+	balance := func(currency, amount string) AccountBalance {
+		return AccountBalance{Currency: currency, Balance: dec(amount), Available: dec(amount), Pending: new(big.Float)}
+	}
+
+	return []AccountBalance{
+		balance("BTC", "1"),
+		balance("LTC", "50"),
+		balance("ETH", "2"),
+		balance("XYZ", "10"),
+		balance("DEAD", "1000"),
+		balance("USDT", "100"),
+		balance("ZERO", "0"),
+	}
+}
+
+func TestValueBalances(t *testing.T) {
+	valuation := ValueBalances(valuationBalances(), valuationSummaries(), "USDT", ValuationOptions{})
+
+	expectDecimal(t, "total", valuation.Total, "18500")
+
+	want := []struct {
+		currency string
+		value    string
+		route    []string
+	}{
+		{"BTC", "10000", []string{"USDT-BTC"}},
+		{"LTC", "5000", []string{"BTC-LTC", "USDT-BTC"}},
+		{"XYZ", "2400", []string{"ETH-XYZ", "USDT-ETH"}},
+		//0.05 BTC is worth more than the direct 480
+		{"ETH", "1000", []string{"BTC-ETH", "USDT-BTC"}},
+		{"USDT", "100", []string{}},
+	}
+
+	if len(valuation.Assets) != len(want)+1 {
+		t.Fatalf("expected %d assets, got %+v", len(want)+1, valuation.Assets)
+	}
+
+	for i, w := range want {
+		asset := valuation.Assets[i]
+		if asset.Currency != w.currency || !reflect.DeepEqual(asset.Route, w.route) {
+			t.Errorf("asset %d: expected %s through %v, got %s through %v", i, w.currency, w.route, asset.Currency, asset.Route)
+			continue
+		}
+		expectDecimal(t, w.currency+" value", asset.Value, w.value)
+	}
+
+	if got := valuation.Assets[0].Allocation.Text('f', 4); got != "0.5405" {
+		t.Errorf("expected BTC to be 54.05%% of the portfolio, got %s", got)
+	}
+
+	dead := valuation.Assets[len(valuation.Assets)-1]
+	if !dead.NoRoute || dead.Value != nil || dead.Allocation != nil {
+		t.Errorf("expected DEAD flagged without a route, got %+v", dead)
+	}
+
+	if unpriced := valuation.Unpriced(); !reflect.DeepEqual(unpriced, []string{"DEAD"}) {
+		t.Errorf("unexpected unpriced %v", unpriced)
+	}
+}
+
+func TestValueBalancesInBTCAtBid(t *testing.T) {
+	balances := valuationBalances()
+
+	//100 USDT buys more BTC through ETH (0.05 / 480 each) than directly (1 / 10000)
+	valuation := ValueBalances(balances, valuationSummaries(), "BTC", ValuationOptions{})
+	if got := valuation.Total.Text('f', 6); got != "1.860417" {
+		t.Errorf("expected a total of 1.860417 at last, got %s", got)
+	}
+	if usdt := valuation.Assets[len(valuation.Assets)-2]; usdt.Currency != "USDT" || !reflect.DeepEqual(usdt.Route, []string{"USDT-ETH", "BTC-ETH"}) {
+		t.Errorf("expected USDT routed through ETH, got %+v", usdt)
+	}
+
+	//selling LTC gets the bid; buying BTC with USDT pays the ask
+	valuation = ValueBalances(balances[:2], valuationSummaries(), "BTC", ValuationOptions{Bid: true})
+	expectDecimal(t, "total at bid", valuation.Total, "1.495")
+
+	valuation = ValueBalances([]AccountBalance{{Currency: "USDT", Balance: dec("1001")}}, valuationSummaries(), "BTC", ValuationOptions{Bid: true, Via: []string{}})
+	expectDecimal(t, "USDT at ask", valuation.Total, "0.1")
+
+	//without routing through ETH, XYZ can't be priced
+	valuation = ValueBalances(balances, valuationSummaries(), "BTC", ValuationOptions{Via: []string{}})
+	if unpriced := valuation.Unpriced(); !reflect.DeepEqual(unpriced, []string{"DEAD", "XYZ"}) {
+		t.Errorf("unexpected unpriced %v", unpriced)
+	}
+}
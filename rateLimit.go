@@ -0,0 +1,88 @@
+package bittrex
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	//defaultBatchRateLimit requests per second PlaceBatch and CancelAll send until SetRateLimit is called, about
+	//what Bittrex allows, in bursts of up to defaultBatchRateBurst.
+	defaultBatchRateLimit = 1
+	defaultBatchRateBurst = 4
+)
+
+//rateLimiter spaces requests interval apart, letting up to burst through at once after a quiet period.
+//Shared by a client and its sessions. An interval of 0 lets everything through.
+type rateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	burst    int
+	next     time.Time
+}
+
+//SetRateLimit limit the client (and everything started from it) to perSecond requests per second on average,
+//with bursts of up to burst requests. Bittrex allows about 1 per second. perSecond 0 or less removes the limit.
+//New clients send single calls unlimited, and PlaceBatch and CancelAll at about 1 per second in bursts of 4; once
+//SetRateLimit is called, its limit covers both.
+func (c *Client) SetRateLimit(perSecond float64, burst int) {
+	c.limiter.set(perSecond, burst)
+	c.batchLimiter = c.limiter
+}
+
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	l := &rateLimiter{}
+	l.set(perSecond, burst)
+	return l
+}
+
+func (l *rateLimiter) set(perSecond float64, burst int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.interval = 0
+	if perSecond > 0 {
+		l.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	l.burst = burst
+	l.next = time.Time{}
+}
+
+//reserve claim the next request slot, returning how long to wait for it.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.interval == 0 {
+		return 0
+	}
+
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	wait := l.next.Sub(now) - time.Duration(l.burst-1)*l.interval
+	l.next = l.next.Add(l.interval)
+
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+//wait block until a request may be sent.
+func (l *rateLimiter) wait() {
+	if l == nil {
+		return
+	}
+
+	if wait := l.reserve(time.Now()); wait > 0 {
+		time.Sleep(wait)
+	}
+}
@@ -0,0 +1,385 @@
+package bittrex
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//DefaultRegistryTTL how long a Registry serves markets and currencies before refetching them.
+const DefaultRegistryTTL = 15 * time.Minute
+
+//RegistryEventType what changed between two Registry refreshes.
+type RegistryEventType int
+
+const (
+	//MarketAdded a market was listed
+	MarketAdded RegistryEventType = iota
+
+	//MarketDelisted a market is no longer listed
+	MarketDelisted
+
+	//MarketDeactivated a market's IsActive went false
+	MarketDeactivated
+
+	//MarketReactivated a market's IsActive went true again
+	MarketReactivated
+
+	//RegistryRefreshFailed a refresh failed, see Err. The previous markets and currencies are kept.
+	RegistryRefreshFailed
+)
+
+func (t RegistryEventType) String() string {
+	switch t {
+	case MarketAdded:
+		return "MarketAdded"
+	case MarketDelisted:
+		return "MarketDelisted"
+	case MarketDeactivated:
+		return "MarketDeactivated"
+	case MarketReactivated:
+		return "MarketReactivated"
+	case RegistryRefreshFailed:
+		return "RegistryRefreshFailed"
+	}
+	return "RegistryEventType(?)"
+}
+
+//RegistryEvent a change seen by a Registry refresh. Market is the latest description (the last known one for
+//MarketDelisted) and is empty for RegistryRefreshFailed.
+type RegistryEvent struct {
+	Type   RegistryEventType
+	Market MarketDescription
+	Err    error
+}
+
+//Registry cache of PublicGetMarkets and PublicGetCurrencies. It loads on first lookup and refetches once its TTL
+//has passed, or on every Period after Start. When a refetch fails and data was loaded before, lookups keep
+//serving it and watchers get a RegistryRefreshFailed event. Safe for concurrent use.
+type Registry struct {
+	//sources, PublicGetMarkets and PublicGetCurrencies outside tests.
+	fetchMarkets    func() ([]MarketDescription, error)
+	fetchCurrencies func() ([]Currency, error)
+
+	mutex      sync.RWMutex
+	ttl        time.Duration
+	loaded     time.Time
+	markets    map[string]MarketDescription
+	currencies map[string]Currency
+
+	refreshMutex sync.Mutex
+
+	watchMutex sync.Mutex
+	watchers   map[int]func(RegistryEvent)
+	nextWatch  int
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	closing   chan struct{}
+	closed    chan struct{}
+}
+
+//Registry the client's market and currency registry, created on first use with DefaultRegistryTTL.
+func (c *Client) Registry() *Registry {
+	c.registryOnce.Do(func() {
+		//the registry may refresh from its own goroutine, so it gets its own session.
+		session := c.session()
+		c.registry = newRegistry(session.PublicGetMarkets, session.PublicGetCurrencies)
+	})
+
+	return c.registry
+}
+
+func newRegistry(markets func() ([]MarketDescription, error), currencies func() ([]Currency, error)) *Registry {
+	return &Registry{
+		fetchMarkets:    markets,
+		fetchCurrencies: currencies,
+		ttl:             DefaultRegistryTTL,
+		watchers:        make(map[int]func(RegistryEvent)),
+		closing:         make(chan struct{}),
+		closed:          make(chan struct{}),
+	}
+}
+
+//SetTTL how long data is served before a lookup refetches it. 0 or less never expires it.
+func (r *Registry) SetTTL(ttl time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.ttl = ttl
+}
+
+//Invalidate make the next lookup refetch.
+func (r *Registry) Invalidate() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.loaded = time.Time{}
+}
+
+//Watch call handler with every RegistryEvent, from the goroutine doing the refresh. Call the returned func to stop.
+func (r *Registry) Watch(handler func(RegistryEvent)) (unwatch func()) {
+	r.watchMutex.Lock()
+	defer r.watchMutex.Unlock()
+
+	id := r.nextWatch
+	r.nextWatch++
+	r.watchers[id] = handler
+
+	return func() {
+		r.watchMutex.Lock()
+		defer r.watchMutex.Unlock()
+
+		delete(r.watchers, id)
+	}
+}
+
+func (r *Registry) emit(events []RegistryEvent) {
+	r.watchMutex.Lock()
+	handlers := make([]func(RegistryEvent), 0, len(r.watchers))
+	for _, handler := range r.watchers {
+		handlers = append(handlers, handler)
+	}
+	r.watchMutex.Unlock()
+
+	for _, event := range events {
+		for _, handler := range handlers {
+			handler(event)
+		}
+	}
+}
+
+//Refresh fetch markets and currencies now.
+func (r *Registry) Refresh() error {
+	r.refreshMutex.Lock()
+	defer r.refreshMutex.Unlock()
+
+	return r.refresh()
+}
+
+func (r *Registry) refresh() error {
+	descriptions, err := r.fetchMarkets()
+	if err != nil {
+		r.emit([]RegistryEvent{{Type: RegistryRefreshFailed, Err: err}})
+		return err
+	}
+
+	currencyList, err := r.fetchCurrencies()
+	if err != nil {
+		r.emit([]RegistryEvent{{Type: RegistryRefreshFailed, Err: err}})
+		return err
+	}
+
+	markets := make(map[string]MarketDescription, len(descriptions))
+	for _, description := range descriptions {
+		markets[strings.ToUpper(description.MarketName)] = description
+	}
+
+	currencies := make(map[string]Currency, len(currencyList))
+	for _, currency := range currencyList {
+		currencies[strings.ToUpper(currency.Currency)] = currency
+	}
+
+	r.mutex.Lock()
+	previous := r.markets
+	r.markets = markets
+	r.currencies = currencies
+	r.loaded = time.Now()
+	r.mutex.Unlock()
+
+	//the first load is not a change.
+	if previous != nil {
+		r.emit(marketChanges(previous, markets))
+	}
+
+	return nil
+}
+
+//marketChanges events turning previous into current, ordered by market name.
+func marketChanges(previous, current map[string]MarketDescription) []RegistryEvent {
+	var events []RegistryEvent
+
+	for name, description := range current {
+		old, ok := previous[name]
+		switch {
+		case !ok:
+			events = append(events, RegistryEvent{Type: MarketAdded, Market: description})
+		case old.IsActive && !description.IsActive:
+			events = append(events, RegistryEvent{Type: MarketDeactivated, Market: description})
+		case !old.IsActive && description.IsActive:
+			events = append(events, RegistryEvent{Type: MarketReactivated, Market: description})
+		}
+	}
+
+	for name, description := range previous {
+		if _, ok := current[name]; !ok {
+			events = append(events, RegistryEvent{Type: MarketDelisted, Market: description})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Market.MarketName < events[j].Market.MarketName
+	})
+
+	return events
+}
+
+//fresh make sure data is loaded and within its TTL.
+func (r *Registry) fresh() error {
+	if r.isFresh() {
+		return nil
+	}
+
+	r.refreshMutex.Lock()
+	defer r.refreshMutex.Unlock()
+
+	//another caller may have refreshed while this one waited.
+	if r.isFresh() {
+		return nil
+	}
+
+	err := r.refresh()
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if err != nil && r.markets == nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Registry) isFresh() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.loaded.IsZero() {
+		return false
+	}
+
+	return r.ttl <= 0 || time.Since(r.loaded) < r.ttl
+}
+
+//Market description of m, ok false when it is not listed.
+func (r *Registry) Market(m Market) (MarketDescription, bool, error) {
+	return r.MarketByName(m.String())
+}
+
+//MarketByName description of a market by its v1.1 name (ex: "BTC-LTC"), ok false when it is not listed.
+func (r *Registry) MarketByName(name string) (MarketDescription, bool, error) {
+	if err := r.fresh(); err != nil {
+		return MarketDescription{}, false, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	description, ok := r.markets[strings.ToUpper(name)]
+	return description, ok, nil
+}
+
+//Currency description of a currency by its code (ex: "BTC"), ok false when it is not listed.
+func (r *Registry) Currency(code string) (Currency, bool, error) {
+	if err := r.fresh(); err != nil {
+		return Currency{}, false, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	currency, ok := r.currencies[strings.ToUpper(code)]
+	return currency, ok, nil
+}
+
+//Markets every listed market, by name.
+func (r *Registry) Markets() ([]MarketDescription, error) {
+	if err := r.fresh(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	markets := make([]MarketDescription, 0, len(r.markets))
+	for _, description := range r.markets {
+		markets = append(markets, description)
+	}
+
+	sort.Slice(markets, func(i, j int) bool {
+		return markets[i].MarketName < markets[j].MarketName
+	})
+
+	return markets, nil
+}
+
+//Currencies every listed currency, by code.
+func (r *Registry) Currencies() ([]Currency, error) {
+	if err := r.fresh(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	currencies := make([]Currency, 0, len(r.currencies))
+	for _, currency := range r.currencies {
+		currencies = append(currencies, currency)
+	}
+
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Currency < currencies[j].Currency
+	})
+
+	return currencies, nil
+}
+
+//Start refresh every period in the background, so events arrive without lookups. Stop with Close.
+func (r *Registry) Start(period time.Duration) {
+	r.startOnce.Do(func() {
+		go r.run(period)
+	})
+}
+
+//Close stop a started registry. Lookups keep working, refreshing on TTL.
+func (r *Registry) Close(ctx context.Context) error {
+	started := true
+	r.startOnce.Do(func() {
+		started = false
+		close(r.closed)
+	})
+
+	r.closeOnce.Do(func() {
+		close(r.closing)
+	})
+
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-r.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Registry) run(period time.Duration) {
+	defer close(r.closed)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.closing:
+			return
+		case <-ticker.C:
+			//failures reach watchers as RegistryRefreshFailed.
+			r.Refresh()
+		}
+	}
+}
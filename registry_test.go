@@ -0,0 +1,138 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	var mutex sync.Mutex
+	fetches := 0
+	markets := []MarketDescription{
+		{MarketName: "BTC-LTC", BaseCurrency: "BTC", MarketCurrency: "LTC", IsActive: true},
+		{MarketName: "BTC-ETH", BaseCurrency: "BTC", MarketCurrency: "ETH", IsActive: true},
+		{MarketName: "BTC-DOGE", BaseCurrency: "BTC", MarketCurrency: "DOGE", IsActive: false},
+	}
+	var fetchErr error
+
+	r := newRegistry(func() ([]MarketDescription, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		fetches++
+		return append([]MarketDescription(nil), markets...), fetchErr
+	}, func() ([]Currency, error) {
+		return []Currency{{Currency: "BTC", TxFee: big.NewFloat(0.001), MinConfirmation: 2}}, nil
+	})
+
+	var events []RegistryEvent
+	unwatch := r.Watch(func(event RegistryEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		events = append(events, event)
+	})
+
+	if description, ok, err := r.MarketByName("btc-ltc"); err != nil || !ok || description.MarketCurrency != "LTC" {
+		t.Fatalf("unexpected lookup %+v %v %v", description, ok, err)
+	}
+
+	if currency, ok, _ := r.Currency("BTC"); !ok || currency.MinConfirmation != 2 {
+		t.Errorf("unexpected currency %+v", currency)
+	}
+
+	if _, ok, _ := r.Currency("XYZ"); ok {
+		t.Errorf("expected XYZ to be unknown")
+	}
+
+	r.Markets()
+	if fetches != 1 {
+		t.Errorf("expected lookups within the TTL to use the cache, got %d fetches", fetches)
+	}
+
+	mutex.Lock()
+	markets = []MarketDescription{
+		{MarketName: "BTC-LTC", BaseCurrency: "BTC", MarketCurrency: "LTC", IsActive: false},
+		{MarketName: "BTC-DOGE", BaseCurrency: "BTC", MarketCurrency: "DOGE", IsActive: true},
+		{MarketName: "BTC-XRP", BaseCurrency: "BTC", MarketCurrency: "XRP", IsActive: true},
+	}
+	mutex.Unlock()
+
+	r.Invalidate()
+	if _, ok, _ := r.MarketByName("BTC-ETH"); ok {
+		t.Errorf("expected BTC-ETH to be delisted after invalidation")
+	}
+
+	expected := []struct {
+		eventType RegistryEventType
+		market    string
+	}{
+		{MarketReactivated, "BTC-DOGE"},
+		{MarketDelisted, "BTC-ETH"},
+		{MarketDeactivated, "BTC-LTC"},
+		{MarketAdded, "BTC-XRP"},
+	}
+
+	mutex.Lock()
+	if len(events) != len(expected) {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	for i, event := range events {
+		if event.Type != expected[i].eventType || event.Market.MarketName != expected[i].market {
+			t.Errorf("event %d: got %s %s, wanted %s %s", i, event.Type, event.Market.MarketName, expected[i].eventType, expected[i].market)
+		}
+	}
+	events = nil
+	fetchErr = errors.New("down")
+	mutex.Unlock()
+
+	//a failed refresh keeps serving the previous data
+	r.SetTTL(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if _, ok, err := r.MarketByName("BTC-XRP"); err != nil || !ok {
+		t.Errorf("expected stale data on a failed refresh, got %v %v", ok, err)
+	}
+
+	mutex.Lock()
+	if len(events) != 1 || events[0].Type != RegistryRefreshFailed {
+		t.Errorf("expected a refresh failure event, got %+v", events)
+	}
+	mutex.Unlock()
+
+	unwatch()
+}
+
+func TestRegistryStart(t *testing.T) {
+	fetched := make(chan struct{}, 10)
+
+	r := newRegistry(func() ([]MarketDescription, error) {
+		fetched <- struct{}{}
+		return nil, nil
+	}, func() ([]Currency, error) {
+		return nil, nil
+	})
+
+	r.Start(10 * time.Millisecond)
+
+	select {
+	case <-fetched:
+	case <-time.After(time.Second):
+		t.Fatalf("expected a background refresh")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := r.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := newRegistry(nil, nil).Close(ctx); err != nil {
+		t.Errorf("closing an unstarted registry: %v", err)
+	}
+}
@@ -33,6 +33,8 @@ func (c *Client) sendRequest(endpoint string, params queryParams) *baseResponse
 
 	request.Header.Add("apisign", sign)
 
+	c.limiter.wait()
+
 	var resp *http.Response
 	var respErr error
 
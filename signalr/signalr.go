@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -37,6 +39,21 @@ type Client struct {
 	responseFutures map[string]chan *serverMessage
 	mutex           sync.Mutex
 	dispatchRunning bool
+	// Serializes socket writes, CallHub may be used from several goroutines.
+	writeMutex sync.Mutex
+
+	// HTTPClient used for the negotiate request. When nil a default client is used.
+	HTTPClient *http.Client
+	// Dialer used to open the websocket. When nil a copy of websocket.DefaultDialer is used.
+	Dialer *websocket.Dialer
+	// Header is sent with both the negotiate request and the websocket handshake.
+	Header http.Header
+	// Jar is shared between negotiate and connect, so cookies set by the server during
+	// negotiation (or injected beforehand, e.g. cf_clearance) are replayed on the websocket handshake.
+	// When HTTPClient has a Jar of its own, that one is shared instead.
+	Jar http.CookieJar
+	// UserAgent overrides the User-Agent header on both requests when not empty.
+	UserAgent string
 }
 
 type serverMessage struct {
@@ -47,20 +64,67 @@ type serverMessage struct {
 	Error      string            `json:"E"`
 }
 
-func negotiate(scheme, address string) (negotiationResponse, error) {
+func (self *Client) requestHeader() http.Header {
+	header := http.Header{}
+	for key, values := range self.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	if self.UserAgent != "" {
+		header.Set("User-Agent", self.UserAgent)
+	}
+	return header
+}
+
+func (self *Client) httpClient() *http.Client {
+	var client http.Client
+	if self.HTTPClient != nil {
+		client = *self.HTTPClient
+	}
+	client.Jar = self.jar()
+	return &client
+}
+
+func (self *Client) dialer() *websocket.Dialer {
+	var dialer websocket.Dialer
+	if self.Dialer != nil {
+		dialer = *self.Dialer
+	} else {
+		dialer = *websocket.DefaultDialer
+	}
+	dialer.Jar = self.jar()
+	return &dialer
+}
+
+// jar the one cookie jar negotiate and connect both use.
+func (self *Client) jar() http.CookieJar {
+	if self.HTTPClient != nil && self.HTTPClient.Jar != nil {
+		return self.HTTPClient.Jar
+	}
+	return self.Jar
+}
+
+func (self *Client) negotiate(scheme, address string) (negotiationResponse, error) {
 	var response negotiationResponse
 
 	var negotiationUrl = url.URL{Scheme: scheme, Host: address, Path: "/signalr/negotiate"}
 
-	client := &http.Client{}
+	request, err := http.NewRequest("GET", negotiationUrl.String(), nil)
+	if err != nil {
+		return response, err
+	}
+	request.Header = self.requestHeader()
 
-	reply, err := client.Get(negotiationUrl.String())
+	reply, err := self.httpClient().Do(request)
 	if err != nil {
 		return response, err
 	}
 
 	defer reply.Body.Close()
 
+	if reply.StatusCode != http.StatusOK {
+		return response, fmt.Errorf("negotiate failed with status %s", reply.Status)
+	}
+
 	if body, err := ioutil.ReadAll(reply.Body); err != nil {
 		return response, err
 	} else if err := json.Unmarshal(body, &response); err != nil {
@@ -75,7 +139,7 @@ func negotiate(scheme, address string) (negotiationResponse, error) {
 	}
 }
 
-func connectWebsocket(address string, params negotiationResponse, hubs []string) (*websocket.Conn, error) {
+func connectionParameters(params negotiationResponse, hubs []string) (url.Values, error) {
 	var connectionData = make([]struct {
 		Name string `json:"Name"`
 	}, len(hubs))
@@ -93,16 +157,70 @@ func connectWebsocket(address string, params negotiationResponse, hubs []string)
 	connectionParameters.Set("connectionToken", params.ConnectionToken)
 	connectionParameters.Set("connectionData", string(connectionDataBytes))
 
-	var connectionUrl = url.URL{Scheme: "wss", Host: address, Path: "signalr/connect"}
+	return connectionParameters, nil
+}
+
+func (self *Client) connectWebsocket(scheme, address string, params negotiationResponse, hubs []string) (*websocket.Conn, error) {
+	connectionParameters, err := connectionParameters(params, hubs)
+	if err != nil {
+		return nil, err
+	}
+
+	var wsScheme = "wss"
+	if scheme == "http" {
+		wsScheme = "ws"
+	}
+
+	var connectionUrl = url.URL{Scheme: wsScheme, Host: address, Path: "signalr/connect"}
 	connectionUrl.RawQuery = connectionParameters.Encode()
 
-	if conn, _, err := websocket.DefaultDialer.Dial(connectionUrl.String(), nil); err != nil {
+	if conn, _, err := self.dialer().Dial(connectionUrl.String(), self.requestHeader()); err != nil {
 		return nil, err
 	} else {
 		return conn, nil
 	}
 }
 
+// Tell the server the transport is ready. SignalR 1.5 servers hold back hub traffic until this succeeds.
+func (self *Client) start(scheme, address string, params negotiationResponse, hubs []string) error {
+	connectionParameters, err := connectionParameters(params, hubs)
+	if err != nil {
+		return err
+	}
+
+	var startUrl = url.URL{Scheme: scheme, Host: address, Path: "/signalr/start"}
+	startUrl.RawQuery = connectionParameters.Encode()
+
+	request, err := http.NewRequest("GET", startUrl.String(), nil)
+	if err != nil {
+		return err
+	}
+	request.Header = self.requestHeader()
+
+	reply, err := self.httpClient().Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer reply.Body.Close()
+
+	var response struct {
+		Response string
+	}
+
+	if reply.StatusCode != http.StatusOK {
+		return fmt.Errorf("start failed with status %s", reply.Status)
+	} else if body, err := ioutil.ReadAll(reply.Body); err != nil {
+		return err
+	} else if err := json.Unmarshal(body, &response); err != nil {
+		return err
+	} else if response.Response != "started" {
+		return fmt.Errorf("start failed with response %q", response.Response)
+	}
+
+	return nil
+}
+
 func (self *Client) routeResponse(response *serverMessage) {
 	self.mutex.Lock()
 	defer self.mutex.Unlock()
@@ -128,6 +246,15 @@ func (self *Client) createResponseFuture(identifier string) (chan *serverMessage
 	return c, nil
 }
 
+func (self *Client) nextIdentifier() int {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	id := self.nextId
+	self.nextId++
+	return id
+}
+
 func (self *Client) deleteResponseFuture(identifier string) {
 	self.mutex.Lock()
 	defer self.mutex.Unlock()
@@ -160,7 +287,8 @@ func (self *Client) endDispatch() {
 	close(self.DisconnectedChannel)
 }
 
-// Start dispatch loop. This function will return when error occurs. When this
+// Start dispatch loop. This function will return when error occurs, or when nothing
+// (not even a keep-alive) was received for the negotiated KeepAliveTimeout. When this
 // happens, all the connections are closed and user can run Connect()
 // and Dispatch() again on the same client.
 func (self *Client) dispatch(connectedChannel chan bool) {
@@ -172,6 +300,9 @@ func (self *Client) dispatch(connectedChannel chan bool) {
 
 	close(connectedChannel)
 
+	// The server sends a keep-alive well within KeepAliveTimeout; a socket silent for longer is stalled.
+	keepAlive := time.Duration(self.params.KeepAliveTimeout * float32(time.Second))
+
 	for {
 		var message serverMessage
 
@@ -181,6 +312,10 @@ func (self *Client) dispatch(connectedChannel chan bool) {
 			Arguments []json.RawMessage `json:"A"`
 		}
 
+		if keepAlive > 0 {
+			self.socket.SetReadDeadline(time.Now().Add(keepAlive))
+		}
+
 		_, data, err := self.socket.ReadMessage()
 		if err != nil {
 			self.socket.Close()
@@ -216,11 +351,9 @@ func (self *Client) CallHub(hub, method string, params ...interface{}) (json.Raw
 		Hub:        hub,
 		Method:     method,
 		Arguments:  params,
-		Identifier: self.nextId,
+		Identifier: self.nextIdentifier(),
 	}
 
-	self.nextId++
-
 	data, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
@@ -232,7 +365,12 @@ func (self *Client) CallHub(hub, method string, params ...interface{}) (json.Raw
 		return nil, err
 	}
 
-	if err := self.socket.WriteMessage(websocket.TextMessage, data); err != nil {
+	self.writeMutex.Lock()
+	err = self.socket.WriteMessage(websocket.TextMessage, data)
+	self.writeMutex.Unlock()
+
+	if err != nil {
+		self.deleteResponseFuture(responseKey)
 		return nil, err
 	}
 
@@ -249,33 +387,51 @@ func (self *Client) CallHub(hub, method string, params ...interface{}) (json.Raw
 
 func (self *Client) Connect(scheme, host string, hubs []string) error {
 	// Negotiate parameters.
-	if params, err := negotiate(scheme, host); err != nil {
+	if params, err := self.negotiate(scheme, host); err != nil {
 		return err
 	} else {
 		self.params = params
 	}
 
 	// Connect Websocket.
-	if ws, err := connectWebsocket(host, self.params, hubs); err != nil {
+	if ws, err := self.connectWebsocket(scheme, host, self.params, hubs); err != nil {
 		return err
 	} else {
+		self.mutex.Lock()
 		self.socket = ws
+		self.mutex.Unlock()
 	}
 
 	var connectedChannel = make(chan bool)
 	go self.dispatch(connectedChannel)
 	<-connectedChannel
 
+	// Start the transport.
+	if err := self.start(scheme, host, self.params, hubs); err != nil {
+		self.socket.Close()
+		return err
+	}
+
 	return nil
 }
 
+// Close the socket. Safe to call before Connect; dispatch ends and DisconnectedChannel is closed once the read fails.
 func (self *Client) Close() {
-	self.socket.Close()
+	self.mutex.Lock()
+	socket := self.socket
+	self.mutex.Unlock()
+
+	if socket != nil {
+		socket.Close()
+	}
 }
 
 func NewWebsocketClient() *Client {
+	jar, _ := cookiejar.New(nil)
+
 	return &Client{
 		nextId:          1,
 		responseFutures: make(map[string]chan *serverMessage),
+		Jar:             jar,
 	}
 }
@@ -0,0 +1,144 @@
+package signalr
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/technicalviking/bittrex/signalr/signalrtest"
+)
+
+func TestNegotiateSendsCookiesAndHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("cf_clearance"); err != nil || cookie.Value != "cleared" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.UserAgent() != "test-agent" || r.Header.Get("X-Extra") != "extra" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"ConnectionToken":"token","ProtocolVersion":"1.5"}`))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+
+	client := NewWebsocketClient()
+	client.UserAgent = "test-agent"
+	client.Header = http.Header{"X-Extra": []string{"extra"}}
+
+	if _, err := client.negotiate("http", serverURL.Host); err == nil {
+		t.Errorf("negotiate without clearance cookie should fail")
+	}
+
+	client.Jar.SetCookies(serverURL, []*http.Cookie{{Name: "cf_clearance", Value: "cleared"}})
+
+	params, err := client.negotiate("http", serverURL.Host)
+	if err != nil {
+		t.Fatalf("negotiate failed: %s", err.Error())
+	}
+
+	if params.ConnectionToken != "token" {
+		t.Errorf("unexpected connection token %s", params.ConnectionToken)
+	}
+}
+
+func TestHTTPClientJarShared(t *testing.T) {
+	client := NewWebsocketClient()
+
+	if client.httpClient().Jar != client.Jar || client.dialer().Jar != client.Jar {
+		t.Errorf("expected negotiate and connect to share Jar")
+	}
+
+	jar, _ := cookiejar.New(nil)
+	client.HTTPClient = &http.Client{Jar: jar}
+
+	if client.httpClient().Jar != jar || client.dialer().Jar != jar {
+		t.Errorf("expected negotiate and connect to share the HTTPClient's jar")
+	}
+}
+
+func TestConnectCallHubAndClientMethod(t *testing.T) {
+	server := signalrtest.NewServer(10 * time.Millisecond)
+	defer server.Close()
+
+	server.Handle("TestHub", "Echo", func(args []json.RawMessage) (interface{}, error) {
+		var value string
+		json.Unmarshal(args[0], &value)
+		return value, nil
+	})
+
+	client := NewWebsocketClient()
+
+	received := make(chan string, 1)
+	client.OnClientMethod = func(hub, method string, arguments []json.RawMessage) {
+		received <- method
+	}
+
+	if err := client.Connect("http", server.Host, []string{"TestHub"}); err != nil {
+		t.Fatalf("connect failed: %s", err.Error())
+	}
+	defer client.Close()
+
+	result, err := client.CallHub("testhub", "echo", "hello")
+	if err != nil {
+		t.Fatalf("CallHub failed: %s", err.Error())
+	}
+
+	if string(result) != `"hello"` {
+		t.Errorf("unexpected CallHub result %s", result)
+	}
+
+	if err := server.Invoke("TestHub", "notify", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case method := <-received:
+		if method != "notify" {
+			t.Errorf("unexpected client method %s", method)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for client method")
+	}
+
+	server.Drop()
+
+	select {
+	case <-client.DisconnectedChannel:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for disconnect")
+	}
+}
+
+func TestKeepAliveTimeout(t *testing.T) {
+	server := signalrtest.NewServer(50 * time.Millisecond)
+	defer server.Close()
+
+	client := NewWebsocketClient()
+
+	if err := client.Connect("http", server.Host, []string{"TestHub"}); err != nil {
+		t.Fatalf("connect failed: %s", err.Error())
+	}
+	defer client.Close()
+
+	//keep-alives hold the connection open well past the timeout
+	select {
+	case <-client.DisconnectedChannel:
+		t.Fatalf("disconnected while keep-alives were flowing")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	server.Silence(true)
+
+	select {
+	case <-client.DisconnectedChannel:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for the stalled connection to be dropped")
+	}
+}
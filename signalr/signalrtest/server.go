@@ -0,0 +1,461 @@
+// Package signalrtest runs a local SignalR 1.5 hub server for tests.
+// It answers negotiate/connect/start, routes hub invocations to scripted handlers,
+// pushes client method calls and can simulate dropped sockets and keep-alive silence.
+package signalrtest
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+//HubHandler scripted response to a hub invocation. The returned value is sent back as the call result,
+//a non nil error is sent back as the call error.
+type HubHandler func(arguments []json.RawMessage) (interface{}, error)
+
+//Call a hub invocation received from a client.
+type Call struct {
+	Hub       string
+	Method    string
+	Arguments []json.RawMessage
+}
+
+type hubRequest struct {
+	Hub        string            `json:"H"`
+	Method     string            `json:"M"`
+	Arguments  []json.RawMessage `json:"A"`
+	Identifier json.RawMessage   `json:"I"`
+}
+
+type hubResponse struct {
+	Result     interface{} `json:"R,omitempty"`
+	Error      string      `json:"E,omitempty"`
+	Identifier string      `json:"I"`
+}
+
+type clientInvocation struct {
+	Hub       string        `json:"H"`
+	Method    string        `json:"M"`
+	Arguments []interface{} `json:"A"`
+}
+
+type persistentMessage struct {
+	Cursor string             `json:"C"`
+	Data   []clientInvocation `json:"M"`
+}
+
+type conn struct {
+	socket  *websocket.Conn
+	writeMu sync.Mutex
+	done    chan struct{}
+	started bool
+}
+
+func (c *conn) write(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.socket.WriteJSON(v)
+}
+
+//Server fake SignalR hub server. Create with NewServer, stop with Close.
+type Server struct {
+	//Host host:port of the server, suitable for signalr.Client.Connect("http", Host, hubs)
+	Host string
+	//URL base url of the server
+	URL string
+
+	httpServer *httptest.Server
+	upgrader   websocket.Upgrader
+
+	mutex       sync.Mutex
+	handlers    map[string]HubHandler
+	conns       map[string]*conn
+	calls       []Call
+	keepAlive   time.Duration
+	silent      bool
+	cursor      int
+	nextToken   int
+	negotiates  int
+	connects    int
+	changed     chan struct{}
+	closed      bool
+	connWorkers sync.WaitGroup
+}
+
+//NewServer start a server listening on a random local port.
+//Keep-alives are sent every keepAlive; zero disables them.
+func NewServer(keepAlive time.Duration) *Server {
+	s := &Server{
+		handlers:  make(map[string]HubHandler),
+		conns:     make(map[string]*conn),
+		keepAlive: keepAlive,
+		changed:   make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/signalr/negotiate", s.handleNegotiate)
+	mux.HandleFunc("/signalr/connect", s.handleConnect)
+	mux.HandleFunc("/signalr/start", s.handleStart)
+
+	s.httpServer = httptest.NewServer(mux)
+	s.URL = s.httpServer.URL
+
+	u, _ := url.Parse(s.URL)
+	s.Host = u.Host
+
+	return s
+}
+
+func handlerKey(hub, method string) string {
+	return strings.ToLower(hub) + "." + strings.ToLower(method)
+}
+
+//Handle script the response to invocations of hub.method. Hub and method names are case insensitive,
+//as they are in SignalR. Unhandled invocations return a nil result.
+func (s *Server) Handle(hub, method string, handler HubHandler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.handlers[handlerKey(hub, method)] = handler
+}
+
+//Invoke call a client method on every started connection.
+func (s *Server) Invoke(hub, method string, arguments ...interface{}) error {
+	return s.broadcast(clientInvocation{hub, method, arguments})
+}
+
+//InvokeCompressed like Invoke, but each argument is sent the way bittrex sends compressed payloads:
+//raw deflate of the JSON encoding, base64 encoded into a JSON string.
+func (s *Server) InvokeCompressed(hub, method string, arguments ...interface{}) error {
+	compressed := make([]interface{}, len(arguments))
+
+	for i, argument := range arguments {
+		encoded, err := Compress(argument)
+		if err != nil {
+			return err
+		}
+		compressed[i] = encoded
+	}
+
+	return s.broadcast(clientInvocation{hub, method, compressed})
+}
+
+//Compress encode v as JSON, raw deflate it and base64 the result.
+func Compress(v interface{}) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+
+	writer, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := writer.Write(raw); err != nil {
+		return "", err
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func (s *Server) broadcast(invocation clientInvocation) error {
+	s.mutex.Lock()
+	s.cursor++
+	message := persistentMessage{fmt.Sprintf("d-%d", s.cursor), []clientInvocation{invocation}}
+	var targets []*conn
+	for _, c := range s.conns {
+		if c.started {
+			targets = append(targets, c)
+		}
+	}
+	s.mutex.Unlock()
+
+	if len(targets) == 0 {
+		return fmt.Errorf("no started connections")
+	}
+
+	for _, c := range targets {
+		if err := c.write(message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Drop close every open socket without a close handshake, as a network failure would.
+func (s *Server) Drop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for token, c := range s.conns {
+		c.socket.Close()
+		delete(s.conns, token)
+	}
+	s.notify()
+}
+
+//Silence stop (true) or resume (false) keep-alive messages, simulating a stalled connection.
+func (s *Server) Silence(silent bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.silent = silent
+}
+
+//Calls hub invocations received so far, in order.
+func (s *Server) Calls() []Call {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]Call(nil), s.calls...)
+}
+
+//Negotiations number of negotiate requests served.
+func (s *Server) Negotiations() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.negotiates
+}
+
+//Connections number of websocket connections accepted, including dropped ones.
+func (s *Server) Connections() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.connects
+}
+
+//WaitForCall block until a call to hub.method has been received, or timeout passes.
+func (s *Server) WaitForCall(hub, method string, timeout time.Duration) error {
+	return s.waitFor(timeout, func() bool {
+		for _, call := range s.calls {
+			if handlerKey(call.Hub, call.Method) == handlerKey(hub, method) {
+				return true
+			}
+		}
+		return false
+	}, fmt.Sprintf("call to %s.%s", hub, method))
+}
+
+//WaitForStarted block until n connections are open and started, or timeout passes.
+func (s *Server) WaitForStarted(n int, timeout time.Duration) error {
+	return s.waitFor(timeout, func() bool {
+		started := 0
+		for _, c := range s.conns {
+			if c.started {
+				started++
+			}
+		}
+		return started >= n
+	}, fmt.Sprintf("%d started connections", n))
+}
+
+func (s *Server) waitFor(timeout time.Duration, condition func() bool, what string) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		s.mutex.Lock()
+		met := condition()
+		changed := s.changed
+		s.mutex.Unlock()
+
+		if met {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return fmt.Errorf("timeout waiting for %s", what)
+		}
+	}
+}
+
+//notify wake up waiters. Must be called with the mutex held.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+//Close drop every connection and stop the server. All server goroutines have exited when it returns.
+func (s *Server) Close() {
+	s.mutex.Lock()
+	s.closed = true
+	s.mutex.Unlock()
+
+	s.Drop()
+	s.httpServer.Close()
+	s.connWorkers.Wait()
+}
+
+func (s *Server) handleNegotiate(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.negotiates++
+	s.nextToken++
+	token := fmt.Sprintf("token-%d", s.nextToken)
+	keepAlive := s.keepAlive
+	s.notify()
+	s.mutex.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Url":                     "/signalr",
+		"ConnectionToken":         token,
+		"ConnectionId":            token,
+		"KeepAliveTimeout":        (keepAlive * 2).Seconds(),
+		"DisconnectTimeout":       30.0,
+		"ConnectionTimeout":       110.0,
+		"TryWebSockets":           true,
+		"ProtocolVersion":         "1.5",
+		"TransportConnectTimeout": 5.0,
+		"LongPollDelay":           0.0,
+	})
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("connectionToken")
+
+	s.mutex.Lock()
+	c, ok := s.conns[token]
+	if ok {
+		c.started = true
+		s.notify()
+	}
+	s.mutex.Unlock()
+
+	if !ok {
+		http.Error(w, "unknown connection token", http.StatusBadRequest)
+		return
+	}
+
+	w.Write([]byte(`{"Response":"started"}`))
+}
+
+func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("connectionToken")
+
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		http.Error(w, "server closed", http.StatusServiceUnavailable)
+		return
+	}
+	s.mutex.Unlock()
+
+	socket, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &conn{socket: socket, done: make(chan struct{})}
+
+	s.mutex.Lock()
+	s.conns[token] = c
+	s.connects++
+	s.connWorkers.Add(2)
+	s.notify()
+	s.mutex.Unlock()
+
+	//init message
+	c.write(map[string]interface{}{"C": "s-0", "S": 1, "M": []interface{}{}})
+
+	go s.keepAliveLoop(c)
+
+	defer s.connWorkers.Done()
+	defer close(c.done)
+	defer s.removeConn(token, c)
+
+	for {
+		var request hubRequest
+
+		if _, data, err := socket.ReadMessage(); err != nil {
+			return
+		} else if err := json.Unmarshal(data, &request); err != nil {
+			continue
+		}
+
+		s.serveCall(c, request)
+	}
+}
+
+func (s *Server) removeConn(token string, c *conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c.socket.Close()
+	if s.conns[token] == c {
+		delete(s.conns, token)
+		s.notify()
+	}
+}
+
+func (s *Server) serveCall(c *conn, request hubRequest) {
+	var identifier string
+	if err := json.Unmarshal(request.Identifier, &identifier); err != nil {
+		identifier = string(request.Identifier)
+	}
+
+	s.mutex.Lock()
+	s.calls = append(s.calls, Call{request.Hub, request.Method, request.Arguments})
+	handler := s.handlers[handlerKey(request.Hub, request.Method)]
+	s.notify()
+	s.mutex.Unlock()
+
+	response := hubResponse{Identifier: identifier}
+
+	if handler != nil {
+		if result, err := handler(request.Arguments); err != nil {
+			response.Error = err.Error()
+		} else {
+			response.Result = result
+		}
+	}
+
+	c.write(response)
+}
+
+func (s *Server) keepAliveLoop(c *conn) {
+	defer s.connWorkers.Done()
+
+	if s.keepAlive <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			s.mutex.Lock()
+			silent := s.silent
+			s.mutex.Unlock()
+
+			if !silent {
+				c.write(struct{}{})
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package bittrex
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+//ErrResyncNeedsMarket sent on Error by an all-markets subscription configured with OverflowDropAndResync, which
+//is closed without connecting.
+var ErrResyncNeedsMarket = errors.New("OverflowDropAndResync needs a market filter")
+
+//OverflowPolicy what a subscription does with a message when its Data channel is full.
+type OverflowPolicy int
+
+const (
+	//OverflowBlock wait for the reader. Stalls the socket (reads, keep-alives, hub call responses) until there is room.
+	OverflowBlock OverflowPolicy = iota
+
+	//OverflowDropOldest discard the oldest buffered message to make room for the new one.
+	OverflowDropOldest
+
+	//OverflowDropAndResync discard the new message, then once there is room again fetch a fresh
+	//QueryExchangeState snapshot (delivered with Initial set) before resuming deltas.
+	//Use this when the reader maintains an order book and cannot tolerate a gap in Nounce.
+	//Needs a market filter: the snapshot of an all-markets subscription cannot be fetched in one query, so such a
+	//subscription fails with ErrResyncNeedsMarket.
+	OverflowDropAndResync
+)
+
+//SubscriptionConfig channel buffering for websocket subscriptions.
+//The drop policies need a buffer, so a DataBuffer of 0 is raised to 1 for them.
+//Error sends never block the socket: when ErrorBuffer is full the error is dropped and counted.
+type SubscriptionConfig struct {
+	DataBuffer  int
+	ErrorBuffer int
+	Overflow    OverflowPolicy
+}
+
+//SetSubscriptionConfig set the buffering used by subsequent websocket subscriptions.
+func (c *Client) SetSubscriptionConfig(config SubscriptionConfig) {
+	c.subConfig = config
+}
+
+type resyncState int
+
+const (
+	resyncNone resyncState = iota
+	resyncWanted
+	resyncRunning
+)
+
+//subscriptionBuffer delivery side of a BittrexSubscription.
+type subscriptionBuffer struct {
+	policy        OverflowPolicy
+	dropped       uint64
+	droppedErrors uint64
+
+	mutex  sync.Mutex
+	resync resyncState
+	held   []ExchangeState
+}
+
+//Dropped number of exchange states discarded because Data was full.
+func (b *BittrexSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&b.buffer.dropped)
+}
+
+//DroppedErrors number of errors discarded because Error was full.
+func (b *BittrexSubscription) DroppedErrors() uint64 {
+	return atomic.LoadUint64(&b.buffer.droppedErrors)
+}
+
+func (b *BittrexSubscription) sendError(err error) {
+	if b.buffer.policy == OverflowBlock {
+		select {
+		case b.Error <- err:
+		case <-b.closing:
+		}
+		return
+	}
+
+	select {
+	case b.Error <- err:
+	default:
+		atomic.AddUint64(&b.buffer.droppedErrors, 1)
+	}
+}
+
+func (b *BittrexSubscription) sendData(state ExchangeState) {
+	switch b.buffer.policy {
+	case OverflowDropOldest:
+		b.buffer.mutex.Lock()
+		defer b.buffer.mutex.Unlock()
+
+		for {
+			select {
+			case b.Data <- state:
+				return
+			default:
+			}
+
+			select {
+			case <-b.Data:
+				atomic.AddUint64(&b.buffer.dropped, 1)
+			default:
+			}
+		}
+	case OverflowDropAndResync:
+		b.sendDataOrResync(state)
+	default:
+		select {
+		case b.Data <- state:
+		case <-b.closing:
+		}
+	}
+}
+
+func (b *BittrexSubscription) sendDataOrResync(state ExchangeState) {
+	b.buffer.mutex.Lock()
+	defer b.buffer.mutex.Unlock()
+
+	switch b.buffer.resync {
+	case resyncRunning:
+		if len(b.buffer.held) >= cap(b.Data) {
+			//too far behind for the snapshot to catch up, start over once this one lands.
+			atomic.AddUint64(&b.buffer.dropped, uint64(len(b.buffer.held)+1))
+			b.buffer.held = nil
+			b.buffer.resync = resyncWanted
+			return
+		}
+		b.buffer.held = append(b.buffer.held, state)
+		return
+	case resyncWanted:
+		atomic.AddUint64(&b.buffer.dropped, 1)
+		if len(b.Data) < cap(b.Data) {
+			b.buffer.resync = resyncRunning
+			b.buffer.held = nil
+			if b.enter() {
+				go b.resyncSnapshot()
+			}
+		}
+		return
+	}
+
+	select {
+	case b.Data <- state:
+	default:
+		atomic.AddUint64(&b.buffer.dropped, 1)
+		b.buffer.resync = resyncWanted
+	}
+}
+
+//resyncSnapshot runs off the dispatch goroutine, since CallHub needs dispatch to route the response.
+func (b *BittrexSubscription) resyncSnapshot() {
+	defer b.inflight.Done()
+
+	snapshot, err := b.queryExchangeState()
+
+	if err == nil {
+		select {
+		case b.Data <- snapshot:
+		case <-b.closing:
+			return
+		}
+	} else {
+		b.sendError(err)
+	}
+
+	b.buffer.mutex.Lock()
+	defer b.buffer.mutex.Unlock()
+
+	held := b.buffer.held
+	b.buffer.held = nil
+
+	if err != nil || b.buffer.resync == resyncWanted {
+		b.buffer.resync = resyncWanted
+		atomic.AddUint64(&b.buffer.dropped, uint64(len(held)))
+		return
+	}
+
+	b.buffer.resync = resyncNone
+
+	for i, state := range held {
+		if state.Nounce <= snapshot.Nounce {
+			continue
+		}
+
+		select {
+		case b.Data <- state:
+		default:
+			atomic.AddUint64(&b.buffer.dropped, uint64(len(held)-i))
+			b.buffer.resync = resyncWanted
+			return
+		}
+	}
+}
+
+//queryExchangeState fetch a snapshot of the book. The hub sends it without Initial and with a null MarketName, so
+//both are filled in here, for the market filter and for LocalOrderBook.Apply.
+func (b *BittrexSubscription) queryExchangeState() (ExchangeState, error) {
+	var param interface{}
+
+	if b.market != "" {
+		param = b.market
+	}
+
+	queryMethod := "QueryExchangeState"
+
+	queryResponse, callHubErr := b.wsClient.CallHub(websocketHub, queryMethod, param)
+	if callHubErr != nil {
+		return ExchangeState{}, fmt.Errorf("QueryExchangeState Error: %s", callHubErr.Error())
+	}
+
+	snapshot, err := b.decodeState(websocketHub, queryMethod, queryResponse)
+	if err != nil {
+		return ExchangeState{}, err
+	}
+
+	snapshot.Initial = true
+	if b.market != "" {
+		snapshot.MarketName = b.market
+	}
+
+	return snapshot, nil
+}
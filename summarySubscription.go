@@ -0,0 +1,118 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+//SummaryState an updateSummaryState message: the summaries of the markets that changed since the previous one.
+type SummaryState struct {
+	Nounce int
+	Deltas []MarketSummary
+}
+
+//SummarySubscription market summary deltas for every market, from the Bittrex websocket API. Data follows the
+//client's SubscriptionConfig, except that both drop policies drop the oldest state: there is no snapshot to resync
+//from. Call Close to shut it down. Data and Error are closed once every goroutine that could send on them has exited.
+type SummarySubscription struct {
+	Data  chan SummaryState
+	Error chan error
+
+	sub *BittrexSubscription
+}
+
+//WsSubSummaryDeltas - Undocumented websocket endpoint for bittrex (SubscribeToSummaryDeltas).
+//Like WsSubExchangeUpdates, it needs a cloudflare clearance cookie; see SetWebsocketCookies.
+func (c *Client) WsSubSummaryDeltas() *SummarySubscription {
+	sub := newSub("", c.timeout, c.wsConfig, c.subConfig)
+
+	s := &SummarySubscription{
+		Data:  make(chan SummaryState, cap(sub.Data)),
+		Error: sub.Error,
+		sub:   sub,
+	}
+
+	sub.onFinish = func() {
+		close(s.Data)
+	}
+	sub.setSubClientMethods("updateSummaryState", s.parseMessage)
+
+	go func() {
+		defer sub.finish()
+
+		if !sub.connect() {
+			return
+		}
+
+		if _, callHubErr := sub.wsClient.CallHub(websocketHub, "SubscribeToSummaryDeltas"); callHubErr != nil {
+			sub.sendError(fmt.Errorf("SubscribeToSummaryDeltas Error: %s", callHubErr.Error()))
+			return
+		}
+
+		select {
+		case <-sub.closing:
+		case <-sub.wsClient.DisconnectedChannel:
+			sub.sendError(fmt.Errorf("socket closed by remote host"))
+		}
+	}()
+
+	return s
+}
+
+//Close stop the subscription and wait for its goroutines to exit, as BittrexSubscription.Close.
+func (s *SummarySubscription) Close(ctx context.Context) error {
+	return s.sub.Close(ctx)
+}
+
+//Dropped number of summary states discarded because Data was full.
+func (s *SummarySubscription) Dropped() uint64 {
+	return s.sub.Dropped()
+}
+
+//DroppedErrors number of errors discarded because Error was full.
+func (s *SummarySubscription) DroppedErrors() uint64 {
+	return s.sub.DroppedErrors()
+}
+
+func (s *SummarySubscription) parseMessage(hub, method string, msg json.RawMessage) {
+	var state SummaryState
+
+	if decoded, decodeErr := decodeMessage(msg); decodeErr != nil {
+		s.sub.sendError(newSubError(hub, method, msg, decodeErr))
+		return
+	} else if parseErr := json.Unmarshal(decoded, &state); parseErr != nil {
+		s.sub.sendError(newSubError(hub, method, msg, parseErr))
+		return
+	}
+
+	s.send(state)
+}
+
+func (s *SummarySubscription) send(state SummaryState) {
+	if s.sub.buffer.policy == OverflowBlock {
+		select {
+		case s.Data <- state:
+		case <-s.sub.closing:
+		}
+		return
+	}
+
+	s.sub.buffer.mutex.Lock()
+	defer s.sub.buffer.mutex.Unlock()
+
+	for {
+		select {
+		case s.Data <- state:
+			return
+		default:
+		}
+
+		select {
+		case <-s.Data:
+			atomic.AddUint64(&s.sub.buffer.dropped, 1)
+		default:
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWsSubSummaryDeltas(t *testing.T) {
+	server, client := newTestHub()
+	defer server.Close()
+
+	server.Handle(websocketHub, "SubscribeToSummaryDeltas", func(args []json.RawMessage) (interface{}, error) {
+		return true, nil
+	})
+
+	sub := client.WsSubSummaryDeltas()
+
+	scanner := newArbitrageScanner(arbitrageMarkets(), []string{"USDT"}, nil, nil)
+	scanner.Follow(sub.Data)
+
+	if err := server.WaitForCall(websocketHub, "SubscribeToSummaryDeltas", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	//the summaries of arbitrageSummaries, the way the hub sends them
+	first := json.RawMessage(`{"Nounce": 1, "Deltas": [
+		{"MarketName": "USDT-BTC", "Bid": 9990, "Ask": 10000, "TimeStamp": "2018-01-01T00:00:00.5"},
+		{"MarketName": "BTC-LTC", "Bid": 0.0099, "Ask": 0.01, "TimeStamp": "2018-01-01T00:00:00.5"}
+	]}`)
+	second := json.RawMessage(`{"Nounce": 2, "Deltas": [
+		{"MarketName": "USDT-LTC", "Bid": 102, "Ask": 103, "TimeStamp": "2018-01-01T00:00:01"}
+	]}`)
+
+	if err := server.Invoke(websocketHub, "updateSummaryState", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.InvokeCompressed(websocketHub, "updateSummaryState", second); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(scanner.Opportunities()) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the deltas to reach the scanner")
+		}
+
+		select {
+		case e := <-sub.Error:
+			t.Fatalf("subscription error %s", e.Error())
+		default:
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := sub.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-sub.Data; ok {
+		t.Error("expected Data closed")
+	}
+}
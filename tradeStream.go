@@ -0,0 +1,273 @@
+package bittrex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+const tradeStreamSeenSize = 2048
+
+var (
+	//tradeStreamRetry delay before resubscribing after the socket drops.
+	tradeStreamRetry = 5 * time.Second
+)
+
+//TradeGapError sent on a TradeStream's Error channel when the market history used for backfill
+//does not reach back to the last trade on the tape: trades between From and To may be missing.
+type TradeGapError struct {
+	Market string
+	From   time.Time
+	To     time.Time
+}
+
+func (e TradeGapError) Error() string {
+	return fmt.Sprintf("trade tape gap in %s between %s and %s", e.Market, e.From.Format(time.RFC3339), e.To.Format(time.RFC3339))
+}
+
+//TradeStream continuous tape of executed trades for one market, built from the Fills of exchange deltas.
+//It is backfilled from /public/getmarkethistory on start, after every reconnect and whenever a Nounce gap shows
+//that deltas were missed. Trades are de-duplicated, so each is delivered once and in timestamp order.
+//Fills carry no trade id, so trades are matched on side, price, quantity and timestamp (to the second).
+type TradeStream struct {
+	Trades chan Trade
+	Error  chan error
+	market string
+	client *Client
+
+	//history source for backfill, PublicGetMarketHistory outside tests.
+	history func(market string) ([]Trade, error)
+
+	seen      map[string]bool
+	seenOrder []string
+	last      time.Time
+	nounce    int
+
+	mutex     sync.Mutex
+	sub       *BittrexSubscription
+	closeOnce sync.Once
+	closing   chan struct{}
+	closed    chan struct{}
+}
+
+//WsTradeStream start a TradeStream for market (ex: "BTC-LTC"). Stop it with Close.
+func (c *Client) WsTradeStream(market string) *TradeStream {
+	//the stream backfills from its own goroutine, so it gets its own session.
+	t := newTradeStream(c, market, c.session().PublicGetMarketHistory)
+
+	go t.run()
+
+	return t
+}
+
+func newTradeStream(c *Client, market string, history func(string) ([]Trade, error)) *TradeStream {
+	return &TradeStream{
+		Trades:  make(chan Trade),
+		Error:   make(chan error),
+		market:  market,
+		client:  c,
+		history: history,
+		seen:    make(map[string]bool),
+		closing: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+//Close stop the stream and its subscription. Trades and Error are closed when it returns nil.
+func (t *TradeStream) Close(ctx context.Context) error {
+	t.closeOnce.Do(func() {
+		close(t.closing)
+
+		t.mutex.Lock()
+		sub := t.sub
+		t.mutex.Unlock()
+
+		if sub != nil {
+			sub.shutdown()
+		}
+	})
+
+	select {
+	case <-t.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *TradeStream) run() {
+	defer func() {
+		close(t.Trades)
+		close(t.Error)
+		close(t.closed)
+	}()
+
+	for {
+		t.mutex.Lock()
+		select {
+		case <-t.closing:
+			t.mutex.Unlock()
+			return
+		default:
+		}
+		sub := t.client.WsSubExchangeUpdates(t.market)
+		t.sub = sub
+		t.mutex.Unlock()
+
+		t.consume(sub)
+		sub.Close(context.Background())
+
+		retry := time.NewTimer(tradeStreamRetry)
+		select {
+		case <-t.closing:
+			retry.Stop()
+			return
+		case <-retry.C:
+		}
+	}
+}
+
+func (t *TradeStream) consume(sub *BittrexSubscription) {
+	//a new subscription always starts with a backfill.
+	t.nounce = 0
+
+	errors := sub.Error
+
+	for {
+		select {
+		case <-t.closing:
+			return
+		case err, ok := <-errors:
+			if !ok {
+				errors = nil
+				continue
+			}
+			t.sendError(err)
+		case state, ok := <-sub.Data:
+			if !ok {
+				return
+			}
+			t.handleState(state)
+		}
+	}
+}
+
+func (t *TradeStream) handleState(state ExchangeState) {
+	if state.Initial || t.nounce == 0 || state.Nounce != t.nounce+1 {
+		t.backfill()
+	}
+	t.nounce = state.Nounce
+
+	fills := make([]Fill, len(state.Fills))
+	copy(fills, state.Fills)
+
+	sort.SliceStable(fills, func(i, j int) bool {
+		return fills[i].Timestamp.Before(fills[j].Timestamp)
+	})
+
+	for _, fill := range fills {
+		t.emit(Trade{
+			TimeStamp: fill.Timestamp,
+			Quantity:  fill.Quantity,
+			Price:     fill.Rate,
+			Total:     new(big.Float).Mul(fill.Quantity, fill.Rate),
+			OrderType: fill.OrderType,
+		})
+	}
+}
+
+func (t *TradeStream) backfill() {
+	trades, err := t.history(t.market)
+	if err != nil {
+		t.sendError(err)
+		return
+	}
+
+	if len(trades) == 0 {
+		return
+	}
+
+	sorted := make([]Trade, len(trades))
+	copy(sorted, trades)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TimeStamp.Before(sorted[j].TimeStamp)
+	})
+
+	oldest := time.Time(sorted[0].TimeStamp)
+	if !t.last.IsZero() && oldest.Truncate(time.Second).After(t.last) {
+		t.sendError(TradeGapError{t.market, t.last, oldest})
+	}
+
+	for _, trade := range sorted {
+		t.emit(trade)
+	}
+}
+
+//tradeContent what identifies a trade without an Id: its side, exact price and quantity, and second.
+func tradeContent(trade Trade) string {
+	return fmt.Sprintf(
+		"%s|%s|%s|%d",
+		trade.OrderType,
+		trade.Price.Text('g', -1),
+		trade.Quantity.Text('g', -1),
+		time.Time(trade.TimeStamp).Truncate(time.Second).Unix(),
+	)
+}
+
+//emit deliver trade unless it was already delivered or is older than the tape. Trades from the market history are
+//known by their Id; socket fills carry none, so they are matched on content against each other and the history.
+func (t *TradeStream) emit(trade Trade) {
+	if trade.Price == nil || trade.Quantity == nil {
+		return
+	}
+
+	timestamp := time.Time(trade.TimeStamp).Truncate(time.Second)
+	if timestamp.Before(t.last) {
+		return
+	}
+
+	content := tradeContent(trade)
+
+	if trade.ID != "" {
+		if t.seen["id|"+trade.ID] || t.seen["fill|"+content] {
+			return
+		}
+		t.remember("id|"+trade.ID, "history|"+content)
+	} else {
+		if t.seen["fill|"+content] || t.seen["history|"+content] {
+			return
+		}
+		t.remember("fill|" + content)
+	}
+
+	t.last = timestamp
+	trade.Market = t.market
+
+	select {
+	case t.Trades <- trade:
+	case <-t.closing:
+	}
+}
+
+func (t *TradeStream) remember(keys ...string) {
+	for _, key := range keys {
+		t.seen[key] = true
+		t.seenOrder = append(t.seenOrder, key)
+	}
+
+	for len(t.seenOrder) > tradeStreamSeenSize {
+		delete(t.seen, t.seenOrder[0])
+		t.seenOrder = t.seenOrder[1:]
+	}
+}
+
+func (t *TradeStream) sendError(err error) {
+	select {
+	case t.Error <- err:
+	case <-t.closing:
+	}
+}
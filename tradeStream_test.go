@@ -0,0 +1,235 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testTradeTime(second int) string {
+	return fmt.Sprintf("2018-01-01T00:00:%02d.5", second)
+}
+
+func testHistoryTrade(second int) Trade {
+	var timestamp BittrexTimestamp
+	json.Unmarshal([]byte(`"`+testTradeTime(second)+`"`), &timestamp)
+
+	return Trade{
+		ID:        fmt.Sprintf("%d", second),
+		TimeStamp: timestamp,
+		Quantity:  big.NewFloat(1),
+		Price:     big.NewFloat(float64(second)),
+		Total:     big.NewFloat(float64(second)),
+		FillType:  "FILL",
+		OrderType: "BUY",
+	}
+}
+
+func testFill(second int) map[string]interface{} {
+	return map[string]interface{}{"OrderType": "BUY", "Rate": second, "Quantity": 1, "TimeStamp": testTradeTime(second)}
+}
+
+func expectTrade(t *testing.T, stream *TradeStream, second int) {
+	select {
+	case trade := <-stream.Trades:
+		price, _ := trade.Price.Float64()
+		if trade.Market != "BTC-LTC" || int(price) != second {
+			t.Errorf("unexpected trade %+v, wanted price %d", trade, second)
+		}
+	case err := <-stream.Error:
+		t.Fatalf("stream error %s", err.Error())
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for trade %d", second)
+	}
+}
+
+func TestTradeStreamDedupe(t *testing.T) {
+	stream := newTradeStream(nil, "BTC-LTC", nil)
+
+	var delivered []Trade
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for trade := range stream.Trades {
+			delivered = append(delivered, trade)
+		}
+	}()
+
+	trade := func(id, price string) Trade {
+		trade := testHistoryTrade(1)
+		trade.ID, trade.Price = id, dec(price)
+		return trade
+	}
+
+	//prices only telling apart past the tenth significant digit, and two identical trades with their own Ids
+	stream.emit(trade("1", "0.012345678901"))
+	stream.emit(trade("2", "0.012345678902"))
+	stream.emit(trade("3", "0.012345678902"))
+	stream.emit(trade("3", "0.012345678902"))
+
+	//the same trade as a socket fill, without an Id
+	stream.emit(trade("", "0.012345678901"))
+	stream.emit(trade("", "0.5"))
+	stream.emit(trade("", "0.5"))
+
+	close(stream.Trades)
+	<-done
+
+	want := []string{"1", "2", "3", ""}
+	if len(delivered) != len(want) {
+		t.Fatalf("expected %d trades, got %+v", len(want), delivered)
+	}
+	for i, id := range want {
+		if delivered[i].ID != id {
+			t.Errorf("trade %d: expected id %q, got %+v", i, id, delivered[i])
+		}
+	}
+}
+
+func TestTradeStream(t *testing.T) {
+	server, client := newTestHub()
+	defer server.Close()
+
+	var mutex sync.Mutex
+	history := []int{1, 2}
+	snapshotFills := []interface{}{testFill(2)}
+
+	server.Handle(websocketHub, "QueryExchangeState", func(args []json.RawMessage) (interface{}, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return map[string]interface{}{"MarketName": "BTC-LTC", "Nounce": 1, "Fills": snapshotFills}, nil
+	})
+
+	defer func(retry time.Duration) { tradeStreamRetry = retry }(tradeStreamRetry)
+	tradeStreamRetry = 10 * time.Millisecond
+
+	stream := newTradeStream(client, "BTC-LTC", func(market string) ([]Trade, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		//newest first, like the api
+		var trades []Trade
+		for i := len(history) - 1; i >= 0; i-- {
+			trades = append(trades, testHistoryTrade(history[i]))
+		}
+		return trades, nil
+	})
+	go stream.run()
+
+	invoke := func(nounce int, fills ...interface{}) {
+		if err := server.Invoke(websocketHub, "updateExchangeState", map[string]interface{}{"MarketName": "BTC-LTC", "Nounce": nounce, "Fills": fills}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//backfill on start, snapshot fill is a duplicate
+	expectTrade(t, stream, 1)
+	expectTrade(t, stream, 2)
+
+	invoke(2, testFill(3))
+	expectTrade(t, stream, 3)
+
+	//nounce 3 was missed, the backfill recovers trade 4
+	mutex.Lock()
+	history = []int{2, 3, 4}
+	mutex.Unlock()
+
+	invoke(4, testFill(5))
+	expectTrade(t, stream, 4)
+	expectTrade(t, stream, 5)
+
+	//reconnect: snapshot and backfill only repeat known trades
+	mutex.Lock()
+	history = []int{3, 4, 5}
+	snapshotFills = []interface{}{testFill(5)}
+	mutex.Unlock()
+
+	server.Drop()
+
+	select {
+	case err := <-stream.Error:
+		if err == nil {
+			t.Errorf("expected disconnect error")
+		}
+	case trade := <-stream.Trades:
+		t.Fatalf("unexpected trade %+v", trade)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for disconnect")
+	}
+
+	if err := server.WaitForStarted(1, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.WaitForCall(websocketHub, "QueryExchangeState", 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	//the resubscribe may still be processing its snapshot; the same delta arriving twice is harmless
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		invoke(2, testFill(6))
+
+		select {
+		case trade := <-stream.Trades:
+			if price, _ := trade.Price.Float64(); price != 6 {
+				t.Fatalf("unexpected trade %+v, wanted price 6", trade)
+			}
+		case err := <-stream.Error:
+			t.Fatalf("stream error %s", err.Error())
+		case <-time.After(50 * time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatalf("timeout waiting for trade 6")
+			}
+			continue
+		}
+		break
+	}
+
+	//history no longer reaches back to trade 6
+	mutex.Lock()
+	history = []int{10}
+	mutex.Unlock()
+
+	invoke(9, testFill(11))
+
+	select {
+	case err := <-stream.Error:
+		if _, ok := err.(TradeGapError); !ok {
+			t.Errorf("expected TradeGapError, got %s", err.Error())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for gap error")
+	}
+
+	expectTrade(t, stream, 10)
+	expectTrade(t, stream, 11)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := stream.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %s", err.Error())
+	}
+}
+
+func TestFillUnmarshal(t *testing.T) {
+	var state ExchangeState
+
+	raw := `{"MarketName":"BTC-LTC","Nounce":3,"Buys":[{"Type":2,"Rate":0.5,"Quantity":3}],"Fills":[{"OrderType":"SELL","Rate":0.5,"Quantity":1,"TimeStamp":"2018-01-01T00:00:01.5"}]}`
+
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		t.Fatal(err)
+	}
+
+	if state.Buys[0].Type != 2 || state.Buys[0].Rate == nil {
+		t.Errorf("unexpected order update %+v", state.Buys[0])
+	}
+
+	if state.Fills[0].OrderType != "SELL" || time.Time(state.Fills[0].Timestamp).Second() != 1 {
+		t.Errorf("unexpected fill %+v", state.Fills[0])
+	}
+}
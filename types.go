@@ -151,15 +151,16 @@ type OrderBook struct {
 	Sell []OrderElement `json:"sell"`
 }
 
-//Trade result element as described under /public/getmarkethistory
+//Trade result element as described under /public/getmarkethistory. Also the element of a TradeStream.
 type Trade struct {
+	Market    string           `json:"Market"`    // set by TradeStream, empty from /public/getmarkethistory
 	ID        string           `json:"Id"`        // : 319435,
 	TimeStamp BittrexTimestamp `json:"TimeStamp"` // : "2014-07-09T03:21:20.08",
 	Quantity  *big.Float       `json:"Quantity"`  // : 0.30802438,
 	Price     *big.Float       `json:"Price"`     // : 0.01263400,
 	Total     *big.Float       `json:"Total"`     // : 0.00389158,
-	FillType  string           `json:"FillType"`  // : "FILL",
-	OrderType string           `json:"OrderType"` // : "BUY" or "SELL"
+	FillType  FillType         `json:"FillType"`  // : "FILL",
+	OrderType OrderSide        `json:"OrderType"` // : "BUY" or "SELL"
 }
 
 //TransactionID Result body of /market/buylimit and /market/sellimit
@@ -172,7 +173,7 @@ type OrderDescription struct {
 	UUID              string           `json:"Uuid"`              // : null,
 	OrderUUID         string           `json:"OrderUuid"`         // : "09aa5bb6-8232-41aa-9b78-a5a1093e0211",
 	Exchange          string           `json:"Exchange"`          // : "BTC-LTC",
-	OrderType         string           `json:"OrderType"`         // : "LIMIT_SELL",
+	OrderType         OrderType        `json:"OrderType"`         // : "LIMIT_SELL",
 	Quantity          *big.Float       `json:"Quantity"`          // : 5.00000000,
 	QuantityRemaining *big.Float       `json:"QuantityRemaining"` // : 5.00000000,
 	Limit             *big.Float       `json:"Limit"`             // : 2.00000000,
@@ -184,7 +185,7 @@ type OrderDescription struct {
 	CancelInitiated   bool             `json:"CancelInitiated"`   // : false,
 	ImmediateOrCancel bool             `json:"ImmediateOrCancel"` // : false,
 	IsConditional     bool             `json:"IsConditional"`     // : false,
-	Condition         string           `json:"Condition"`         // : null,
+	Condition         OrderCondition   `json:"Condition"`         // : null,
 	ConditionTarget   string           `json:"ConditionTarget"`   // : null
 }
 
@@ -193,7 +194,7 @@ type AccountOrderDescription struct {
 	AccountID                  string           `json:"AccountId"`                  // : null,
 	OrderUUID                  string           `json:"OrderUuid"`                  // : "0cb4c4e4-bdc7-4e13-8c13-430e587d2cc1",
 	Exchange                   string           `json:"Exchange"`                   // : "BTC-SHLD",
-	Type                       string           `json:"Type"`                       // : "LIMIT_BUY",
+	Type                       OrderType        `json:"Type"`                       // : "LIMIT_BUY",
 	Quantity                   *big.Float       `json:"Quantity"`                   // : 1000.00000000,
 	QuantityRemaining          *big.Float       `json:"QuantityRemaining"`          // : 1000.00000000,
 	Limit                      *big.Float       `json:"Limit"`                      // : 0.00000001,
@@ -211,7 +212,7 @@ type AccountOrderDescription struct {
 	CancelInitiated            bool             `json:"CancelInitiated"`            // : false,
 	ImmediateOrCancel          bool             `json:"ImmediateOrCancel"`          // : false,
 	IsConditional              bool             `json:"IsConditional"`              // : false,
-	Condition                  string           `json:"Condition"`                  // : "NONE",
+	Condition                  OrderCondition   `json:"Condition"`                  // : "NONE",
 	ConditionTarget            string           `json:"ConditionTarget"`            // : null
 }
 
@@ -220,7 +221,7 @@ type AccountOrderHistoryDescription struct {
 	OrderUUID         string           `json:"OrderUuid"`         // : "fd97d393-e9b9-4dd1-9dbf-f288fc72a185",
 	Exchange          string           `json:"Exchange"`          // : "BTC-LTC",
 	TimeStamp         BittrexTimestamp `json:"TimeStamp"`         // : "2014-07-09T04:01:00.667",
-	OrderType         string           `json:"OrderType"`         // : "LIMIT_BUY",
+	OrderType         OrderType        `json:"OrderType"`         // : "LIMIT_BUY",
 	Limit             *big.Float       `json:"Limit"`             // : 0.00000001,
 	Quantity          *big.Float       `json:"Quantity"`          // : 100000.00000000,
 	QuantityRemaining *big.Float       `json:"QuantityRemaining"` // : 100000.00000000,
@@ -228,7 +229,7 @@ type AccountOrderHistoryDescription struct {
 	Price             *big.Float       `json:"Price"`             // : 0.00000000,
 	PricePerUnit      *big.Float       `json:"PricePerUnit"`      // : null,
 	IsConditional     bool             `json:"IsConditional"`     // : false,
-	Condition         string           `json:"Condition"`         // : null,
+	Condition         OrderCondition   `json:"Condition"`         // : null,
 	ConditionTarget   string           `json:"ConditionTarget"`   // : null,
 	ImmediateOrCancel bool             `json:"ImmediateOrCancel"` // : false
 }
@@ -281,13 +282,13 @@ type Candle struct {
 //OrderUpdate Update to an order listed under buys and sells in ExchangeState
 type OrderUpdate struct {
 	OrderElement //embed
-	Type         int
+	Type         OrderUpdateType
 }
 
 //Fill structure found inside an ExchangeState object
 type Fill struct {
 	OrderElement //embed
-	OrderType    string
+	OrderType    OrderSide
 	Timestamp    BittrexTimestamp
 }
 